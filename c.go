@@ -107,6 +107,10 @@ extern size_t colfer_size_max;
 // colfer_list_max is the upper limit for the number of elements in a list.
 extern size_t colfer_list_max;
 
+// colfer_depth_max is the upper limit for the nesting level of data
+// structures, with 1 for the top-level.
+extern size_t colfer_depth_max;
+
 
 // colfer_text is a UTF-8 CLOB.
 typedef struct {
@@ -161,9 +165,18 @@ struct {{.NameNative}} {
 {{- end}}
 };
 
+// {{.NameNative}}_fingerprint is the schema digest, which changes with any
+// change to the fields, including the data structures referred to.
+extern const char {{.NameNative}}_fingerprint[];
+
 // {{.NameNative}}_marshal_len returns the Colfer serial octet size.
 // When the return is zero then errno is set to EFBIG to indicate a breach of
+{{- if .Pkg.StrictText}}
+// either colfer_size_max or colfer_list_max, or to EBADMSG to indicate
+// malformed UTF-8 in text.
+{{- else}}
 // either colfer_size_max or colfer_list_max.
+{{- end}}
 size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o);
 
 // {{.NameNative}}_marshal encodes o as Colfer into buf and returns the number
@@ -173,10 +186,37 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf);
 // {{.NameNative}}_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
 // colfer_size_max, whichever occurs first.
-// When the return is zero then errno is set to one of the following 3 values:
+{{- if .Pkg.StrictText}}
+// When the return is zero then errno is set to one of the following 5 values:
 // EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// or colfer_list_max, ELOOP on a breach of colfer_depth_max, EBADMSG on
+// malformed UTF-8 in text and EILSEQ on schema mismatch.
+{{- else}}
+// When the return is zero then errno is set to one of the following 4 values:
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
+// or colfer_list_max, ELOOP on a breach of colfer_depth_max and EILSEQ on
+// schema mismatch.
+{{- end}}
 size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t datalen);
+{{- if .Pkg.Canonical}}
+
+// {{.NameNative}}_unmarshal_canonical decodes data like
+// {{.NameNative}}_unmarshal, yet it rejects any serial other than the one
+// {{.NameNative}}_marshal produces for the result. Equal values have identical
+// serials as such. Data after the serial is rejected too. When the return is
+// zero then errno is set like {{.NameNative}}_unmarshal does, or to EPROTO on a
+// non-canonical encoding, including data after the serial. The check encodes
+// the result for comparison, which costs about as much as the decoding.
+size_t {{.NameNative}}_unmarshal_canonical({{.NameNative}}* o, const void* data, size_t datalen);
+
+// {{.NameNative}}_canonicalize decodes data like {{.NameNative}}_unmarshal,
+// and it encodes the result into buf like {{.NameNative}}_marshal, which gives
+// the canonical serial. The return is the number of octets written. A buflen
+// of colfer_size_max always suffices. When the return is zero then errno is set
+// like {{.NameNative}}_unmarshal does, to EPROTO on data after the serial, or to
+// ENOBUFS when buflen is too small.
+size_t {{.NameNative}}_canonicalize({{.NameNative}}* o, void* buf, size_t buflen, const void* data, size_t datalen);
+{{- end}}
 {{end}}{{end}}
 
 #ifdef __cplusplus
@@ -210,7 +250,52 @@ const cTemplate = `// Code generated by colf(1); DO NOT EDIT.
 {{with index . 0}}
 size_t colfer_size_max = {{.SizeMax}};
 size_t colfer_list_max = {{.ListMax}};
+size_t colfer_depth_max = {{.DepthMax}};
+{{- if .StrictText}}
+
+// colfer_utf8_valid returns whether the n octets at p are well-formed UTF-8
+// conform RFC 3629, i.e., no overlong encodings, no surrogate halves and no
+// code points beyond U+10FFFF.
+static int colfer_utf8_valid(const uint8_t* p, size_t n) {
+	if (!n) return 1;
+	const uint8_t* end = p + n;
+	while (p < end) {
+		uint_fast8_t c = *p++;
+		if (c < 0x80) continue;
+
+		size_t follow;
+		uint_fast8_t lo = 0x80, hi = 0xbf;
+		if (c < 0xc2) {
+			return 0;
+		} else if (c < 0xe0) {
+			follow = 1;
+		} else if (c < 0xf0) {
+			follow = 2;
+			if (c == 0xe0) lo = 0xa0;
+			else if (c == 0xed) hi = 0x9f;
+		} else if (c < 0xf5) {
+			follow = 3;
+			if (c == 0xf0) lo = 0x90;
+			else if (c == 0xf4) hi = 0x8f;
+		} else {
+			return 0;
+		}
+
+		if ((size_t) (end - p) < follow) return 0;
+		if (*p < lo || *p > hi) return 0;
+		for (++p, --follow; follow; --follow, ++p)
+			if ((*p & 0xc0) != 0x80) return 0;
+	}
+	return 1;
+}
+{{- end}}
 {{end}}
+{{range .}}{{range .Structs}}
+const char {{.NameNative}}_fingerprint[] = "{{.Fingerprint}}";
+{{- end}}{{end}}
+{{range .}}{{range .Structs}}
+static size_t {{.NameNative}}_unmarshal_depth({{.NameNative}}* o, const void* data, size_t datalen, size_t depth);
+{{- end}}{{end}}
 
 {{range .}}{{range .Structs}}
 size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
@@ -310,6 +395,12 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 			errno = EFBIG;
 			return 0;
 		}
+  {{- if .Struct.Pkg.StrictText}}
+		if (!colfer_utf8_valid((const uint8_t*) o->{{.NameNative}}.utf8, n)) {
+			errno = EBADMSG;
+			return 0;
+		}
+  {{- end}}
 		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
 	}
  {{- else}}
@@ -327,6 +418,12 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 					errno = EFBIG;
 					return 0;
 				}
+  {{- if .Struct.Pkg.StrictText}}
+				if (!colfer_utf8_valid((const uint8_t*) a[i].utf8, len)) {
+					errno = EBADMSG;
+					return 0;
+				}
+  {{- end}}
 				for (l += len + 1; len > 127; len >>= 7, ++l);
 			}
 			for (l += 2; n > 127; n >>= 7, ++l);
@@ -521,6 +618,10 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf) {
 		*p++ = x >> 8;
 		*p++ = x;
 #endif
+  {{- if .Struct.Pkg.Canonical}}
+		// canonical NaN
+		if (o->{{.NameNative}} != o->{{.NameNative}}) memcpy(p - 4, "\x7f\xc0\0\0", 4);
+  {{- end}}
 	}
  {{- else}}
 	{
@@ -547,6 +648,14 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf) {
 				++fp;
 			}
 #endif
+  {{- if .Struct.Pkg.Canonical}}
+			// canonical NaN
+			n = o->{{.NameNative}}.len;
+			for (size_t i = 0; i < n; ++i) {
+				float f = o->{{.NameNative}}.list[i];
+				if (f != f) memcpy(p - (n - i) * 4, "\x7f\xc0\0\0", 4);
+			}
+  {{- end}}
 		}
 	}
  {{- end}}
@@ -570,6 +679,10 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf) {
 		*p++ = x >> 8;
 		*p++ = x;
 #endif
+  {{- if .Struct.Pkg.Canonical}}
+		// canonical NaN
+		if (o->{{.NameNative}} != o->{{.NameNative}}) memcpy(p - 8, "\x7f\xf8\0\0\0\0\0\0", 8);
+  {{- end}}
 	}
  {{- else}}
 	{
@@ -601,6 +714,14 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf) {
 				++fp;
 			}
 #endif
+  {{- if .Struct.Pkg.Canonical}}
+			// canonical NaN
+			n = o->{{.NameNative}}.len;
+			for (size_t i = 0; i < n; ++i) {
+				double f = o->{{.NameNative}}.list[i];
+				if (f != f) memcpy(p - (n - i) * 8, "\x7f\xf8\0\0\0\0\0\0", 8);
+			}
+  {{- end}}
 		}
 	}
  {{- end}}
@@ -749,6 +870,17 @@ size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf) {
 }
 
 size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t datalen) {
+	return {{.NameNative}}_unmarshal_depth(o, data, datalen, 1);
+}
+
+// {{.NameNative}}_unmarshal_depth is {{.NameNative}}_unmarshal with depth as
+// the nesting level of o.
+static size_t {{.NameNative}}_unmarshal_depth({{.NameNative}}* o, const void* data, size_t datalen, size_t depth) {
+	if (depth > colfer_depth_max) {
+		errno = ELOOP;
+		return 0;
+	}
+
 	// octet pointer navigation
 	const uint8_t* p = data;
 	const uint8_t* end;
@@ -1148,6 +1280,12 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 			errno = enderr;
 			return 0;
 		}
+  {{- if .Struct.Pkg.StrictText}}
+		if (!colfer_utf8_valid(p, n)) {
+			errno = EBADMSG;
+			return 0;
+		}
+  {{- end}}
 		o->{{.NameNative}}.len = n;
 
 		void* a = malloc(n);
@@ -1184,6 +1322,11 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 			errno = EFBIG;
 			return 0;
 		}
+		if (p+n >= end) {
+			// elements take one octet at least
+			errno = enderr;
+			return 0;
+		}
 		o->{{.NameNative}}.len = n;
 
 		colfer_text* text = malloc(n * sizeof(colfer_text));
@@ -1217,6 +1360,12 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				errno = enderr;
 				return 0;
 			}
+  {{- if .Struct.Pkg.StrictText}}
+			if (!colfer_utf8_valid(p, len)) {
+				errno = EBADMSG;
+				return 0;
+			}
+  {{- end}}
 			text->len = len;
 
 			char* a = malloc(len);
@@ -1301,6 +1450,11 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 			errno = EFBIG;
 			return 0;
 		}
+		if (p+n >= end) {
+			// elements take one octet at least
+			errno = enderr;
+			return 0;
+		}
 		o->{{.NameNative}}.len = n;
 
 		colfer_binary* binary = malloc(n * sizeof(colfer_binary));
@@ -1355,7 +1509,7 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
  {{- if not .TypeList}}
 	if (header == {{.Index}}) {
 		o->{{.NameNative}} = calloc(1, sizeof({{.TypeRef.NameNative}}));
-		size_t read = {{.TypeRef.NameNative}}_unmarshal(o->{{.NameNative}}, p, (size_t) (end - p));
+		size_t read = {{.TypeRef.NameNative}}_unmarshal_depth(o->{{.NameNative}}, p, (size_t) (end - p), depth + 1);
 		if (!read) {
 			if (errno == EWOULDBLOCK) errno = enderr;
 			return read;
@@ -1394,10 +1548,16 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 			errno = EFBIG;
 			return 0;
 		}
+		if (p+n >= end) {
+			// elements take one octet at least
+			errno = enderr;
+			return 0;
+		}
 
 		{{.TypeRef.NameNative}}* a = calloc(n, sizeof({{.TypeRef.NameNative}}));
 		for (size_t i = 0; i < n; ++i) {
-			size_t read = {{.TypeRef.NameNative}}_unmarshal(&a[i], p, (size_t) (end - p));
+			// reserve one octet for each element pending
+			size_t read = {{.TypeRef.NameNative}}_unmarshal_depth(&a[i], p, (size_t) (end - p) - (n - 1 - i), depth + 1);
 			if (!read) {
 				if (errno == EWOULDBLOCK) errno = enderr;
 				return read;
@@ -1422,4 +1582,49 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 
 	return (size_t) (p - (const uint8_t*) data);
 }
+{{- if .Pkg.Canonical}}
+
+size_t {{.NameNative}}_unmarshal_canonical({{.NameNative}}* o, const void* data, size_t datalen) {
+	size_t n = {{.NameNative}}_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = {{.NameNative}}_marshal_len(o);
+	if (!l) return 0;
+	if (l != n) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	void* serial = malloc(l);
+	{{.NameNative}}_marshal(o, serial);
+	int diff = memcmp(serial, data, l);
+	free(serial);
+	if (diff) {
+		errno = EPROTO;
+		return 0;
+	}
+	return n;
+}
+
+size_t {{.NameNative}}_canonicalize({{.NameNative}}* o, void* buf, size_t buflen, const void* data, size_t datalen) {
+	size_t n = {{.NameNative}}_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = {{.NameNative}}_marshal_len(o);
+	if (!l) return 0;
+	if (l > buflen) {
+		errno = ENOBUFS;
+		return 0;
+	}
+	return {{.NameNative}}_marshal(o, buf);
+}
+{{- end}}
 {{end}}{{end}}`
@@ -19,8 +19,53 @@
 
 size_t colfer_size_max = 16 * 1024 * 1024;
 size_t colfer_list_max = 64 * 1024;
+size_t colfer_depth_max = 100;
+
+// colfer_utf8_valid returns whether the n octets at p are well-formed UTF-8
+// conform RFC 3629, i.e., no overlong encodings, no surrogate halves and no
+// code points beyond U+10FFFF.
+static int colfer_utf8_valid(const uint8_t* p, size_t n) {
+	if (!n) return 1;
+	const uint8_t* end = p + n;
+	while (p < end) {
+		uint_fast8_t c = *p++;
+		if (c < 0x80) continue;
+
+		size_t follow;
+		uint_fast8_t lo = 0x80, hi = 0xbf;
+		if (c < 0xc2) {
+			return 0;
+		} else if (c < 0xe0) {
+			follow = 1;
+		} else if (c < 0xf0) {
+			follow = 2;
+			if (c == 0xe0) lo = 0xa0;
+			else if (c == 0xed) hi = 0x9f;
+		} else if (c < 0xf5) {
+			follow = 3;
+			if (c == 0xf0) lo = 0x90;
+			else if (c == 0xf4) hi = 0x8f;
+		} else {
+			return 0;
+		}
+
+		if ((size_t) (end - p) < follow) return 0;
+		if (*p < lo || *p > hi) return 0;
+		for (++p, --follow; follow; --follow, ++p)
+			if ((*p & 0xc0) != 0x80) return 0;
+	}
+	return 1;
+}
 
 
+const char gen_o_fingerprint[] = "98768e7cc2fcda72";
+const char gen_dromedary_case_fingerprint[] = "f21325a195f2e6e5";
+const char gen_embed_o_fingerprint[] = "1ea394cc8643da1b";
+
+static size_t gen_o_unmarshal_depth(gen_o* o, const void* data, size_t datalen, size_t depth);
+static size_t gen_dromedary_case_unmarshal_depth(gen_dromedary_case* o, const void* data, size_t datalen, size_t depth);
+static size_t gen_embed_o_unmarshal_depth(gen_embed_o* o, const void* data, size_t datalen, size_t depth);
+
 
 size_t gen_o_marshal_len(const gen_o* o) {
 	size_t l = 1;
@@ -85,6 +130,10 @@ size_t gen_o_marshal_len(const gen_o* o) {
 			errno = EFBIG;
 			return 0;
 		}
+		if (!colfer_utf8_valid((const uint8_t*) o->s.utf8, n)) {
+			errno = EBADMSG;
+			return 0;
+		}
 		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
 	}
 
@@ -132,6 +181,10 @@ size_t gen_o_marshal_len(const gen_o* o) {
 					errno = EFBIG;
 					return 0;
 				}
+				if (!colfer_utf8_valid((const uint8_t*) a[i].utf8, len)) {
+					errno = EBADMSG;
+					return 0;
+				}
 				for (l += len + 1; len > 127; len >>= 7, ++l);
 			}
 			for (l += 2; n > 127; n >>= 7, ++l);
@@ -297,6 +350,8 @@ size_t gen_o_marshal(const gen_o* o, void* buf) {
 		*p++ = x >> 8;
 		*p++ = x;
 #endif
+		// canonical NaN
+		if (o->f32 != o->f32) memcpy(p - 4, "\x7f\xc0\0\0", 4);
 	}
 
 	if (o->f64 != 0.0) {
@@ -317,6 +372,8 @@ size_t gen_o_marshal(const gen_o* o, void* buf) {
 		*p++ = x >> 8;
 		*p++ = x;
 #endif
+		// canonical NaN
+		if (o->f64 != o->f64) memcpy(p - 8, "\x7f\xf8\0\0\0\0\0\0", 8);
 	}
 
 	{
@@ -497,6 +554,12 @@ size_t gen_o_marshal(const gen_o* o, void* buf) {
 				++fp;
 			}
 #endif
+			// canonical NaN
+			n = o->f32s.len;
+			for (size_t i = 0; i < n; ++i) {
+				float f = o->f32s.list[i];
+				if (f != f) memcpy(p - (n - i) * 4, "\x7f\xc0\0\0", 4);
+			}
 		}
 	}
 
@@ -529,6 +592,12 @@ size_t gen_o_marshal(const gen_o* o, void* buf) {
 				++fp;
 			}
 #endif
+			// canonical NaN
+			n = o->f64s.len;
+			for (size_t i = 0; i < n; ++i) {
+				double f = o->f64s.list[i];
+				if (f != f) memcpy(p - (n - i) * 8, "\x7f\xf8\0\0\0\0\0\0", 8);
+			}
 		}
 	}
 
@@ -538,6 +607,17 @@ size_t gen_o_marshal(const gen_o* o, void* buf) {
 }
 
 size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
+	return gen_o_unmarshal_depth(o, data, datalen, 1);
+}
+
+// gen_o_unmarshal_depth is gen_o_unmarshal with depth as
+// the nesting level of o.
+static size_t gen_o_unmarshal_depth(gen_o* o, const void* data, size_t datalen, size_t depth) {
+	if (depth > colfer_depth_max) {
+		errno = ELOOP;
+		return 0;
+	}
+
 	// octet pointer navigation
 	const uint8_t* p = data;
 	const uint8_t* end;
@@ -803,6 +883,10 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 			errno = enderr;
 			return 0;
 		}
+		if (!colfer_utf8_valid(p, n)) {
+			errno = EBADMSG;
+			return 0;
+		}
 		o->s.len = n;
 
 		void* a = malloc(n);
@@ -856,7 +940,7 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 
 	if (header == 10) {
 		o->o = calloc(1, sizeof(gen_o));
-		size_t read = gen_o_unmarshal(o->o, p, (size_t) (end - p));
+		size_t read = gen_o_unmarshal_depth(o->o, p, (size_t) (end - p), depth + 1);
 		if (!read) {
 			if (errno == EWOULDBLOCK) errno = enderr;
 			return read;
@@ -895,10 +979,16 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 			errno = EFBIG;
 			return 0;
 		}
+		if (p+n >= end) {
+			// elements take one octet at least
+			errno = enderr;
+			return 0;
+		}
 
 		gen_o* a = calloc(n, sizeof(gen_o));
 		for (size_t i = 0; i < n; ++i) {
-			size_t read = gen_o_unmarshal(&a[i], p, (size_t) (end - p));
+			// reserve one octet for each element pending
+			size_t read = gen_o_unmarshal_depth(&a[i], p, (size_t) (end - p) - (n - 1 - i), depth + 1);
 			if (!read) {
 				if (errno == EWOULDBLOCK) errno = enderr;
 				return read;
@@ -940,6 +1030,11 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 			errno = EFBIG;
 			return 0;
 		}
+		if (p+n >= end) {
+			// elements take one octet at least
+			errno = enderr;
+			return 0;
+		}
 		o->ss.len = n;
 
 		colfer_text* text = malloc(n * sizeof(colfer_text));
@@ -973,6 +1068,10 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 				errno = enderr;
 				return 0;
 			}
+			if (!colfer_utf8_valid(p, len)) {
+				errno = EBADMSG;
+				return 0;
+			}
 			text->len = len;
 
 			char* a = malloc(len);
@@ -1015,6 +1114,11 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 			errno = EFBIG;
 			return 0;
 		}
+		if (p+n >= end) {
+			// elements take one octet at least
+			errno = enderr;
+			return 0;
+		}
 		o->as.len = n;
 
 		colfer_binary* binary = malloc(n * sizeof(colfer_binary));
@@ -1202,6 +1306,49 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 	return (size_t) (p - (const uint8_t*) data);
 }
 
+size_t gen_o_unmarshal_canonical(gen_o* o, const void* data, size_t datalen) {
+	size_t n = gen_o_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = gen_o_marshal_len(o);
+	if (!l) return 0;
+	if (l != n) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	void* serial = malloc(l);
+	gen_o_marshal(o, serial);
+	int diff = memcmp(serial, data, l);
+	free(serial);
+	if (diff) {
+		errno = EPROTO;
+		return 0;
+	}
+	return n;
+}
+
+size_t gen_o_canonicalize(gen_o* o, void* buf, size_t buflen, const void* data, size_t datalen) {
+	size_t n = gen_o_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = gen_o_marshal_len(o);
+	if (!l) return 0;
+	if (l > buflen) {
+		errno = ENOBUFS;
+		return 0;
+	}
+	return gen_o_marshal(o, buf);
+}
+
 size_t gen_dromedary_case_marshal_len(const gen_dromedary_case* o) {
 	size_t l = 1;
 
@@ -1211,6 +1358,10 @@ size_t gen_dromedary_case_marshal_len(const gen_dromedary_case* o) {
 			errno = EFBIG;
 			return 0;
 		}
+		if (!colfer_utf8_valid((const uint8_t*) o->pascal_case.utf8, n)) {
+			errno = EBADMSG;
+			return 0;
+		}
 		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
 	}
 
@@ -1245,6 +1396,17 @@ size_t gen_dromedary_case_marshal(const gen_dromedary_case* o, void* buf) {
 }
 
 size_t gen_dromedary_case_unmarshal(gen_dromedary_case* o, const void* data, size_t datalen) {
+	return gen_dromedary_case_unmarshal_depth(o, data, datalen, 1);
+}
+
+// gen_dromedary_case_unmarshal_depth is gen_dromedary_case_unmarshal with depth as
+// the nesting level of o.
+static size_t gen_dromedary_case_unmarshal_depth(gen_dromedary_case* o, const void* data, size_t datalen, size_t depth) {
+	if (depth > colfer_depth_max) {
+		errno = ELOOP;
+		return 0;
+	}
+
 	// octet pointer navigation
 	const uint8_t* p = data;
 	const uint8_t* end;
@@ -1292,6 +1454,10 @@ size_t gen_dromedary_case_unmarshal(gen_dromedary_case* o, const void* data, siz
 			errno = enderr;
 			return 0;
 		}
+		if (!colfer_utf8_valid(p, n)) {
+			errno = EBADMSG;
+			return 0;
+		}
 		o->pascal_case.len = n;
 
 		void* a = malloc(n);
@@ -1311,6 +1477,49 @@ size_t gen_dromedary_case_unmarshal(gen_dromedary_case* o, const void* data, siz
 	return (size_t) (p - (const uint8_t*) data);
 }
 
+size_t gen_dromedary_case_unmarshal_canonical(gen_dromedary_case* o, const void* data, size_t datalen) {
+	size_t n = gen_dromedary_case_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = gen_dromedary_case_marshal_len(o);
+	if (!l) return 0;
+	if (l != n) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	void* serial = malloc(l);
+	gen_dromedary_case_marshal(o, serial);
+	int diff = memcmp(serial, data, l);
+	free(serial);
+	if (diff) {
+		errno = EPROTO;
+		return 0;
+	}
+	return n;
+}
+
+size_t gen_dromedary_case_canonicalize(gen_dromedary_case* o, void* buf, size_t buflen, const void* data, size_t datalen) {
+	size_t n = gen_dromedary_case_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = gen_dromedary_case_marshal_len(o);
+	if (!l) return 0;
+	if (l > buflen) {
+		errno = ENOBUFS;
+		return 0;
+	}
+	return gen_dromedary_case_marshal(o, buf);
+}
+
 size_t gen_embed_o_marshal_len(const gen_embed_o* o) {
 	size_t l = 1;
 
@@ -1343,6 +1552,17 @@ size_t gen_embed_o_marshal(const gen_embed_o* o, void* buf) {
 }
 
 size_t gen_embed_o_unmarshal(gen_embed_o* o, const void* data, size_t datalen) {
+	return gen_embed_o_unmarshal_depth(o, data, datalen, 1);
+}
+
+// gen_embed_o_unmarshal_depth is gen_embed_o_unmarshal with depth as
+// the nesting level of o.
+static size_t gen_embed_o_unmarshal_depth(gen_embed_o* o, const void* data, size_t datalen, size_t depth) {
+	if (depth > colfer_depth_max) {
+		errno = ELOOP;
+		return 0;
+	}
+
 	// octet pointer navigation
 	const uint8_t* p = data;
 	const uint8_t* end;
@@ -1363,7 +1583,7 @@ size_t gen_embed_o_unmarshal(gen_embed_o* o, const void* data, size_t datalen) {
 
 	if (header == 0) {
 		o->inner = calloc(1, sizeof(gen_o));
-		size_t read = gen_o_unmarshal(o->inner, p, (size_t) (end - p));
+		size_t read = gen_o_unmarshal_depth(o->inner, p, (size_t) (end - p), depth + 1);
 		if (!read) {
 			if (errno == EWOULDBLOCK) errno = enderr;
 			return read;
@@ -1384,3 +1604,46 @@ size_t gen_embed_o_unmarshal(gen_embed_o* o, const void* data, size_t datalen) {
 
 	return (size_t) (p - (const uint8_t*) data);
 }
+
+size_t gen_embed_o_unmarshal_canonical(gen_embed_o* o, const void* data, size_t datalen) {
+	size_t n = gen_embed_o_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = gen_embed_o_marshal_len(o);
+	if (!l) return 0;
+	if (l != n) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	void* serial = malloc(l);
+	gen_embed_o_marshal(o, serial);
+	int diff = memcmp(serial, data, l);
+	free(serial);
+	if (diff) {
+		errno = EPROTO;
+		return 0;
+	}
+	return n;
+}
+
+size_t gen_embed_o_canonicalize(gen_embed_o* o, void* buf, size_t buflen, const void* data, size_t datalen) {
+	size_t n = gen_embed_o_unmarshal(o, data, datalen);
+	if (!n) return 0;
+	if (n != datalen) {
+		errno = EPROTO;
+		return 0;
+	}
+
+	size_t l = gen_embed_o_marshal_len(o);
+	if (!l) return 0;
+	if (l > buflen) {
+		errno = ENOBUFS;
+		return 0;
+	}
+	return gen_embed_o_marshal(o, buf);
+}
@@ -25,6 +25,10 @@ extern size_t colfer_size_max;
 // colfer_list_max is the upper limit for the number of elements in a list.
 extern size_t colfer_list_max;
 
+// colfer_depth_max is the upper limit for the nesting level of data
+// structures, with 1 for the top-level.
+extern size_t colfer_depth_max;
+
 
 // colfer_text is a UTF-8 CLOB.
 typedef struct {
@@ -101,9 +105,14 @@ struct gen_o {
 	} f64s;
 };
 
+// gen_o_fingerprint is the schema digest, which changes with any
+// change to the fields, including the data structures referred to.
+extern const char gen_o_fingerprint[];
+
 // gen_o_marshal_len returns the Colfer serial octet size.
 // When the return is zero then errno is set to EFBIG to indicate a breach of
-// either colfer_size_max or colfer_list_max.
+// either colfer_size_max or colfer_list_max, or to EBADMSG to indicate
+// malformed UTF-8 in text.
 size_t gen_o_marshal_len(const gen_o* o);
 
 // gen_o_marshal encodes o as Colfer into buf and returns the number
@@ -113,20 +122,43 @@ size_t gen_o_marshal(const gen_o* o, void* buf);
 // gen_o_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
 // colfer_size_max, whichever occurs first.
-// When the return is zero then errno is set to one of the following 3 values:
+// When the return is zero then errno is set to one of the following 5 values:
 // EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// or colfer_list_max, ELOOP on a breach of colfer_depth_max, EBADMSG on
+// malformed UTF-8 in text and EILSEQ on schema mismatch.
 size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen);
 
+// gen_o_unmarshal_canonical decodes data like
+// gen_o_unmarshal, yet it rejects any serial other than the one
+// gen_o_marshal produces for the result. Equal values have identical
+// serials as such. Data after the serial is rejected too. When the return is
+// zero then errno is set like gen_o_unmarshal does, or to EPROTO on a
+// non-canonical encoding, including data after the serial. The check encodes
+// the result for comparison, which costs about as much as the decoding.
+size_t gen_o_unmarshal_canonical(gen_o* o, const void* data, size_t datalen);
+
+// gen_o_canonicalize decodes data like gen_o_unmarshal,
+// and it encodes the result into buf like gen_o_marshal, which gives
+// the canonical serial. The return is the number of octets written. A buflen
+// of colfer_size_max always suffices. When the return is zero then errno is set
+// like gen_o_unmarshal does, to EPROTO on data after the serial, or to
+// ENOBUFS when buflen is too small.
+size_t gen_o_canonicalize(gen_o* o, void* buf, size_t buflen, const void* data, size_t datalen);
+
 // DromedaryCase oposes name casings.
 struct gen_dromedary_case {
 
 	colfer_text pascal_case;
 };
 
+// gen_dromedary_case_fingerprint is the schema digest, which changes with any
+// change to the fields, including the data structures referred to.
+extern const char gen_dromedary_case_fingerprint[];
+
 // gen_dromedary_case_marshal_len returns the Colfer serial octet size.
 // When the return is zero then errno is set to EFBIG to indicate a breach of
-// either colfer_size_max or colfer_list_max.
+// either colfer_size_max or colfer_list_max, or to EBADMSG to indicate
+// malformed UTF-8 in text.
 size_t gen_dromedary_case_marshal_len(const gen_dromedary_case* o);
 
 // gen_dromedary_case_marshal encodes o as Colfer into buf and returns the number
@@ -136,11 +168,29 @@ size_t gen_dromedary_case_marshal(const gen_dromedary_case* o, void* buf);
 // gen_dromedary_case_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
 // colfer_size_max, whichever occurs first.
-// When the return is zero then errno is set to one of the following 3 values:
+// When the return is zero then errno is set to one of the following 5 values:
 // EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// or colfer_list_max, ELOOP on a breach of colfer_depth_max, EBADMSG on
+// malformed UTF-8 in text and EILSEQ on schema mismatch.
 size_t gen_dromedary_case_unmarshal(gen_dromedary_case* o, const void* data, size_t datalen);
 
+// gen_dromedary_case_unmarshal_canonical decodes data like
+// gen_dromedary_case_unmarshal, yet it rejects any serial other than the one
+// gen_dromedary_case_marshal produces for the result. Equal values have identical
+// serials as such. Data after the serial is rejected too. When the return is
+// zero then errno is set like gen_dromedary_case_unmarshal does, or to EPROTO on a
+// non-canonical encoding, including data after the serial. The check encodes
+// the result for comparison, which costs about as much as the decoding.
+size_t gen_dromedary_case_unmarshal_canonical(gen_dromedary_case* o, const void* data, size_t datalen);
+
+// gen_dromedary_case_canonicalize decodes data like gen_dromedary_case_unmarshal,
+// and it encodes the result into buf like gen_dromedary_case_marshal, which gives
+// the canonical serial. The return is the number of octets written. A buflen
+// of colfer_size_max always suffices. When the return is zero then errno is set
+// like gen_dromedary_case_unmarshal does, to EPROTO on data after the serial, or to
+// ENOBUFS when buflen is too small.
+size_t gen_dromedary_case_canonicalize(gen_dromedary_case* o, void* buf, size_t buflen, const void* data, size_t datalen);
+
 // EmbedO has an inner object only.
 // Covers regression of issue #66.
 struct gen_embed_o {
@@ -148,9 +198,14 @@ struct gen_embed_o {
 	gen_o* inner;
 };
 
+// gen_embed_o_fingerprint is the schema digest, which changes with any
+// change to the fields, including the data structures referred to.
+extern const char gen_embed_o_fingerprint[];
+
 // gen_embed_o_marshal_len returns the Colfer serial octet size.
 // When the return is zero then errno is set to EFBIG to indicate a breach of
-// either colfer_size_max or colfer_list_max.
+// either colfer_size_max or colfer_list_max, or to EBADMSG to indicate
+// malformed UTF-8 in text.
 size_t gen_embed_o_marshal_len(const gen_embed_o* o);
 
 // gen_embed_o_marshal encodes o as Colfer into buf and returns the number
@@ -160,11 +215,29 @@ size_t gen_embed_o_marshal(const gen_embed_o* o, void* buf);
 // gen_embed_o_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
 // colfer_size_max, whichever occurs first.
-// When the return is zero then errno is set to one of the following 3 values:
+// When the return is zero then errno is set to one of the following 5 values:
 // EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// or colfer_list_max, ELOOP on a breach of colfer_depth_max, EBADMSG on
+// malformed UTF-8 in text and EILSEQ on schema mismatch.
 size_t gen_embed_o_unmarshal(gen_embed_o* o, const void* data, size_t datalen);
 
+// gen_embed_o_unmarshal_canonical decodes data like
+// gen_embed_o_unmarshal, yet it rejects any serial other than the one
+// gen_embed_o_marshal produces for the result. Equal values have identical
+// serials as such. Data after the serial is rejected too. When the return is
+// zero then errno is set like gen_embed_o_unmarshal does, or to EPROTO on a
+// non-canonical encoding, including data after the serial. The check encodes
+// the result for comparison, which costs about as much as the decoding.
+size_t gen_embed_o_unmarshal_canonical(gen_embed_o* o, const void* data, size_t datalen);
+
+// gen_embed_o_canonicalize decodes data like gen_embed_o_unmarshal,
+// and it encodes the result into buf like gen_embed_o_marshal, which gives
+// the canonical serial. The return is the number of octets written. A buflen
+// of colfer_size_max always suffices. When the return is zero then errno is set
+// like gen_embed_o_unmarshal does, to EPROTO on data after the serial, or to
+// ENOBUFS when buflen is too small.
+size_t gen_embed_o_canonicalize(gen_embed_o* o, void* buf, size_t buflen, const void* data, size_t datalen);
+
 
 #ifdef __cplusplus
 } // extern "C"
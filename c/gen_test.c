@@ -239,6 +239,161 @@ int main() {
 		colfer_size_max = 16 * 1024 * 1024;
 	}
 
+	// depth maximum:
+	const struct {
+		const char* data;
+		size_t len;
+		size_t depth;
+	} nested[] = {
+		{"\x0a\x0a\x7f\x7f\x7f", 5, 3},
+		{"\x0a\x0a\x0a\x7f\x7f\x7f\x7f", 7, 4},
+		{"\x0b\x01\x0b\x01\x7f\x7f\x7f", 7, 3},
+		{"\x0b\x01\x0b\x01\x0b\x01\x7f\x7f\x7f\x7f", 10, 4},
+		{"\x0b\x01\x0a\x0a\x7f\x7f\x7f\x7f", 8, 4},
+	};
+	colfer_depth_max = 3;
+	for (size_t i = 0; i < sizeof(nested) / sizeof(nested[0]); ++i) {
+		gen_o o = {0};
+		size_t read = gen_o_unmarshal(&o, nested[i].data, nested[i].len);
+		if (nested[i].depth <= colfer_depth_max ? read != nested[i].len : read || errno != ELOOP)
+			printf("nesting %zu: unmarshal read %zu with errno %d for depth maximum %zu\n", i, read, errno, colfer_depth_max);
+		errno = 0;
+	}
+	colfer_depth_max = 100;
+
+	// sizes and element counts with little data to back them:
+	const struct {
+		const char* data;
+		size_t len;
+	} sparse[] = {
+		{"\x09\x80\x80\x80\x04\x7f", 6},
+		{"\x0d\x01\x80\x80\x80\x04\x7f", 7},
+		{"\x0a\x09\x80\x80\x80\x04\x7f\x7f", 8},
+		{"\x0b\x80\x80\x04\x7f", 5},
+		{"\x0a\x0b\x80\x80\x04\x7f\x7f", 7},
+		{"\x0b\x02\x0b\x80\x80\x04\x7f\x7f\x7f", 9},
+		{"\x0c\x80\x80\x04\x7f", 5},
+		{"\x0d\x80\x80\x04\x7f", 5},
+		{"\x10\x80\x80\x04\x7f", 5},
+		{"\x11\x80\x80\x04\x7f", 5},
+	};
+	for (size_t i = 0; i < sizeof(sparse) / sizeof(sparse[0]); ++i) {
+		gen_o o = {0};
+		size_t read = gen_o_unmarshal(&o, sparse[i].data, sparse[i].len);
+		if (read || errno != EWOULDBLOCK)
+			printf("sparse %zu: unmarshal read %zu with errno %d\n", i, read, errno);
+		errno = 0;
+	}
+
+	printf("TEST fingerprint...\n");
+	// same in all languages, as in testdata
+	if (strcmp(gen_o_fingerprint, "98768e7cc2fcda72"))
+		printf("got fingerprint %s\n", gen_o_fingerprint);
+
+	printf("TEST UTF-8 validation...\n");
+	const char* malformed[] = {"\xff", "\xc0\x80", "\xed\xa0\x80", "a\xe0\x80", "\xf4\x90\x80\x80"};
+	for (size_t i = 0; i < sizeof(malformed) / sizeof(malformed[0]); ++i) {
+		const char* s = malformed[i];
+		size_t slen = strlen(s);
+
+		gen_o o = {0};
+		o.s.utf8 = s;
+		o.s.len = slen;
+		size_t got = gen_o_marshal_len(&o);
+		if (got || errno != EBADMSG)
+			printf("malformed text %zu: got marshal length %zu and errno %d\n", i, got, errno);
+		errno = 0;
+
+		colfer_text list[] = {{"ok", 2}, {s, slen}};
+		o = (gen_o) {0};
+		o.ss.list = list;
+		o.ss.len = 2;
+		got = gen_o_marshal_len(&o);
+		if (got || errno != EBADMSG)
+			printf("malformed text list %zu: got marshal length %zu and errno %d\n", i, got, errno);
+		errno = 0;
+
+		uint8_t data[16] = {8, (uint8_t) slen};
+		memcpy(data + 2, s, slen);
+		data[2 + slen] = 127;
+		o = (gen_o) {0};
+		size_t read = gen_o_unmarshal(&o, data, 3 + slen);
+		if (read || errno != EBADMSG)
+			printf("malformed text %zu: unmarshal read %zu and errno %d\n", i, read, errno);
+		errno = 0;
+	}
+
+	printf("TEST canonical encoding...\n");
+	for (int i = 0; i < n; ++i) {
+		golden g = golden_cases[i];
+		size_t len = gen_o_marshal(&g.o, buf);
+
+		gen_o o = {0};
+		size_t read = gen_o_unmarshal_canonical(&o, buf, len);
+		if (read != len)
+			printf("0x%s: canonical unmarshal read %zu and errno %d\n", g.hex, read, errno);
+		errno = 0;
+	}
+	const struct {
+		const char* data;
+		size_t len;
+		const char* hex;
+	} noncanonical[] = {
+		// uint32 in fixed width
+		{"\x81\x00\x00\x00\x01\x7f", 6, "01017f"},
+		// uint32 in overlong varint
+		{"\x01\x81\x00\x7f", 4, "01017f"},
+		// uint16 in 2 bytes
+		{"\x0f\x00\xff\x7f", 4, "8fff7f"},
+		// int32 negative zero
+		{"\x83\x00\x7f", 3, "7f"},
+		// float32 NaN with payload
+		{"\x05\x7f\xc0\x00\x01\x7f", 6, "057fc000007f"},
+		// float64 list with NaN payload
+		{"\x11\x01\x7f\xf8\x00\x00\x00\x00\x00\x01\x7f", 11, "11017ff80000000000007f"},
+		// timestamp in 12 bytes
+		{"\x87\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x7f", 14, "0700000001000000007f"},
+		// timestamp at Unix epoch
+		{"\x07\x00\x00\x00\x00\x00\x00\x00\x00\x7f", 10, "7f"},
+		// empty list
+		{"\x0b\x00\x7f", 3, "7f"},
+	};
+	for (size_t i = 0; i < sizeof(noncanonical) / sizeof(noncanonical[0]); ++i) {
+		gen_o o = {0};
+		size_t read = gen_o_unmarshal_canonical(&o, noncanonical[i].data, noncanonical[i].len);
+		if (read || errno != EPROTO)
+			printf("non-canonical %zu: unmarshal read %zu and errno %d\n", i, read, errno);
+		errno = 0;
+
+		o = (gen_o) {0};
+		size_t wrote = gen_o_canonicalize(&o, buf, colfer_size_max, noncanonical[i].data, noncanonical[i].len);
+		hexstr(hex, buf, wrote);
+		if (strcmp(hex, noncanonical[i].hex))
+			printf("non-canonical %zu: canonicalized to 0x%s with errno %d, want 0x%s\n", i, hex, errno, noncanonical[i].hex);
+		errno = 0;
+	}
+	{
+		// data after the serial, same in all languages
+		gen_o o = {0};
+		size_t read = gen_o_unmarshal_canonical(&o, "\x01\x01\x7f\x00", 4);
+		if (read || errno != EPROTO)
+			printf("canonical unmarshal with tail read %zu and errno %d\n", read, errno);
+		errno = 0;
+
+		o = (gen_o) {0};
+		size_t wrote = gen_o_canonicalize(&o, buf, colfer_size_max, "\x01\x01\x7f\x00", 4);
+		if (wrote || errno != EPROTO)
+			printf("canonicalize with tail wrote %zu and errno %d\n", wrote, errno);
+		errno = 0;
+	}
+	{
+		gen_o o = {0};
+		size_t wrote = gen_o_canonicalize(&o, buf, 2, noncanonical[0].data, noncanonical[0].len);
+		if (wrote || errno != ENOBUFS)
+			printf("canonicalize into 2 bytes wrote %zu with errno %d\n", wrote, errno);
+		errno = 0;
+	}
+
 	free(buf);
 	free(hex);
 }
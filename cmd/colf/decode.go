@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pascaldekloe/colfer"
+	"github.com/pascaldekloe/colfer/dynamic"
+)
+
+// Decode runs the decode command with its arguments.
+func decode(args []string) {
+	flags := flag.NewFlagSet("decode", flag.ExitOnError)
+	flags.Usage = printManual
+	typeName := flags.String("type", "", "")
+	inFormat := flags.String("in", "raw", "")
+	ndjson := flags.Bool("ndjson", false, "")
+	flags.Parse(args)
+
+	t := mustLoadType("decode", *typeName, flags.Args())
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data = mustDecodeInput(data, *inFormat)
+
+	w := bufio.NewWriter(os.Stdout)
+	if !*ndjson {
+		w.WriteString("[")
+	}
+	var buf []byte
+	var offset int
+	for n := 0; offset < len(data); n++ {
+		o := dynamic.New(t)
+		size, err := o.Unmarshal(data[offset:])
+		if err != nil {
+			if !*ndjson {
+				w.WriteString("\n]\n")
+			}
+			w.Flush()
+			fatalSerial(t, offset, err)
+		}
+		report.Printf("%s serial at byte %d has %d bytes", t, offset, size)
+		offset += size
+
+		buf, err = o.AppendJSON(buf[:0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *ndjson {
+			w.Write(buf)
+			w.WriteByte('\n')
+			continue
+		}
+
+		if n != 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString("\n\t")
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf, "\t", "\t"); err != nil {
+			log.Fatal(err)
+		}
+		w.Write(indented.Bytes())
+	}
+	if !*ndjson {
+		w.WriteString("\n]\n")
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// MustLoadType resolves a struct by its qualified name from the schema input
+// for a command. The global options apply.
+func mustLoadType(command, typeName string, schemaArgs []string) *colfer.Struct {
+	if typeName == "" {
+		log.Fatalf("%s: %s requires a -type", name, command)
+	}
+	mustSupportOptions(command)
+
+	var err error
+	dynamic.ColferSizeMax, err = colfer.EvalLimit(*sizeMax)
+	if err != nil {
+		log.Fatalf("%s: size limit %q not supported with %s: %s", name, *sizeMax, command, err)
+	}
+	dynamic.ColferListMax, err = colfer.EvalLimit(*listMax)
+	if err != nil {
+		log.Fatalf("%s: list limit %q not supported with %s: %s", name, *listMax, command, err)
+	}
+	dynamic.ColferDepthMax, err = colfer.EvalLimit(*depthMax)
+	if err != nil {
+		log.Fatalf("%s: depth limit %q not supported with %s: %s", name, *depthMax, command, err)
+	}
+
+	if len(schemaArgs) != 0 {
+		mustResolveSchemaFiles(schemaArgs...)
+	} else {
+		mustResolveSchemaFiles(".")
+	}
+	packages, err := colfer.ParseFiles(schemaPaths...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, p := range packages {
+		p.StrictText = *strictText
+		for _, t := range p.Structs {
+			if t.String() == typeName {
+				return t
+			}
+		}
+	}
+	log.Fatalf("%s: type %q not in schema", name, typeName)
+	return nil
+}
+
+// FatalSerial exits on a decoding error of the serial at byte offset of the
+// input, with any byte positions relative to the input.
+func fatalSerial(t *colfer.Struct, offset int, err error) {
+	if err == io.EOF {
+		log.Fatalf("%s: trailing data at byte %d: incomplete %s serial", name, offset, t)
+	}
+	if i, ok := err.(dynamic.ColferError); ok {
+		// relative to the input instead of the serial
+		err = dynamic.ColferError(offset + int(i))
+	}
+	log.Fatalf("%s: %s serial at byte %d: %s", name, t, offset, err)
+}
+
+// MustDecodeInput returns the serial data in an input format.
+func mustDecodeInput(data []byte, format string) []byte {
+	var err error
+	switch format {
+	case "raw":
+		return data
+	case "hex":
+		data, err = hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	case "base64":
+		data, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	default:
+		log.Fatalf("%s: unsupported input format %q", name, format)
+	}
+	if err != nil {
+		log.Fatalf("%s: %s input: %s", name, format, err)
+	}
+	return data
+}
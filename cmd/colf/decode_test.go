@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	const point = `{"x":1,"name":"az"}` + "\n"
+	runCommandCases(t, []commandCase{
+		// input formats
+		{
+			args:   []string{"decode", "-type", "demo.pt", "-ndjson", demoSchema},
+			input:  "\x00\x01\x01\x02az\x7f",
+			stdout: point,
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", "-ndjson", demoSchema},
+			input:  "00 01 01 02 61 7a\n7f\n",
+			stdout: point,
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "base64", "-ndjson", demoSchema},
+			input:  "AAEBAmF6fw==\n",
+			stdout: point,
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input:  "00017f7f",
+			stdout: "[\n\t{\n\t\t\"x\": 1,\n\t\t\"name\": \"\"\n\t},\n\t{\n\t\t\"x\": 0,\n\t\t\"name\": \"\"\n\t}\n]\n",
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input:  "",
+			stdout: "[\n]\n",
+		},
+
+		// truncated input
+		{
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", "-ndjson", demoSchema},
+			input:  "0001",
+			stderr: "trailing data at byte 0: incomplete demo.pt serial\n",
+			exit:   1,
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", "-ndjson", demoSchema},
+			input:  "00017f0101",
+			stdout: `{"x":1,"name":""}` + "\n",
+			stderr: "trailing data at byte 3: incomplete demo.pt serial\n",
+			exit:   1,
+		},
+
+		// trailing garbage
+		{
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", "-ndjson", demoSchema},
+			input:  "00017fff",
+			stdout: `{"x":1,"name":""}` + "\n",
+			stderr: "demo.pt serial at byte 3: colfer: unknown header at byte 3\n",
+			exit:   1,
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input:  "00017f000105",
+			stdout: "[\n\t{\n\t\t\"x\": 1,\n\t\t\"name\": \"\"\n\t}\n]\n",
+			stderr: "demo.pt serial at byte 3: colfer: unknown header at byte 5\n",
+			exit:   1,
+		},
+
+		// malformed input and usage
+		{
+			args:   []string{"decode", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input:  "0z",
+			stderr: "hex input: encoding/hex: invalid byte: U+007A 'z'\n",
+			exit:   1,
+		}, {
+			args:   []string{"decode", "-type", "demo.pt", "-in", "octal", demoSchema},
+			stderr: "unsupported input format \"octal\"\n",
+			exit:   1,
+		}, {
+			args:   []string{"decode", demoSchema},
+			stderr: "decode requires a -type\n",
+			exit:   1,
+		}, {
+			args:   []string{"decode", "-type", "demo.nope", demoSchema},
+			stderr: "type \"demo.nope\" not in schema\n",
+			exit:   1,
+		},
+	})
+}
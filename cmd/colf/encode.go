@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pascaldekloe/colfer"
+	"github.com/pascaldekloe/colfer/dynamic"
+)
+
+// Encode runs the encode command with its arguments.
+func encode(args []string) {
+	flags := flag.NewFlagSet("encode", flag.ExitOnError)
+	flags.Usage = printManual
+	typeName := flags.String("type", "", "")
+	outFormat := flags.String("out", "raw", "")
+	flags.Parse(args)
+
+	switch *outFormat {
+	case "raw", "hex", "base64":
+		break
+	default:
+		log.Fatalf("%s: unsupported output format %q", name, *outFormat)
+	}
+
+	t := mustLoadType("encode", *typeName, flags.Args())
+
+	w := bufio.NewWriter(os.Stdout)
+	in := &countReader{r: bufio.NewReader(os.Stdin)}
+	dec := json.NewDecoder(in)
+	for n := 0; ; {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Flush()
+			offset := dec.InputOffset()
+			if e, ok := err.(*json.SyntaxError); ok {
+				offset = e.Offset - 1 // count includes the byte
+			} else if err == io.ErrUnexpectedEOF {
+				offset = in.n
+			}
+			log.Fatalf("%s: JSON input at byte %d: %s", name, offset, err)
+		}
+
+		// arrays as produced by the decode command
+		objects := []json.RawMessage{raw}
+		if raw[0] == '[' {
+			objects = nil
+			if err := json.Unmarshal(raw, &objects); err != nil {
+				w.Flush()
+				log.Fatalf("%s: JSON input before byte %d: %s", name, dec.InputOffset(), err)
+			}
+		}
+
+		for _, object := range objects {
+			n++
+			data, err := encodeObject(t, object)
+			if err != nil {
+				w.Flush()
+				log.Fatalf("%s: JSON value %d before byte %d: %s", name, n, dec.InputOffset(), err)
+			}
+			report.Printf("%s serial %d has %d bytes", t, n, len(data))
+
+			switch *outFormat {
+			case "raw":
+				w.Write(data)
+			case "hex":
+				w.WriteString(hex.EncodeToString(data))
+				w.WriteByte('\n')
+			case "base64":
+				w.WriteString(base64.StdEncoding.EncodeToString(data))
+				w.WriteByte('\n')
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func encodeObject(t *colfer.Struct, object json.RawMessage) ([]byte, error) {
+	o := dynamic.New(t)
+	if err := o.UnmarshalJSON(object); err != nil {
+		return nil, err
+	}
+	return o.MarshalBinary()
+}
+
+// CountReader tracks the number of bytes read.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.
+func (c *countReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
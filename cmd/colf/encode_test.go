@@ -0,0 +1,93 @@
+package main
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	runCommandCases(t, []commandCase{
+		// output formats
+		{
+			args:   []string{"encode", "-type", "demo.pt", demoSchema},
+			input:  `{"x":1,"name":"az"}`,
+			stdout: "\x00\x01\x01\x02az\x7f",
+		}, {
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `{"x":1,"name":"az"}`,
+			stdout: "00010102617a7f\n",
+		}, {
+			args:   []string{"encode", "-type", "demo.pt", "-out", "base64", demoSchema},
+			input:  `{"x":1,"name":"az"}`,
+			stdout: "AAEBAmF6fw==\n",
+		},
+
+		// arrays and sequences, as produced by decode with and without -ndjson
+		{
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  "[\n\t{\"x\": 1},\n\t{\"name\": \"b\"}\n]\n",
+			stdout: "00017f\n0101627f\n",
+		}, {
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  "{\"x\":1}\n{}\n",
+			stdout: "00017f\n7f\n",
+		}, {
+			args:  []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input: "",
+		},
+
+		// truncated input
+		{
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `{"x":1} {"x":`,
+			stdout: "00017f\n",
+			stderr: "JSON input at byte 13: unexpected EOF\n",
+			exit:   1,
+		}, {
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `[{"x":1}`,
+			stderr: "JSON input at byte 8: unexpected EOF\n",
+			exit:   1,
+		},
+
+		// trailing garbage
+		{
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `{"x":1} x`,
+			stdout: "00017f\n",
+			stderr: "JSON input at byte 8: invalid character 'x' looking for beginning of value\n",
+			exit:   1,
+		},
+
+		// malformed input
+		{
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `{"x":1} {"x" 2}`,
+			stdout: "00017f\n",
+			stderr: "JSON input at byte 13: invalid character '2' after object key\n",
+			exit:   1,
+		},
+
+		// values against the schema
+		{
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `{"x":1} {"y":2}`,
+			stdout: "00017f\n",
+			stderr: "JSON value 2 before byte 15: colfer: JSON member \"y\" not in demo.pt\n",
+			exit:   1,
+		}, {
+			args:   []string{"encode", "-type", "demo.pt", "-out", "hex", demoSchema},
+			input:  `{"x":-1}`,
+			stderr: "JSON value 1 before byte 8: colfer: demo.pt.x JSON -1: strconv.ParseUint: parsing \"-1\": invalid syntax\n",
+			exit:   1,
+		},
+
+		// usage
+		{
+			args:   []string{"encode", "-type", "demo.pt", "-out", "octal", demoSchema},
+			stderr: "unsupported output format \"octal\"\n",
+			exit:   1,
+		}, {
+			args:   []string{"encode", demoSchema},
+			stderr: "encode requires a -type\n",
+			exit:   1,
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// Fingerprint runs the fingerprint command with its arguments.
+func fingerprint(args []string) {
+	flags := flag.NewFlagSet("fingerprint", flag.ExitOnError)
+	flags.Usage = printManual
+	typeName := flags.String("type", "", "")
+	flags.Parse(args)
+	mustSupportOptions("fingerprint")
+
+	if flags.NArg() != 0 {
+		mustResolveSchemaFiles(flags.Args()...)
+	} else {
+		mustResolveSchemaFiles(".")
+	}
+	packages, err := colfer.ParseFiles(schemaPaths...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	var found bool
+	for _, p := range packages {
+		for _, t := range p.Structs {
+			if *typeName != "" && t.String() != *typeName {
+				continue
+			}
+			found = true
+			fmt.Fprintf(w, "%s %s\n", t.Fingerprint(), t)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if *typeName != "" && !found {
+		log.Fatalf("%s: type %q not in schema", name, *typeName)
+	}
+}
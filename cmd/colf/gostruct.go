@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pascaldekloe/colfer/gostruct"
+)
+
+// ImportGoStruct runs the gostruct command with its arguments.
+func importGoStruct(args []string) {
+	flags := flag.NewFlagSet("gostruct", flag.ExitOnError)
+	flags.Usage = printManual
+	typeNames := flags.String("type", "", "")
+	flags.Parse(args)
+	mustSupportOptions("gostruct")
+
+	dir := "."
+	switch flags.NArg() {
+	case 0:
+		break
+	case 1:
+		dir = flags.Arg(0)
+	default:
+		log.Fatalf("%s: gostruct reads one package directory; got %d operands", name, flags.NArg())
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	pkg, err := gostruct.Load(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	schema, issues, err := gostruct.Convert(pkg, names...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*basedir, 0777); err != nil {
+		log.Fatal(err)
+	}
+	target := filepath.Join(*basedir, pkg.Types.Name()+".colf")
+	if err := ioutil.WriteFile(target, schema, 0666); err != nil {
+		log.Fatal(err)
+	}
+	report.Printf("converted %s to %s", dir, target)
+
+	for _, issue := range issues {
+		log.Printf("%s: %s", name, issue)
+	}
+	if len(issues) != 0 {
+		os.Exit(1)
+	}
+}
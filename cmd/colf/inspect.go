@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/pascaldekloe/colfer/dynamic"
+)
+
+// Inspect runs the inspect command with its arguments.
+func inspect(args []string) {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	flags.Usage = printManual
+	typeName := flags.String("type", "", "")
+	inFormat := flags.String("in", "raw", "")
+	flags.Parse(args)
+
+	t := mustLoadType("inspect", *typeName, flags.Args())
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data = mustDecodeInput(data, *inFormat)
+
+	w := bufio.NewWriter(os.Stdout)
+	for offset := 0; offset < len(data); {
+		if offset != 0 {
+			w.WriteByte('\n')
+		}
+		size, err := dynamic.Inspect(w, t, data, offset)
+		if err != nil {
+			w.Flush()
+			fatalSerial(t, offset, err)
+		}
+		offset += size
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
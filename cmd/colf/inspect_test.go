@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestInspect(t *testing.T) {
+	const point = "" +
+		"     0  00                      header 0x00: field 0 x uint32\n" +
+		"     1  01                        varint = 1\n" +
+		"     2  01                      header 0x01: field 1 name text\n" +
+		"     3  02                        size 2\n" +
+		"     4  61 7a                     \"az\"\n" +
+		"     6  7f                      end of demo.pt\n"
+	const short = "" +
+		"     0  00                      header 0x00: field 0 x uint32\n" +
+		"     1  01                        varint = 1\n" +
+		"     2  7f                      end of demo.pt\n"
+
+	runCommandCases(t, []commandCase{
+		// input formats
+		{
+			args:   []string{"inspect", "-type", "demo.pt", demoSchema},
+			input:  "\x00\x01\x01\x02az\x7f",
+			stdout: point,
+		}, {
+			args:   []string{"inspect", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input:  "00 01 01 02 61 7a\n7f\n",
+			stdout: point,
+		}, {
+			args:   []string{"inspect", "-type", "demo.pt", "-in", "base64", demoSchema},
+			input:  "AAEBAmF6fw==\n",
+			stdout: point,
+		}, {
+			args:   []string{"inspect", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input:  "00017f7f",
+			stdout: short + "\n     3  7f                      end of demo.pt\n",
+		},
+
+		// truncated input
+		{
+			args:  []string{"inspect", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input: "0001",
+			stdout: "" +
+				"     0  00                      header 0x00: field 0 x uint32\n" +
+				"     2                          ! demo.pt.x incomplete\n",
+			stderr: "trailing data at byte 0: incomplete demo.pt serial\n",
+			exit:   1,
+		}, {
+			args:  []string{"inspect", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input: "00017f00",
+			stdout: short + "\n" +
+				"     3  00                      header 0x00: field 0 x uint32\n" +
+				"     4                          ! demo.pt.x incomplete\n",
+			stderr: "trailing data at byte 3: incomplete demo.pt serial\n",
+			exit:   1,
+		},
+
+		// trailing garbage
+		{
+			args:  []string{"inspect", "-type", "demo.pt", "-in", "hex", demoSchema},
+			input: "00017f000105",
+			stdout: short + "\n" +
+				"     3  00                      header 0x00: field 0 x uint32\n" +
+				"     4  01                        varint = 1\n" +
+				"     5  05                      header 0x05: unknown field 5\n" +
+				"     5                          ! demo.pt has 2 fields\n",
+			stderr: "demo.pt serial at byte 3: colfer: unknown header at byte 5\n",
+			exit:   1,
+		},
+
+		// malformed input and usage
+		{
+			args:   []string{"inspect", "-type", "demo.pt", "-in", "base64", demoSchema},
+			input:  "AA*",
+			stderr: "base64 input: illegal base64 data at input byte 2\n",
+			exit:   1,
+		}, {
+			args:   []string{"inspect", demoSchema},
+			stderr: "inspect requires a -type\n",
+			exit:   1,
+		},
+	})
+}
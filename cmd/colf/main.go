@@ -19,13 +19,26 @@ var (
 	format  = flag.Bool("f", false, "Normalize the format of all schema input on the fly.")
 	verbose = flag.Bool("v", false, "Enable verbose reporting to "+italic+"standard error"+clear+".")
 
-	sizeMax = flag.String("s", "16 * 1024 * 1024", "Set the default upper limit for serial byte sizes. The\n`expression` is applied to the target language under the name\nColferSizeMax.")
-	listMax = flag.String("l", "64 * 1024", "Set the default upper limit for the number of elements in a\nlist. The `expression` is applied to the target language under\nthe name ColferListMax.")
+	sizeMax  = flag.String("s", "16 * 1024 * 1024", "Set the default upper limit for serial byte sizes. The\n`expression` is applied to the target language under the name\nColferSizeMax.")
+	listMax  = flag.String("l", "64 * 1024", "Set the default upper limit for the number of elements in a\nlist. The `expression` is applied to the target language under\nthe name ColferListMax.")
+	depthMax = flag.String("r", "100", "Set the default upper limit for the nesting level of data\nstructures. The `expression` is applied to the target language\nunder the name ColferDepthMax.")
 
 	superClass  = flag.String("x", "", "Make all generated classes extend a super `class`.")
 	interfaces  = flag.String("i", "", "Make all generated classes implement one or more `interfaces`.\nUse commas as a list separator.")
 	tagFiles    = flag.String("t", "", "Supply custom tags with one or more `files`. Use commas as a list\nseparator. See the TAGS section for details.")
 	snippetFile = flag.String("c", "", "Insert a code snippet from a `file`.")
+	strictText  = flag.Bool("u", false, "Reject malformed UTF-8 in text fields, on both marshal and\nunmarshal, with a dedicated error.")
+	keepUnknown = flag.Bool("k", false, "Keep unknown fields, which trail the known ones, on binary\nunmarshal and write them back on marshal.")
+	jsonCodec   = flag.Bool("j", false, "Generate JSON marshalling and unmarshalling, with the field\nnames from the schema.")
+	fuzzTests   = flag.Bool("z", false, "Generate a native fuzz test for each struct, in a test file\nnext to the code. Fuzzing requires Go 1.18 or later.")
+	quickTests  = flag.Bool("q", false, "Generate random values for testing/quick, with a property test\nfor each struct, in a test file next to the code.")
+	fieldMask   = flag.Bool("m", false, "Generate selective unmarshalling with a field mask, which skips\nany fields not in the mask.")
+	lazy        = flag.Bool("d", false, "Defer the decoding of nested data structures until first use.\nUnmarshal validates them still.")
+	iterate     = flag.Bool("e", false, "Generate unmarshalling of data structure lists one element at a\ntime, with constant memory.")
+	canonical   = flag.Bool("n", false, "Generate strict unmarshalling which rejects any encoding other\nthan the canonical one, plus a canonicalization helper. The\noption implies -u.")
+	sqlValues   = flag.Bool("a", false, "Generate database/sql support, with an implementation of both\nsql.Scanner and driver.Valuer for binary columns.")
+	registry    = flag.Bool("g", false, "Generate a registry with a constructor for each type identifier,\nplus an envelope for serials of mixed types.")
+	descriptor  = flag.Bool("w", false, "Generate a static description of each data structure from the\nschema, plus access to fields by index.")
 )
 
 func init() {
@@ -33,6 +46,58 @@ func init() {
 	flag.Usage = printManual
 }
 
+// Unsupported has the options which do not apply, by flag name, per command
+// and per target language.
+var unsupported = map[string][]string{
+	"C":                {"x", "i", "t", "c", "k", "j", "z", "q", "m", "d", "e", "a", "g", "w"},
+	"Go":               {"x", "i", "c"},
+	"Java":             {"k", "j", "z", "q", "m", "d", "a", "g", "w"},
+	"ECMAScript":       {"x", "i", "t", "c", "k", "j", "z", "q", "m", "d", "e", "a", "g", "w"},
+	"Protocol Buffers": {"r", "x", "i", "t", "c", "u", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"JSON Schema":      {"r", "x", "i", "t", "c", "u", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"decode":           {"x", "i", "t", "c", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"encode":           {"x", "i", "t", "c", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"inspect":          {"x", "i", "t", "c", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"fingerprint":      {"s", "l", "r", "x", "i", "t", "c", "u", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"gostruct":         {"p", "s", "l", "r", "x", "i", "t", "c", "u", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+	"proto":            {"p", "s", "l", "r", "x", "i", "t", "c", "u", "k", "j", "z", "q", "m", "d", "e", "n", "a", "g", "w"},
+}
+
+// OptionFeatures has a description per flag name, for error reporting.
+var optionFeatures = map[string]string{
+	"p": "package prefix",
+	"s": "size limit",
+	"l": "list limit",
+	"r": "depth limit",
+	"x": "super class",
+	"i": "interfaces",
+	"t": "tags",
+	"c": "snippet",
+	"u": "UTF-8 validation",
+	"k": "unknown field retention",
+	"j": "JSON generation",
+	"z": "fuzz tests",
+	"q": "property tests",
+	"m": "field masks",
+	"d": "lazy decoding",
+	"e": "list iteration",
+	"n": "canonical encoding",
+	"a": "SQL support",
+	"g": "type registry",
+	"w": "descriptors",
+}
+
+// MustSupportOptions exits when any of the options set is unsupported by the
+// command or the target language.
+func mustSupportOptions(command string) {
+	for _, flagName := range unsupported[command] {
+		f := flag.Lookup(flagName)
+		if f.Value.String() != f.DefValue {
+			log.Fatalf("%s: %s not supported with %s", name, optionFeatures[flagName], command)
+		}
+	}
+}
+
 var name = os.Args[0]
 var report = log.New(ioutil.Discard, os.Args[0]+": ", 0)
 
@@ -54,61 +119,66 @@ func main() {
 		os.Exit(2)
 	}
 
+	switch strings.ToLower(flag.Arg(0)) {
+	case "decode":
+		decode(flag.Args()[1:])
+		return
+	case "encode":
+		encode(flag.Args()[1:])
+		return
+	case "inspect":
+		inspect(flag.Args()[1:])
+		return
+	case "fingerprint":
+		fingerprint(flag.Args()[1:])
+		return
+	case "proto":
+		importProto(flag.Args()[1:])
+		return
+	case "gostruct":
+		importGoStruct(flag.Args()[1:])
+		return
+	}
+
 	// select language
 	var gen func(string, colfer.Packages) error
 	var tagOptions colfer.TagOptions
 	switch lang := flag.Arg(0); strings.ToLower(lang) {
 	case "c":
 		report.Print("set-up for C")
+		mustSupportOptions("C")
 		gen = colfer.GenerateC
-		if *superClass != "" {
-			log.Fatalf("%s: super class not supported with C", name)
-		}
-		if *interfaces != "" {
-			log.Fatalf("%s: interfaces not supported with C", name)
-		}
-		if *tagFiles != "" {
-			log.Fatalf("%s: tags not supported with C", name)
-		}
-		if *snippetFile != "" {
-			log.Fatalf("%s: snippet not supported with C", name)
-		}
 
 	case "go":
 		report.Print("set-up for Go")
+		mustSupportOptions("Go")
 		gen = colfer.GenerateGo
-		if *superClass != "" {
-			log.Fatalf("%s: super class not supported with Go", name)
-		}
-		if *interfaces != "" {
-			log.Fatalf("%s: interfaces not supported with Go", name)
-		}
-		if *snippetFile != "" {
-			log.Fatalf("%s: snippet not supported with Go", name)
+		if *lazy && *canonical {
+			log.Fatalf("%s: lazy decoding not supported with canonical encoding", name)
 		}
 		tagOptions.FieldAllow = colfer.TagSingle
 
 	case "java":
 		report.Print("set-up for Java")
+		mustSupportOptions("Java")
 		gen = colfer.GenerateJava
 		tagOptions.StructAllow = colfer.TagMulti
 		tagOptions.FieldAllow = colfer.TagMulti
 
 	case "javascript", "js", "ecmascript":
 		report.Print("set-up for ECMAScript")
+		mustSupportOptions("ECMAScript")
 		gen = colfer.GenerateECMA
-		if *superClass != "" {
-			log.Fatalf("%s: super class not supported with ECMAScript", name)
-		}
-		if *interfaces != "" {
-			log.Fatalf("%s: interfaces not supported with ECMAScript", name)
-		}
-		if *tagFiles != "" {
-			log.Fatalf("%s: tags not supported with ECMAScript", name)
-		}
-		if *snippetFile != "" {
-			log.Fatalf("%s: snippet not supported with ECMAScript", name)
-		}
+
+	case "protobuf":
+		report.Print("set-up for Protocol Buffers")
+		mustSupportOptions("Protocol Buffers")
+		gen = colfer.GenerateProtobuf
+
+	case "jsonschema":
+		report.Print("set-up for JSON Schema")
+		mustSupportOptions("JSON Schema")
+		gen = colfer.GenerateJSONSchema
 
 	default:
 		log.Fatalf("%s: unsupported language %q", name, lang)
@@ -153,7 +223,20 @@ func main() {
 		p.Name = path.Join(*prefix, p.Name)
 		p.SizeMax = *sizeMax
 		p.ListMax = *listMax
+		p.DepthMax = *depthMax
 		p.SuperClass = *superClass
+		p.StrictText = *strictText || *canonical
+		p.KeepUnknown = *keepUnknown
+		p.JSON = *jsonCodec
+		p.Fuzz = *fuzzTests
+		p.Quick = *quickTests
+		p.FieldMask = *fieldMask
+		p.Lazy = *lazy
+		p.Iterate = *iterate
+		p.Canonical = *canonical
+		p.SQL = *sqlValues
+		p.Registry = *registry
+		p.Descriptor = *descriptor
 		if *interfaces != "" {
 			p.Interfaces = strings.Split(*interfaces, ",")
 		}
@@ -223,20 +306,22 @@ func printManual() {
 	nameSection := bold + "NAME\n\t" + name + clear + " \u2014 compile Colfer schemas\n"
 
 	synopsisSection := bold + "SYNOPSIS\n\t" + name + clear + " [" + bold + "-h" + clear + "]\n\t" +
-		bold + name + clear + " [" + bold + "-vf" + clear + "] [" +
+		bold + name + clear + " [" + bold + "-vfun" + clear + "] [" +
 		bold + "-b" + clear + " directory] [" +
 		bold + "-p" + clear + " package] \\\n\t\t[" +
 		bold + "-s" + clear + " expression] [" +
-		bold + "-l" + clear + " expression] " + bold + "C" + clear +
+		bold + "-l" + clear + " expression] [" +
+		bold + "-r" + clear + " expression] " + bold + "C" + clear +
 		" [file ...]\n\t" +
-		bold + name + clear + " [" + bold + "-vf" + clear + "] [" +
+		bold + name + clear + " [" + bold + "-vfukjzqmdenagw" + clear + "] [" +
 		bold + "-b" + clear + " directory] [" +
 		bold + "-p" + clear + " package] [" +
 		bold + "-t" + clear + " files] \\\n\t\t[" +
 		bold + "-s" + clear + " expression] [" +
-		bold + "-l" + clear + " expression] " + bold + "Go" + clear +
+		bold + "-l" + clear + " expression] [" +
+		bold + "-r" + clear + " expression] " + bold + "Go" + clear +
 		" [file ...]\n\t" +
-		bold + name + clear + " [" + bold + "-vf" + clear + "] [" +
+		bold + name + clear + " [" + bold + "-vfuen" + clear + "] [" +
 		bold + "-b" + clear + " directory] [" +
 		bold + "-p" + clear + " package] [" +
 		bold + "-t" + clear + " files] \\\n\t\t[" +
@@ -244,17 +329,61 @@ func printManual() {
 		bold + "-i" + clear + " interfaces] [" +
 		bold + "-c" + clear + " file] \\\n\t\t[" +
 		bold + "-s" + clear + " expression] [" +
-		bold + "-l" + clear + " expression] " + bold + "Java" + clear +
+		bold + "-l" + clear + " expression] [" +
+		bold + "-r" + clear + " expression] " + bold + "Java" + clear +
+		" [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-vfun" + clear + "] [" +
+		bold + "-b" + clear + " directory] [" +
+		bold + "-p" + clear + " package] \\\n\t\t[" +
+		bold + "-s" + clear + " expression] [" +
+		bold + "-l" + clear + " expression] [" +
+		bold + "-r" + clear + " expression] " + bold + "JavaScript" + clear +
 		" [file ...]\n\t" +
 		bold + name + clear + " [" + bold + "-vf" + clear + "] [" +
 		bold + "-b" + clear + " directory] [" +
 		bold + "-p" + clear + " package] \\\n\t\t[" +
 		bold + "-s" + clear + " expression] [" +
-		bold + "-l" + clear + " expression] " + bold + "JavaScript" + clear +
-		" [file ...]\n"
+		bold + "-l" + clear + " expression] " + bold + "Protobuf" + clear +
+		" [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-vf" + clear + "] [" +
+		bold + "-b" + clear + " directory] [" +
+		bold + "-p" + clear + " package] \\\n\t\t[" +
+		bold + "-s" + clear + " expression] [" +
+		bold + "-l" + clear + " expression] " + bold + "JSONSchema" + clear +
+		" [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-vu" + clear + "] [" +
+		bold + "-s" + clear + " expression] [" +
+		bold + "-l" + clear + " expression] [" +
+		bold + "-r" + clear + " expression] " + bold + "decode" + clear + " \\\n\t\t" +
+		bold + "-type" + clear + " name [" +
+		bold + "-in" + clear + " format] [" +
+		bold + "-ndjson" + clear + "] [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-v" + clear + "] [" +
+		bold + "-s" + clear + " expression] [" +
+		bold + "-l" + clear + " expression] " + bold + "encode" + clear + " \\\n\t\t" +
+		bold + "-type" + clear + " name [" +
+		bold + "-out" + clear + " format] [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-vu" + clear + "] [" +
+		bold + "-s" + clear + " expression] [" +
+		bold + "-l" + clear + " expression] [" +
+		bold + "-r" + clear + " expression] " + bold + "inspect" + clear + " \\\n\t\t" +
+		bold + "-type" + clear + " name [" +
+		bold + "-in" + clear + " format] [file ...]\n\t" +
+		bold + name + clear + " " + bold + "fingerprint" + clear + " [" +
+		bold + "-type" + clear + " name] [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-v" + clear + "] [" +
+		bold + "-b" + clear + " directory] " + bold + "proto" + clear + " [file ...]\n\t" +
+		bold + name + clear + " [" + bold + "-v" + clear + "] [" +
+		bold + "-b" + clear + " directory] " + bold + "gostruct" + clear + " [" +
+		bold + "-type" + clear + " names] [directory]\n"
 
 	descriptionSection := bold + "DESCRIPTION" + clear + "\n" +
-		"\tThe output is source code for either C, Go, Java or JavaScript.\n\n" +
+		"\tThe output is source code for either C, Go, Java or JavaScript.\n" +
+		"\tAlternatively, Protobuf exports a proto3 schema per package, with\n" +
+		"\tthe field indices plus one as field numbers, and JSONSchema exports\n" +
+		"\ta JSON Schema per package, which describes the JSON from the Go\n" +
+		"\t" + bold + "-j" + clear + " option, with the size and list limits as constraints.\n" +
+		"\tSee the COMMANDS section for other modes of operation.\n\n" +
 		"\tFor each operand that names a file of a type other than\n" +
 		"\tdirectory, " + bold + "colf" + clear + " reads the content as schema input. For each\n" +
 		"\tnamed directory, " + bold + "colf" + clear + " reads all files with a .colf extension\n" +
@@ -276,6 +405,66 @@ func printManual() {
 		"\tcan take multiple tag lines for the same struct or field. Each\n" +
 		"\tcode line is applied in order of appearance.\n"
 
+	commandsSection := bold + "COMMANDS" + clear + "\n" +
+		"\tThe " + bold + "decode" + clear + " command reads serials from " + italic + "standard input" + clear + " and it\n" +
+		"\tprints them as JSON to " + italic + "standard output" + clear + ". The input may consist of\n" +
+		"\tmultiple serials in a row. Members are in schema order, with the\n" +
+		"\tfield names from the schema. Errors report byte positions of the\n" +
+		"\tinput.\n\n" +
+		"\t  " + bold + "-type" + clear + " name\n" +
+		"\t    \tThe data structure, as in <package>.<struct>.\n" +
+		"\t  " + bold + "-in" + clear + " format\n" +
+		"\t    \tRead the input as either raw, hex or base64. (default raw)\n" +
+		"\t  " + bold + "-ndjson" + clear + "\n" +
+		"\t    \tPrint one JSON object per line instead of a JSON array.\n\n" +
+		"\tThe " + bold + "encode" + clear + " command does the opposite. It reads JSON objects,\n" +
+		"\tor arrays of JSON objects, from " + italic + "standard input" + clear + " and it writes the\n" +
+		"\tserials to " + italic + "standard output" + clear + ", byte for byte what generated code\n" +
+		"\twould produce. Timestamps are RFC 3339 strings or numbers of\n" +
+		"\tseconds since the Unix epoch. Binaries are base64 strings. The\n" +
+		"\tinput must match the field types and limits.\n\n" +
+		"\t  " + bold + "-type" + clear + " name\n" +
+		"\t    \tThe data structure, as in <package>.<struct>.\n" +
+		"\t  " + bold + "-out" + clear + " format\n" +
+		"\t    \tWrite either raw, or one line of hex or base64 per serial.\n" +
+		"\t    \t(default raw)\n\n" +
+		"\tThe " + bold + "inspect" + clear + " command reads serials like " + bold + "decode" + clear + " does, and it\n" +
+		"\tprints an annotated hex dump to " + italic + "standard output" + clear + ". Each line has\n" +
+		"\ta byte position, up to 8 bytes of input, and a description of\n" +
+		"\tthe content, i.e., the headers with their field index and flag\n" +
+		"\t(0x80), the values, sizes, list lengths and struct boundaries.\n" +
+		"\tA line with an exclamation mark shows where the input diverges\n" +
+		"\tfrom the schema. The " + bold + "-type" + clear + " and " + bold + "-in" + clear + " options are the same as\n" +
+		"\twith " + bold + "decode" + clear + ".\n\n" +
+		"\tThe " + bold + "fingerprint" + clear + " command prints a schema digest for each data\n" +
+		"\tstructure to " + italic + "standard output" + clear + ", one per line, with the qualified\n" +
+		"\tname. Any change to the names, the types or the order of fields\n" +
+		"\tchanges the digest, including changes to the data structures\n" +
+		"\treferred to. Generated code has the same digest as a constant,\n" +
+		"\tfor all languages. The " + bold + "-type" + clear + " option selects one data structure.\n\n" +
+		"\tThe " + bold + "proto" + clear + " command converts Protocol Buffers schemas into\n" +
+		"\tColfer schemas, with one .colf file in the base directory per\n" +
+		"\t.proto file. Named directories are read for files with a .proto\n" +
+		"\textension instead. Field numbers minus one become the field\n" +
+		"\tindices, with placeholders for any gaps. Constructs without a\n" +
+		"\tColfer equivalent, such as oneofs, maps, field numbers above 127\n" +
+		"\tand services, are reported on " + italic + "standard error" + clear + ". The command exits\n" +
+		"\t1 when such constructs are found, after it wrote all output.\n\n" +
+		"\tThe " + bold + "gostruct" + clear + " command converts the struct types of a Go package\n" +
+		"\tinto a Colfer schema, which is written to the base directory as\n" +
+		"\t<package>.colf. The package is read from the directory operand,\n" +
+		"\tor from the current directory by default. Imports resolve like\n" +
+		"\tthe go(1) command does from the working directory. The " + bold + "-type" + clear + "\n" +
+		"\toption selects struct types by name, with commas as a list\n" +
+		"\tseparator. All exported struct types are selected by default, and\n" +
+		"\tany struct types referred to from the selection are included too.\n" +
+		"\tFields of type time.Time map to timestamp, []byte maps to binary,\n" +
+		"\tslices map to lists, and (pointers to) structs map to references.\n" +
+		"\tFields without a Colfer equivalent, such as maps, interfaces,\n" +
+		"\tembedded and unexported fields, are reported on " + italic + "standard error" + clear + ",\n" +
+		"\tlike with " + bold + "proto" + clear + ".\n\n" +
+		"\tThe " + bold + "-s" + clear + ", " + bold + "-l" + clear + " and " + bold + "-r" + clear + " expressions must be a product of integers.\n"
+
 	exitStatusSection := bold + "EXIT STATUS" + clear + "\n" +
 		"\tThe command exits 0 on success, 1 on error and 2 when invoked\n" +
 		"\twithout arguments.\n"
@@ -283,15 +472,27 @@ func printManual() {
 	examplesSection := bold + "EXAMPLES" + clear + "\n" +
 		"\tCompile ./io.colf with compact limits as C:\n\n" +
 		"\t\t" + name + " -b src -s 2048 -l 96 C io.colf\n\n" +
+		"\tExport ./*.colf as a JSON Schema in ./schema:\n\n" +
+		"\t\t" + name + " -b schema JSONSchema\n\n" +
 		"\tCompile ./*.colf with a common parent as Java:\n\n" +
-		"\t\t" + name + " -p com.example.model -x com.example.io.IOBean Java\n"
+		"\t\t" + name + " -p com.example.model -x com.example.io.IOBean Java\n\n" +
+		"\tPrint a hex dump of gen.o serials from ./testdata/*.colf as JSON:\n\n" +
+		"\t\techo 0801617f 7f | " + name + " decode -type gen.o -in hex testdata\n\n" +
+		"\tCompare the schema of gen.o in ./testdata/*.colf with a peer:\n\n" +
+		"\t\t" + name + " fingerprint -type gen.o testdata\n\n" +
+		"\tWrite a gen.o serial from ./testdata/*.colf to a file:\n\n" +
+		"\t\techo '{\"s\": \"a\"}' | " + name + " encode -type gen.o testdata > a.bin\n\n" +
+		"\tConvert ./api/*.proto to Colfer schemas in ./schema:\n\n" +
+		"\t\t" + name + " -b schema proto api\n\n" +
+		"\tConvert the Order type from Go package ./shop to ./schema/shop.colf:\n\n" +
+		"\t\t" + name + " -b schema gostruct -type Order shop\n"
 
 	bugsSection := bold + "BUGS" + clear + "\n" +
 		"\tReport bugs at <https://github.com/pascaldekloe/colfer/issues>.\n\n" +
 		"\tText validation is not part of the marshalling and unmarshalling\n" +
-		"\tprocess. C and Go just pass any malformed UTF-8 characters. Java\n" +
-		"\tand JavaScript replace unmappable content with the '?' character\n" +
-		"\t(ASCII 63).\n"
+		"\tprocess unless the " + bold + "-u" + clear + " option is set. C and Go just pass any\n" +
+		"\tmalformed UTF-8 characters. Java and JavaScript replace\n" +
+		"\tunmappable content with the '?' character (ASCII 63).\n"
 
 	seeAlsoSection := bold + "SEE ALSO" + clear + "\n\tprotoc(1), flatc(1)\n"
 
@@ -303,6 +504,7 @@ func printManual() {
 	flag.PrintDefaults()
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, tagsSection)
+	fmt.Fprintln(w, commandsSection)
 	fmt.Fprintln(w, exitStatusSection)
 	fmt.Fprintln(w, examplesSection)
 	fmt.Fprintln(w, bugsSection)
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// DemoSchema has the data structure demo.pt.
+const demoSchema = "../../testdata/demo.colf"
+
+// TestMain runs the command instead of the tests on request of colf, such that
+// the tests can check the output and the exit status.
+func TestMain(m *testing.M) {
+	if os.Getenv("COLF_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Colf runs the command with arguments and input. The standard error output
+// comes without the command name prefix.
+func colf(t *testing.T, input string, args ...string) (stdout, stderr string, exitCode int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "COLF_TEST_MAIN=1")
+	cmd.Stdin = strings.NewReader(input)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatal(err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	return outBuf.String(), strings.ReplaceAll(errBuf.String(), os.Args[0]+": ", ""), exitCode
+}
+
+// CommandCase is a run with the expected outcome.
+type commandCase struct {
+	args   []string
+	input  string
+	stdout string
+	stderr string
+	exit   int
+}
+
+func runCommandCases(t *testing.T, cases []commandCase) {
+	t.Helper()
+	for _, c := range cases {
+		stdout, stderr, exit := colf(t, c.input, c.args...)
+		if stdout != c.stdout {
+			t.Errorf("%q with input %q got standard output %q, want %q", c.args, c.input, stdout, c.stdout)
+		}
+		if stderr != c.stderr {
+			t.Errorf("%q with input %q got standard error %q, want %q", c.args, c.input, stderr, c.stderr)
+		}
+		if exit != c.exit {
+			t.Errorf("%q with input %q got exit status %d, want %d", c.args, c.input, exit, c.exit)
+		}
+	}
+}
+
+func TestUnsupportedOptions(t *testing.T) {
+	// arguments per command or target language
+	commandArgs := map[string][]string{
+		"C":                {"C", demoSchema},
+		"Go":               {"Go", demoSchema},
+		"Java":             {"Java", demoSchema},
+		"ECMAScript":       {"JavaScript", demoSchema},
+		"Protocol Buffers": {"protobuf", demoSchema},
+		"JSON Schema":      {"jsonschema", demoSchema},
+		"decode":           {"decode", "-type", "demo.pt", demoSchema},
+		"encode":           {"encode", "-type", "demo.pt", demoSchema},
+		"inspect":          {"inspect", "-type", "demo.pt", demoSchema},
+		"fingerprint":      {"fingerprint", demoSchema},
+		"gostruct":         {"gostruct"},
+		"proto":            {"proto"},
+	}
+	// a value other than the default per option
+	optionArgs := map[string]string{
+		"p": "-p=example.com/demo",
+		"s": "-s=1024",
+		"l": "-l=99",
+		"r": "-r=9",
+		"x": "-x=Super",
+		"i": "-i=Marker",
+		"t": "-t=demo.tags",
+		"c": "-c=snippet.txt",
+	}
+
+	for command, flagNames := range unsupported {
+		args, ok := commandArgs[command]
+		if !ok {
+			t.Errorf("command %q has no arguments for the test", command)
+			continue
+		}
+		for _, flagName := range flagNames {
+			option, ok := optionArgs[flagName]
+			if !ok {
+				option = "-" + flagName
+			}
+
+			_, stderr, exit := colf(t, "", append([]string{option}, args...)...)
+			want := optionFeatures[flagName] + " not supported with " + command + "\n"
+			if stderr != want || exit != 1 {
+				t.Errorf("%s with %s got standard error %q and exit status %d, want %q and 1", command, option, stderr, exit, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pascaldekloe/colfer/proto"
+)
+
+// ImportProto runs the proto command with its arguments.
+func importProto(args []string) {
+	flags := flag.NewFlagSet("proto", flag.ExitOnError)
+	flags.Usage = printManual
+	flags.Parse(args)
+	mustSupportOptions("proto")
+
+	var paths []string
+	operands := flags.Args()
+	if len(operands) == 0 {
+		operands = []string{"."}
+	}
+	for _, operand := range operands {
+		info, err := os.Stat(operand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !info.IsDir() {
+			paths = append(paths, operand)
+			continue
+		}
+		children, err := filepath.Glob(filepath.Join(operand, "*.proto"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		paths = append(paths, children...)
+	}
+	if len(paths) == 0 {
+		log.Fatalf("%s: no .proto files found", name)
+	}
+
+	files := make([]*proto.File, len(paths))
+	targets := make([]string, len(paths))
+	sources := make(map[string]string) // path by target
+	for i, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		files[i], err = proto.Parse(path, src)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		targets[i] = filepath.Join(*basedir, strings.TrimSuffix(filepath.Base(path), ".proto")+".colf")
+		if other, ok := sources[targets[i]]; ok {
+			log.Fatalf("%s: both %s and %s convert to %s", name, other, path, targets[i])
+		}
+		sources[targets[i]] = path
+	}
+
+	schemas, issues, err := proto.Convert(files...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*basedir, 0777); err != nil {
+		log.Fatal(err)
+	}
+	for i, target := range targets {
+		if err := ioutil.WriteFile(target, schemas[i], 0666); err != nil {
+			log.Fatal(err)
+		}
+		report.Printf("converted %s to %s", paths[i], target)
+	}
+
+	for _, issue := range issues {
+		log.Printf("%s: %s", name, issue)
+	}
+	if len(issues) != 0 {
+		os.Exit(1)
+	}
+}
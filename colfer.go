@@ -4,10 +4,13 @@ package colfer
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -60,6 +63,8 @@ type Package struct {
 	SizeMax string
 	// ListMax is the uper limit expression.
 	ListMax string
+	// DepthMax is the uper limit expression.
+	DepthMax string
 	// SuperClass is the fully qualified path.
 	SuperClass string
 	// SuperClassNative is the language specific SuperClass.
@@ -70,6 +75,45 @@ type Package struct {
 	InterfaceNatives []string
 	// CodeSnippet is helpful in book-keeping functionality.
 	CodeSnippet string
+	// StrictText enables UTF-8 validation of text on both marshal and
+	// unmarshal.
+	StrictText bool
+	// KeepUnknown enables retention of trailing fields which are not in
+	// the schema, i.e., forward compatibility.
+	KeepUnknown bool
+	// JSON enables JSON encoding and decoding with the field names from the
+	// schema.
+	JSON bool
+	// Fuzz enables generation of a native fuzz test for each struct, next
+	// to the code.
+	Fuzz bool
+	// Quick enables generation of random values for testing/quick, with a
+	// property test for each struct, next to the code.
+	Quick bool
+	// FieldMask enables selective decoding, with a set of fields to unmarshal
+	// and the others skipped.
+	FieldMask bool
+	// Lazy enables deferred decoding of nested data structures, which are
+	// kept as serial until first access.
+	Lazy bool
+	// Iterate enables unmarshalling of data structure lists one element at
+	// a time.
+	Iterate bool
+	// Canonical enables strict decoding, which rejects any serial other than
+	// the one produced by the marshaller, plus a canonicalization helper.
+	// Marshallers normalize NaN and the Unix epoch as a zero timestamp such
+	// that all languages agree on the bytes. Text must be well-formed UTF-8
+	// for the guarantee, as with StrictText.
+	Canonical bool
+	// SQL enables database/sql support, with each struct as a binary column
+	// value in Colfer encoding.
+	SQL bool
+	// Registry enables a constructor lookup per type identifier, with an
+	// envelope for serials of mixed types.
+	Registry bool
+	// Descriptor enables a static description of each data structure, with
+	// field access by index.
+	Descriptor bool
 }
 
 // DocText returns the documentation lines prefixed with ident.
@@ -118,6 +162,26 @@ func (p *Package) HasFloat() bool {
 	return false
 }
 
+// HasText returns whether p has one or more text fields.
+func (p *Package) HasText() bool {
+	for _, t := range p.Structs {
+		if t.HasText() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBinary returns whether p has one or more binary fields.
+func (p *Package) HasBinary() bool {
+	for _, t := range p.Structs {
+		if t.HasBinary() {
+			return true
+		}
+	}
+	return false
+}
+
 // HasTimestamp returns whether p has one or more timestamp fields.
 func (p *Package) HasTimestamp() bool {
 	for _, t := range p.Structs {
@@ -165,6 +229,39 @@ func (t *Struct) String() string {
 	return fmt.Sprintf("%s.%s", t.Pkg.Name, t.Name)
 }
 
+// Fingerprint returns a digest of the schema definition as 16 hexadecimal
+// digits. Any change to the names, the types or the order of the fields
+// changes the fingerprint, including changes to the data structures referred
+// to. Documentation, tags and package prefixes do not apply, such that all
+// languages agree.
+func (t *Struct) Fingerprint() string {
+	h := sha256.New()
+	t.writeSchema(h, make(map[*Struct]int))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// writeSchema writes a normalized definition of t to w, with references to
+// data structures in visited as their ordinal, i.e., recursion.
+func (t *Struct) writeSchema(w io.Writer, visited map[*Struct]int) {
+	visited[t] = len(visited)
+	pkgName := t.Pkg.Name[strings.LastIndexByte(t.Pkg.Name, '/')+1:]
+	fmt.Fprintf(w, "type %s.%s struct {\n", pkgName, t.Name)
+	for _, f := range t.Fields {
+		fmt.Fprintf(w, "%d %s ", f.Index, f.Name)
+		if f.TypeList {
+			io.WriteString(w, "[]")
+		}
+		if f.TypeRef == nil {
+			fmt.Fprintf(w, "%s\n", f.Type)
+		} else if n, ok := visited[f.TypeRef]; ok {
+			fmt.Fprintf(w, "#%d\n", n)
+		} else {
+			f.TypeRef.writeSchema(w, visited)
+		}
+	}
+	io.WriteString(w, "}\n")
+}
+
 // HasFloat returns whether s has one or more floating point fields.
 func (t *Struct) HasFloat() bool {
 	for _, f := range t.Fields {
@@ -225,6 +322,26 @@ func (t *Struct) HasList() bool {
 	return false
 }
 
+// HasRefList returns whether s has one or more data structure list fields.
+func (t *Struct) HasRefList() bool {
+	for _, f := range t.Fields {
+		if f.TypeRef != nil && f.TypeList {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRef returns whether s has one or more data structure fields.
+func (t *Struct) HasRef() bool {
+	for _, f := range t.Fields {
+		if f.TypeRef != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // Field is a Struct member definition.
 type Field struct {
 	// Struct is the parent.
@@ -442,3 +559,20 @@ func (p Packages) qNameNotFound(qName string, path string, lineNo int) error {
 	}
 	return fmt.Errorf("map %s:%d: package %q not in schema", path, lineNo, segs[0])
 }
+
+// EvalLimit returns the value of a limit expression, like SizeMax and ListMax,
+// which is restricted to a product of integers, such as "16 * 1024 * 1024".
+func EvalLimit(expr string) (int, error) {
+	product := 1
+	for _, factor := range strings.Split(expr, "*") {
+		n, err := strconv.Atoi(strings.TrimSpace(factor))
+		if err != nil {
+			return 0, err
+		}
+		product *= n
+	}
+	if product <= 0 {
+		return 0, strconv.ErrRange
+	}
+	return product, nil
+}
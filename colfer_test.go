@@ -1,6 +1,14 @@
 package colfer
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func GoldenTagPackages() Packages {
 	p := &Package{Name: "gen"}
@@ -53,3 +61,191 @@ func TestTagFileErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestEvalLimit(t *testing.T) {
+	golden := []struct {
+		expr string
+		want int
+	}{
+		{"16 * 1024 * 1024", 16 * 1024 * 1024},
+		{"64*1024", 64 * 1024},
+		{"7", 7},
+	}
+	for _, gold := range golden {
+		got, err := EvalLimit(gold.expr)
+		if err != nil || got != gold.want {
+			t.Errorf("%q: got %d, %v; want %d", gold.expr, got, err, gold.want)
+		}
+	}
+
+	for _, expr := range []string{"", "0", "-1 * 2", "1 << 20", "ColferSizeMax"} {
+		if got, err := EvalLimit(expr); err == nil {
+			t.Errorf("%q: got %d, want error", expr, got)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	golden := []struct {
+		schemas []string
+		files   []string
+	}{
+		{[]string{"testdata/test.colf"}, []string{"gen"}},
+		{[]string{"testdata/break.colf", "testdata/break-refs.colf"}, []string{"static", "void"}},
+	}
+	for _, gold := range golden {
+		for _, gen := range []struct {
+			f   func(string, Packages) error
+			ext string
+		}{
+			{GenerateProtobuf, ".proto"},
+			{GenerateJSONSchema, ".schema.json"},
+		} {
+			packages, err := ParseFiles(gold.schemas...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range packages {
+				p.SizeMax = "16 * 1024 * 1024"
+				p.ListMax = "64 * 1024"
+			}
+
+			dir, err := ioutil.TempDir("", "colfer-export")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err := gen.f(dir, packages); err != nil {
+				t.Errorf("%s %s: %s", gold.schemas, gen.ext, err)
+				continue
+			}
+			for _, name := range gold.files {
+				got, err := ioutil.ReadFile(filepath.Join(dir, name+gen.ext))
+				if err != nil {
+					t.Error(err)
+					continue
+				}
+				want, err := ioutil.ReadFile(filepath.Join("testdata", "export", name+gen.ext))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s%s: got:\n%s\nwant:\n%s", name, gen.ext, got, want)
+				}
+				if gen.ext == ".schema.json" && !json.Valid(got) {
+					t.Errorf("%s%s: malformed JSON", name, gen.ext)
+				}
+			}
+		}
+	}
+}
+
+func TestExportLimitError(t *testing.T) {
+	packages, err := ParseFiles("testdata/test.colf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range packages {
+		p.SizeMax = "ColferSizeMax"
+		p.ListMax = "64 * 1024"
+	}
+	dir, err := ioutil.TempDir("", "colfer-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = GenerateJSONSchema(dir, packages)
+	const want = `colfer: package gen size limit "ColferSizeMax": `
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got error %v, want prefix %q", err, want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colfer-fingerprint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// fingerprint of demo.a from a schema
+	fingerprint := func(schema string) string {
+		path := filepath.Join(dir, "demo.colf")
+		if err := ioutil.WriteFile(path, []byte(schema), 0644); err != nil {
+			t.Fatal(err)
+		}
+		packages, err := ParseFiles(path)
+		if err != nil {
+			t.Fatalf("%s: %s", schema, err)
+		}
+		return packages.StructsByQName()["demo.a"].Fingerprint()
+	}
+
+	const base = "package demo\ntype a struct {\n\tx uint32\n\tbs []b\n}\ntype b struct {\n\ty text\n\ta a\n}\n"
+	want := fingerprint(base)
+	if len(want) != 16 {
+		t.Errorf("got fingerprint %q, want 16 hexadecimals", want)
+	}
+
+	same := []string{
+		"// Package demo is documented.\npackage demo\n// A is documented.\ntype a struct {\n\t// X is documented.\n\tx uint32\n\tbs []b\n}\ntype b struct {\n\ty text\n\ta a\n}\n",
+		"package demo\ntype b struct {\n\ty text\n\ta a\n}\ntype a struct {\n\tx uint32\n\tbs []b\n}\n",
+		base + "type c struct {\n\tz bool\n}\n",
+	}
+	for _, schema := range same {
+		if got := fingerprint(schema); got != want {
+			t.Errorf("got fingerprint %q, want %q for schema:\n%s", got, want, schema)
+		}
+	}
+
+	differ := []string{
+		"package demo\ntype a struct {\n\tz uint32\n\tbs []b\n}\ntype b struct {\n\ty text\n\ta a\n}\n",
+		"package demo\ntype a struct {\n\tx uint64\n\tbs []b\n}\ntype b struct {\n\ty text\n\ta a\n}\n",
+		"package demo\ntype a struct {\n\tbs []b\n\tx uint32\n}\ntype b struct {\n\ty text\n\ta a\n}\n",
+		"package demo\ntype a struct {\n\tx uint32\n\tbs b\n}\ntype b struct {\n\ty text\n\ta a\n}\n",
+		"package demo\ntype a struct {\n\tx uint32\n\tbs []b\n}\ntype b struct {\n\ty binary\n\ta a\n}\n",
+		"package demo\ntype a struct {\n\tx uint32\n\tbs []b\n}\ntype b struct {\n\ty text\n\ta a\n\tz bool\n}\n",
+		"package demo\ntype a struct {\n\tx uint32\n\tbs []c\n}\ntype c struct {\n\ty text\n\ta a\n}\n",
+		"package demo\ntype a struct {\n\tx uint32\n}\n",
+	}
+	for _, schema := range differ {
+		if got := fingerprint(schema); got == want {
+			t.Errorf("got fingerprint %q for schema:\n%s", got, schema)
+		}
+	}
+
+	// stable over releases, as peers compare
+	packages, err := ParseFiles("testdata/test.colf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := packages.StructsByQName()["gen.o"]
+	const golden = "98768e7cc2fcda72"
+	if got := o.Fingerprint(); got != golden {
+		t.Errorf("gen.o got fingerprint %q, want %q", got, golden)
+	}
+	o.Pkg.Name = "com/example/gen"
+	if got := o.Fingerprint(); got != golden {
+		t.Errorf("gen.o with package prefix got fingerprint %q, want %q", got, golden)
+	}
+}
+
+func TestGoTypeID(t *testing.T) {
+	packages, err := ParseFiles("testdata/test.colf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := packages.StructsByQName()["gen.o"]
+	// stable over releases, as peers compare
+	const golden = 0x7ffec28a
+	if got := goTypeID(o); got != golden {
+		t.Errorf("gen.o got type identifier %#08x, want %#08x", got, golden)
+	}
+	o.Pkg.Name = "com/example/gen"
+	if got := goTypeID(o); got != golden {
+		t.Errorf("gen.o with package prefix got type identifier %#08x, want %#08x", got, golden)
+	}
+	if got, want := goSchemaName(o), "gen.o"; got != want {
+		t.Errorf("gen.o with package prefix got schema name %q, want %q", got, want)
+	}
+}
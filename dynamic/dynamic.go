@@ -0,0 +1,1383 @@
+// Package dynamic provides Colfer serialization without generated code. The
+// data structures are interpreted from their schema at runtime. Limits and
+// errors behave exactly like the generated Go code does.
+package dynamic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+var intconv = binary.BigEndian
+
+// Colfer configuration attributes
+var (
+	// ColferSizeMax is the upper limit for serial byte sizes.
+	ColferSizeMax = 16 * 1024 * 1024
+	// ColferListMax is the upper limit for the number of elements in a list.
+	ColferListMax = 64 * 1024
+	// ColferDepthMax is the upper limit for the nesting level of data
+	// structures, with 1 for the top-level.
+	ColferDepthMax = 100
+)
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferDepth signals an upper limit breach of the nesting level.
+type ColferDepth string
+
+// Error honors the error interface.
+func (m ColferDepth) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// ColferUTF8 signals malformed UTF-8 in text. The check applies only to
+// packages with colfer.Package.StrictText set.
+type ColferUTF8 string
+
+// Error honors the error interface.
+func (m ColferUTF8) Error() string { return string(m) }
+
+// Struct is a data structure instance. Each value has the Go type of the
+// respective field, as generated by colf(1), with the exception of *Struct
+// and []*Struct for data structure references.
+//
+//	bool       bool
+//	uint8      uint8
+//	uint16     uint16
+//	uint32     uint32
+//	uint64     uint64
+//	int32      int32,   or []int32 for lists
+//	int64      int64,   or []int64 for lists
+//	float32    float32, or []float32 for lists
+//	float64    float64, or []float64 for lists
+//	timestamp  time.Time
+//	text       string,  or []string for lists
+//	binary     []byte,  or [][]byte for lists
+//	<struct>   *Struct, or []*Struct for lists
+type Struct struct {
+	// Type is the schema definition.
+	Type *colfer.Struct
+	// Values are in order of Type.Fields.
+	Values []interface{}
+	// Unknown has the serial of trailing fields not in the schema. See
+	// colfer.Package.KeepUnknown for details.
+	Unknown []byte
+}
+
+// New returns a new instance with all values set to zero.
+func New(t *colfer.Struct) *Struct {
+	o := &Struct{Type: t, Values: make([]interface{}, len(t.Fields))}
+	for i, f := range t.Fields {
+		o.Values[i] = zero(f)
+	}
+	return o
+}
+
+// Zero returns the initial value of f.
+func zero(f *colfer.Field) interface{} {
+	if f.TypeRef != nil {
+		if f.TypeList {
+			return []*Struct(nil)
+		}
+		return (*Struct)(nil)
+	}
+
+	switch f.Type {
+	case "bool":
+		return false
+	case "uint8":
+		return uint8(0)
+	case "uint16":
+		return uint16(0)
+	case "uint32":
+		return uint32(0)
+	case "uint64":
+		return uint64(0)
+	case "int32":
+		if f.TypeList {
+			return []int32(nil)
+		}
+		return int32(0)
+	case "int64":
+		if f.TypeList {
+			return []int64(nil)
+		}
+		return int64(0)
+	case "float32":
+		if f.TypeList {
+			return []float32(nil)
+		}
+		return float32(0)
+	case "float64":
+		if f.TypeList {
+			return []float64(nil)
+		}
+		return float64(0)
+	case "timestamp":
+		return time.Time{}
+	case "text":
+		if f.TypeList {
+			return []string(nil)
+		}
+		return ""
+	case "binary":
+		if f.TypeList {
+			return [][]byte(nil)
+		}
+		return []byte(nil)
+	}
+	panic("colfer: unknown datatype " + f.Type)
+}
+
+// Field returns the definition with name, or nil when absent.
+func (o *Struct) Field(name string) *colfer.Field {
+	for _, f := range o.Type.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Get returns the value of the field with name.
+func (o *Struct) Get(name string) (v interface{}, ok bool) {
+	f := o.Field(name)
+	if f == nil {
+		return nil, false
+	}
+	o.init()
+	return o.Values[f.Index], true
+}
+
+// Set updates the value of the field with name. The Go type of v must match
+// the field definition exactly.
+func (o *Struct) Set(name string, v interface{}) error {
+	f := o.Field(name)
+	if f == nil {
+		return fmt.Errorf("colfer: no field %q in %s", name, o.Type)
+	}
+	if err := check(f, v); err != nil {
+		return err
+	}
+	o.init()
+	o.Values[f.Index] = v
+	return nil
+}
+
+// Check verifies the Go type of v against f.
+func check(f *colfer.Field, v interface{}) error {
+	if f.TypeRef != nil {
+		var ok bool
+		if f.TypeList {
+			var a []*Struct
+			a, ok = v.([]*Struct)
+			for _, o := range a {
+				if o != nil && o.Type != f.TypeRef {
+					return fmt.Errorf("colfer: %s element type %s mismatch", f, o.Type)
+				}
+			}
+		} else {
+			var o *Struct
+			o, ok = v.(*Struct)
+			if o != nil && o.Type != f.TypeRef {
+				return fmt.Errorf("colfer: %s type %s mismatch", f, o.Type)
+			}
+		}
+		if !ok {
+			return fmt.Errorf("colfer: %s Go type %T mismatch", f, v)
+		}
+		return nil
+	}
+
+	var ok bool
+	switch zero(f).(type) {
+	case bool:
+		_, ok = v.(bool)
+	case uint8:
+		_, ok = v.(uint8)
+	case uint16:
+		_, ok = v.(uint16)
+	case uint32:
+		_, ok = v.(uint32)
+	case uint64:
+		_, ok = v.(uint64)
+	case int32:
+		_, ok = v.(int32)
+	case []int32:
+		_, ok = v.([]int32)
+	case int64:
+		_, ok = v.(int64)
+	case []int64:
+		_, ok = v.([]int64)
+	case float32:
+		_, ok = v.(float32)
+	case []float32:
+		_, ok = v.([]float32)
+	case float64:
+		_, ok = v.(float64)
+	case []float64:
+		_, ok = v.([]float64)
+	case time.Time:
+		_, ok = v.(time.Time)
+	case string:
+		_, ok = v.(string)
+	case []string:
+		_, ok = v.([]string)
+	case []byte:
+		_, ok = v.([]byte)
+	case [][]byte:
+		_, ok = v.([][]byte)
+	}
+	if !ok {
+		return fmt.Errorf("colfer: %s Go type %T mismatch", f, v)
+	}
+	return nil
+}
+
+// Init ensures a value for each field.
+func (o *Struct) init() {
+	if len(o.Values) == len(o.Type.Fields) {
+		return
+	}
+	values := make([]interface{}, len(o.Type.Fields))
+	copy(values, o.Values)
+	for i := len(o.Values); i < len(values); i++ {
+		values[i] = zero(o.Type.Fields[i])
+	}
+	o.Values = values
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+// All nil entries in lists of data structures will be replaced with a new value.
+func (o *Struct) MarshalTo(buf []byte) int {
+	o.init()
+	var i int
+	for fi, f := range o.Type.Fields {
+		i = o.marshalField(buf, i, f, fi)
+	}
+	i += copy(buf[i:], o.Unknown)
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+func (o *Struct) marshalField(buf []byte, i int, f *colfer.Field, fi int) int {
+	index := byte(f.Index)
+
+	switch v := o.Values[fi].(type) {
+	case bool:
+		if v {
+			buf[i] = index
+			i++
+		}
+
+	case uint8:
+		if v != 0 {
+			buf[i] = index
+			buf[i+1] = v
+			i += 2
+		}
+
+	case uint16:
+		if v >= 1<<8 {
+			buf[i] = index
+			buf[i+1] = byte(v >> 8)
+			buf[i+2] = byte(v)
+			i += 3
+		} else if v != 0 {
+			buf[i] = index | 0x80
+			buf[i+1] = byte(v)
+			i += 2
+		}
+
+	case uint32:
+		if v >= 1<<21 {
+			buf[i] = index | 0x80
+			intconv.PutUint32(buf[i+1:], v)
+			i += 5
+		} else if v != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(v))
+		}
+
+	case uint64:
+		if v >= 1<<49 {
+			buf[i] = index | 0x80
+			intconv.PutUint64(buf[i+1:], v)
+			i += 9
+		} else if v != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, v)
+		}
+
+	case int32:
+		if v != 0 {
+			x := uint32(v)
+			if v >= 0 {
+				buf[i] = index
+			} else {
+				x = ^x + 1
+				buf[i] = index | 0x80
+			}
+			i = putVarint(buf, i+1, uint64(x))
+		}
+
+	case []int32:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for _, v := range v {
+				i = putVarint(buf, i, uint64(uint32(v<<1)^uint32(v>>31)))
+			}
+		}
+
+	case int64:
+		if v != 0 {
+			x := uint64(v)
+			if v >= 0 {
+				buf[i] = index
+			} else {
+				x = ^x + 1
+				buf[i] = index | 0x80
+			}
+			i = putVarint64(buf, i+1, x)
+		}
+
+	case []int64:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for _, v := range v {
+				i = putVarint64(buf, i, uint64(v<<1)^uint64(v>>63))
+			}
+		}
+
+	case float32:
+		if v != 0 {
+			buf[i] = index
+			intconv.PutUint32(buf[i+1:], math.Float32bits(v))
+			i += 5
+		}
+
+	case []float32:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for _, v := range v {
+				intconv.PutUint32(buf[i:], math.Float32bits(v))
+				i += 4
+			}
+		}
+
+	case float64:
+		if v != 0 {
+			buf[i] = index
+			intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+			i += 9
+		}
+
+	case []float64:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for _, v := range v {
+				intconv.PutUint64(buf[i:], math.Float64bits(v))
+				i += 8
+			}
+		}
+
+	case time.Time:
+		if !v.IsZero() {
+			s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+			if s < 1<<32 {
+				buf[i] = index
+				intconv.PutUint32(buf[i+1:], uint32(s))
+				i += 5
+			} else {
+				buf[i] = index | 0x80
+				intconv.PutUint64(buf[i+1:], s)
+				i += 9
+			}
+			intconv.PutUint32(buf[i:], ns)
+			i += 4
+		}
+
+	case string:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			i += copy(buf[i:], v)
+		}
+
+	case []string:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for _, s := range v {
+				i = putVarint(buf, i, uint64(len(s)))
+				i += copy(buf[i:], s)
+			}
+		}
+
+	case []byte:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			i += copy(buf[i:], v)
+		}
+
+	case [][]byte:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for _, a := range v {
+				i = putVarint(buf, i, uint64(len(a)))
+				i += copy(buf[i:], a)
+			}
+		}
+
+	case *Struct:
+		if v != nil {
+			buf[i] = index
+			i++
+			i += v.MarshalTo(buf[i:])
+		}
+
+	case []*Struct:
+		if len(v) != 0 {
+			buf[i] = index
+			i = putVarint(buf, i+1, uint64(len(v)))
+			for vi, e := range v {
+				if e == nil {
+					e = New(f.TypeRef)
+					v[vi] = e
+				}
+				i += e.MarshalTo(buf[i:])
+			}
+		}
+
+	default:
+		panic(fmt.Sprintf("colfer: %s has Go type %T", f, v))
+	}
+
+	return i
+}
+
+// PutVarint writes x at buf[i:] and returns the index after.
+func putVarint(buf []byte, i int, x uint64) int {
+	for x >= 0x80 {
+		buf[i] = byte(x | 0x80)
+		x >>= 7
+		i++
+	}
+	buf[i] = byte(x)
+	return i + 1
+}
+
+// PutVarint64 is like putVarint, yet it uses the full 8 bits of the ninth byte.
+func putVarint64(buf []byte, i int, x uint64) int {
+	for n := 0; x >= 0x80 && n < 8; n++ {
+		buf[i] = byte(x | 0x80)
+		x >>= 7
+		i++
+	}
+	buf[i] = byte(x)
+	return i + 1
+}
+
+// VarintLen returns the number of bytes needed for x.
+func varintLen(x uint64) int {
+	l := 1
+	for ; x >= 0x80; l++ {
+		x >>= 7
+	}
+	return l
+}
+
+// VarintLen64 returns the number of bytes needed for x with putVarint64.
+func varintLen64(x uint64) int {
+	l := 1
+	for n := 0; x >= 0x80 && n < 8; n++ {
+		x >>= 7
+		l++
+	}
+	return l
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax, or ColferUTF8 in strict text mode.
+func (o *Struct) MarshalLen() (int, error) {
+	o.init()
+	l := 1
+	for fi, f := range o.Type.Fields {
+		var err error
+		l, err = o.marshalFieldLen(l, f, fi)
+		if err != nil {
+			return 0, err
+		}
+	}
+	l += len(o.Unknown)
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct %s exceeds %d bytes", o.Type, ColferSizeMax))
+	}
+	return l, nil
+}
+
+func (o *Struct) marshalFieldLen(l int, f *colfer.Field, fi int) (int, error) {
+	strict := o.Type.Pkg.StrictText
+
+	switch v := o.Values[fi].(type) {
+	case bool:
+		if v {
+			l++
+		}
+
+	case uint8:
+		if v != 0 {
+			l += 2
+		}
+
+	case uint16:
+		if v >= 1<<8 {
+			l += 3
+		} else if v != 0 {
+			l += 2
+		}
+
+	case uint32:
+		if v >= 1<<21 {
+			l += 5
+		} else if v != 0 {
+			l += 1 + varintLen(uint64(v))
+		}
+
+	case uint64:
+		if v >= 1<<49 {
+			l += 9
+		} else if v != 0 {
+			l += 1 + varintLen(v)
+		}
+
+	case int32:
+		if v != 0 {
+			x := uint32(v)
+			if v < 0 {
+				x = ^x + 1
+			}
+			l += 1 + varintLen(uint64(x))
+		}
+
+	case []int32:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x))
+			for _, v := range v {
+				l += varintLen(uint64(uint32(v<<1) ^ uint32(v>>31)))
+			}
+			if l >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+			}
+		}
+
+	case int64:
+		if v != 0 {
+			x := uint64(v)
+			if v < 0 {
+				x = ^x + 1
+			}
+			l += 1 + varintLen64(x)
+		}
+
+	case []int64:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x))
+			for _, v := range v {
+				l += varintLen64(uint64(v<<1) ^ uint64(v>>63))
+			}
+			if l >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+			}
+		}
+
+	case float32:
+		if v != 0 {
+			l += 5
+		}
+
+	case []float32:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x)) + x*4
+		}
+
+	case float64:
+		if v != 0 {
+			l += 9
+		}
+
+	case []float64:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x)) + x*8
+		}
+
+	case time.Time:
+		if !v.IsZero() {
+			if s := uint64(v.Unix()); s < 1<<32 {
+				l += 9
+			} else {
+				l += 13
+			}
+		}
+
+	case string:
+		if x := len(v); x != 0 {
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, ColferSizeMax))
+			}
+			if strict && !utf8.ValidString(v) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: field %s has malformed UTF-8", f))
+			}
+			l += 1 + varintLen(uint64(x)) + x
+		}
+
+	case []string:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x))
+			for ai, s := range v {
+				x = len(s)
+				if x > ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, ColferSizeMax))
+				}
+				if strict && !utf8.ValidString(s) {
+					return 0, ColferUTF8(fmt.Sprintf("colfer: field %s element %d has malformed UTF-8", f, ai))
+				}
+				l += varintLen(uint64(x)) + x
+			}
+			if l >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+			}
+		}
+
+	case []byte:
+		if x := len(v); x != 0 {
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, ColferSizeMax))
+			}
+			l += 1 + varintLen(uint64(x)) + x
+		}
+
+	case [][]byte:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x))
+			for _, a := range v {
+				x = len(a)
+				if x > ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d bytes", f, ColferSizeMax))
+				}
+				l += varintLen(uint64(x)) + x
+			}
+			if l >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+			}
+		}
+
+	case *Struct:
+		if v != nil {
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl + 1
+		}
+
+	case []*Struct:
+		if x := len(v); x != 0 {
+			if x > ColferListMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field %s exceeds %d elements", f, ColferListMax))
+			}
+			l += 1 + varintLen(uint64(x))
+			for _, e := range v {
+				if e == nil {
+					l++
+					continue
+				}
+				vl, err := e.MarshalLen()
+				if err != nil {
+					return 0, err
+				}
+				l += vl
+			}
+			if l > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+			}
+		}
+
+	default:
+		return 0, fmt.Errorf("colfer: %s has Go type %T", f, v)
+	}
+
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in lists of data structures will be replaced with a new value.
+// The error return option is ColferMax, or ColferUTF8 in strict text mode.
+func (o *Struct) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// ErrEOF signals incomplete data, like a goto eof in generated code.
+var errEOF = errors.New("colfer: EOF")
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, ColferError, ColferDepth, ColferMax,
+// and ColferUTF8 in strict text mode.
+func (o *Struct) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, false, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// When keep is set, then data is the entire serial of o. The depth is the
+// nesting level of o.
+func (o *Struct) unmarshal(data []byte, keep bool, depth int) (int, error) {
+	o.init()
+	o.Unknown = o.Unknown[:0]
+
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct %s exceeds %d levels", o.Type, ColferDepthMax))
+	}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	var err error
+	for fi, f := range o.Type.Fields {
+		i, header, err = o.unmarshalField(data, i, header, f, fi, depth)
+		if err != nil {
+			break
+		}
+	}
+	switch err {
+	case nil:
+		break
+	case errEOF:
+		if i >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+		}
+		return 0, io.EOF
+	default:
+		return 0, err
+	}
+
+	if header != 0x7f {
+		if keep && o.Type.Pkg.KeepUnknown && int(header&0x7f) >= len(o.Type.Fields) && data[len(data)-1] == 0x7f {
+			if len(data) > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+			}
+			if !unknownValid(data, i-1, len(data)-1) {
+				return 0, ColferError(i - 1)
+			}
+			o.Unknown = append(o.Unknown[:0], data[i-1:len(data)-1]...)
+			return len(data), nil
+		}
+		return 0, ColferError(i - 1)
+	}
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct %s size exceeds %d bytes", o.Type, ColferSizeMax))
+	}
+	return i, nil
+}
+
+// UnmarshalField decodes f when header matches, with depth as the nesting
+// level of o. The return is errEOF when data is incomplete at index i.
+func (o *Struct) unmarshalField(data []byte, i int, header byte, f *colfer.Field, fi, depth int) (int, byte, error) {
+	index := byte(f.Index)
+	if header&0x7f != index {
+		return i, header, nil
+	}
+	flag := header&0x80 != 0
+
+	if f.TypeRef != nil {
+		if flag {
+			return i, header, nil
+		}
+		if !f.TypeList {
+			v := New(f.TypeRef)
+			o.Values[fi] = v
+			n, err := v.unmarshal(data[i:], false, depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return i, header, ColferMax(fmt.Sprintf("colfer: %s size exceeds %d bytes", o.Type, ColferSizeMax))
+				}
+				return i, header, err
+			}
+			i += n
+		} else {
+			x, n, err := uvarint(data, i)
+			i = n
+			if err != nil {
+				return i, header, err
+			}
+			if x > uint(ColferListMax) {
+				return i, header, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d elements", f, x, ColferListMax))
+			}
+			if end := i + int(x); end >= len(data) {
+				return end, header, errEOF // elements take one byte at least
+			}
+			a := make([]*Struct, int(x))
+			for ai := range a {
+				v := New(f.TypeRef)
+				a[ai] = v
+
+				// reserve one byte for each element pending
+				n, err := v.unmarshal(data[i:len(data)-(len(a)-1-ai)], false, depth+1)
+				if err != nil {
+					if err == io.EOF && len(data) >= ColferSizeMax {
+						return i, header, ColferMax(fmt.Sprintf("colfer: %s size exceeds %d bytes", o.Type, ColferSizeMax))
+					}
+					return i, header, err
+				}
+				i += n
+			}
+			o.Values[fi] = a
+		}
+
+		if i >= len(data) {
+			return i, header, errEOF
+		}
+		header = data[i]
+		i++
+		return i, header, nil
+	}
+
+	switch f.Type {
+	case "bool":
+		if flag {
+			return i, header, nil
+		}
+		if i >= len(data) {
+			return i, header, errEOF
+		}
+		o.Values[fi] = true
+
+	case "uint8":
+		if flag {
+			return i, header, nil
+		}
+		start := i
+		i++
+		if i >= len(data) {
+			return i, header, errEOF
+		}
+		o.Values[fi] = data[start]
+
+	case "uint16":
+		start := i
+		if !flag {
+			i += 2
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			o.Values[fi] = intconv.Uint16(data[start:])
+		} else {
+			i++
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			o.Values[fi] = uint16(data[start])
+		}
+
+	case "uint32":
+		if !flag {
+			x, n, err := fieldVarint(data, i, 0)
+			i = n
+			if err != nil {
+				return i, header, err
+			}
+			o.Values[fi] = uint32(x)
+		} else {
+			start := i
+			i += 4
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			o.Values[fi] = intconv.Uint32(data[start:])
+		}
+
+	case "uint64":
+		if !flag {
+			x, n, err := fieldVarint(data, i, 56)
+			i = n
+			if err != nil {
+				return i, header, err
+			}
+			o.Values[fi] = x
+		} else {
+			start := i
+			i += 8
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			o.Values[fi] = intconv.Uint64(data[start:])
+		}
+
+	case "int32":
+		if f.TypeList {
+			if flag {
+				return i, header, nil
+			}
+			x, n, err := uvarint(data, i)
+			i = n
+			if err != nil {
+				return i, header, err
+			}
+			if x > uint(ColferListMax) {
+				return i, header, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d elements", f, x, ColferListMax))
+			}
+			if end := i + int(x); end >= len(data) {
+				return end, header, errEOF // elements take one byte at least
+			}
+			a := make([]int32, int(x))
+			for ai := range a {
+				x, n, err := fieldVarint(data, i, 0)
+				i = n
+				if err != nil {
+					return i, header, err
+				}
+				a[ai] = int32((x >> 1) ^ (-(x & 1)))
+			}
+			o.Values[fi] = a
+			break
+		}
+
+		x, n, err := fieldVarint(data, i, 0)
+		i = n
+		if err != nil {
+			return i, header, err
+		}
+		if flag {
+			o.Values[fi] = int32(^uint32(x) + 1)
+		} else {
+			o.Values[fi] = int32(x)
+		}
+
+	case "int64":
+		if f.TypeList {
+			if flag {
+				return i, header, nil
+			}
+			x, n, err := uvarint(data, i)
+			i = n
+			if err != nil {
+				return i, header, err
+			}
+			if x > uint(ColferListMax) {
+				return i, header, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d elements", f, x, ColferListMax))
+			}
+			if end := i + int(x); end >= len(data) {
+				return end, header, errEOF // elements take one byte at least
+			}
+			a := make([]int64, int(x))
+			for ai := range a {
+				x, n, err := fieldVarint(data, i, 56)
+				i = n
+				if err != nil {
+					return i, header, err
+				}
+				a[ai] = int64((x >> 1) ^ (-(x & 1)))
+			}
+			o.Values[fi] = a
+			break
+		}
+
+		x, n, err := fieldVarint(data, i, 56)
+		i = n
+		if err != nil {
+			return i, header, err
+		}
+		if flag {
+			o.Values[fi] = int64(^x + 1)
+		} else {
+			o.Values[fi] = int64(x)
+		}
+
+	case "float32", "float64":
+		if flag {
+			return i, header, nil
+		}
+		size := 4
+		if f.Type == "float64" {
+			size = 8
+		}
+
+		if !f.TypeList {
+			start := i
+			i += size
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			if size == 4 {
+				o.Values[fi] = math.Float32frombits(intconv.Uint32(data[start:]))
+			} else {
+				o.Values[fi] = math.Float64frombits(intconv.Uint64(data[start:]))
+			}
+			break
+		}
+
+		x, n, err := uvarint(data, i)
+		i = n
+		if err != nil {
+			return i, header, err
+		}
+		if x > uint(ColferListMax) {
+			return i, header, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d elements", f, x, ColferListMax))
+		}
+		l := int(x)
+		if end := i + l*size; end >= len(data) {
+			return end, header, errEOF
+		}
+		if size == 4 {
+			a := make([]float32, l)
+			for ai := range a {
+				a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+				i += 4
+			}
+			o.Values[fi] = a
+		} else {
+			a := make([]float64, l)
+			for ai := range a {
+				a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+				i += 8
+			}
+			o.Values[fi] = a
+		}
+
+	case "timestamp":
+		start := i
+		if !flag {
+			i += 8
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			o.Values[fi] = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		} else {
+			i += 12
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			o.Values[fi] = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		}
+
+	case "text", "binary":
+		if flag {
+			return i, header, nil
+		}
+		strict := f.Type == "text" && o.Type.Pkg.StrictText
+
+		x, n, err := uvarint(data, i)
+		i = n
+		if err != nil {
+			return i, header, err
+		}
+
+		if !f.TypeList {
+			if x > uint(ColferSizeMax) {
+				return i, header, ColferMax(fmt.Sprintf("colfer: %s size %d exceeds %d bytes", f, x, ColferSizeMax))
+			}
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			if strict && !utf8.Valid(data[start:i]) {
+				return i, header, ColferUTF8(fmt.Sprintf("colfer: %s has malformed UTF-8 at byte %d", f, start))
+			}
+			if f.Type == "text" {
+				o.Values[fi] = string(data[start:i])
+			} else {
+				o.Values[fi] = append(make([]byte, 0, int(x)), data[start:i]...)
+			}
+			break
+		}
+
+		if x > uint(ColferListMax) {
+			return i, header, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d elements", f, x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			return end, header, errEOF // elements take one byte at least
+		}
+		var texts []string
+		var binaries [][]byte
+		if f.Type == "text" {
+			texts = make([]string, int(x))
+		} else {
+			binaries = make([][]byte, int(x))
+		}
+		for ai := 0; ai < int(x); ai++ {
+			size, n, err := uvarint(data, i)
+			i = n
+			if err != nil {
+				return i, header, err
+			}
+			if size > uint(ColferSizeMax) {
+				return i, header, ColferMax(fmt.Sprintf("colfer: %s element %d size %d exceeds %d bytes", f, ai, size, ColferSizeMax))
+			}
+
+			start := i
+			i += int(size)
+			if i >= len(data) {
+				return i, header, errEOF
+			}
+			if strict && !utf8.Valid(data[start:i]) {
+				return i, header, ColferUTF8(fmt.Sprintf("colfer: %s element %d has malformed UTF-8 at byte %d", f, ai, start))
+			}
+			if texts != nil {
+				texts[ai] = string(data[start:i])
+			} else {
+				binaries[ai] = append(make([]byte, 0, int(size)), data[start:i]...)
+			}
+		}
+		if texts != nil {
+			o.Values[fi] = texts
+		} else {
+			o.Values[fi] = binaries
+		}
+		if i >= len(data) {
+			return i, header, errEOF
+		}
+
+	default:
+		panic("colfer: unknown datatype " + f.Type)
+	}
+
+	header = data[i]
+	i++
+	return i, header, nil
+}
+
+// Uvarint reads the size or length at data[i:], as does generated code.
+func uvarint(data []byte, i int) (uint, int, error) {
+	if i >= len(data) {
+		return 0, i, errEOF
+	}
+	x := uint(data[i])
+	i++
+
+	if x >= 0x80 {
+		x &= 0x7f
+		for shift := uint(7); ; shift += 7 {
+			if i >= len(data) {
+				return 0, i, errEOF
+			}
+			b := uint(data[i])
+			i++
+
+			if b < 0x80 {
+				x |= b << shift
+				break
+			}
+			x |= (b & 0x7f) << shift
+		}
+	}
+	return x, i, nil
+}
+
+// FieldVarint reads an integer value at data[i:], as does generated code. A
+// non-zero fullShift accepts all 8 bits at that shift. The return is errEOF
+// when no byte remains after the value.
+func fieldVarint(data []byte, i int, fullShift uint) (uint64, int, error) {
+	if i+1 >= len(data) {
+		return 0, i + 1, errEOF
+	}
+	x := uint64(data[i])
+	i++
+
+	if x >= 0x80 {
+		x &= 0x7f
+		for shift := uint(7); ; shift += 7 {
+			b := uint64(data[i])
+			i++
+			if i >= len(data) {
+				return 0, i, errEOF
+			}
+
+			if b < 0x80 || shift == fullShift {
+				x |= b << shift
+				break
+			}
+			x |= (b & 0x7f) << shift
+		}
+	}
+	return x, i, nil
+}
+
+// UnknownValid returns whether data[start:end] walks as fields in
+// ascending index order, with data[end] as their terminator. The header of
+// unknown fields has no type information. The walk thus accepts any datatype
+// which fits the payload. Nested data structures are checked on their
+// terminator only. List elements beyond a work limit pass unchecked, as with
+// generated code.
+func unknownValid(data []byte, start, end int) bool {
+	// valid has the field index plus one per header position with a valid
+	// continuation, or zero for none. The terminator exceeds any index.
+	valid := make([]byte, end-start+1)
+	valid[end-start] = 128
+	// nested has the maximum of valid past each terminator from a position
+	// onwards, excluding the one at end, for data structure payloads.
+	nested := make([]byte, end-start+1)
+	// limit the work on list elements to linear time
+	budget := 8 * (end - start)
+
+	// fits returns whether a field with index k can continue at i.
+	fits := func(i int, k byte) bool {
+		return i <= end && valid[i-start] > k+1
+	}
+	// varint returns the value at i, with the position after it.
+	varint := func(i int) (x uint64, next int, ok bool) {
+		for shift := uint(0); shift < 64; shift += 7 {
+			if i >= end {
+				return 0, i, false
+			}
+			c := data[i]
+			i++
+			x |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return x, i, true
+			}
+		}
+		return 0, i, false
+	}
+
+	for p := end - 1; p >= start; p-- {
+		nested[p-start] = nested[p+1-start]
+		if data[p] == 0x7f && valid[p+1-start] > nested[p-start] {
+			nested[p-start] = valid[p+1-start]
+		}
+
+		header := data[p]
+		k := header & 0x7f
+		if k == 0x7f {
+			continue // terminator or invalid
+		}
+		i := p + 1
+		var ok bool
+		if header&0x80 != 0 {
+			// fixed size integers, timestamp, or a negative integer
+			ok = fits(i+1, k) || fits(i+4, k) || fits(i+8, k) || fits(i+12, k)
+			if !ok {
+				if _, next, isVarint := varint(i); isVarint {
+					ok = fits(next, k)
+				}
+			}
+		} else {
+			// boolean, fixed size numbers, timestamp, or a data structure
+			ok = fits(i, k) || fits(i+1, k) || fits(i+2, k) || fits(i+4, k) || fits(i+8, k) || nested[i-start] > k+1
+			if x, c, isVarint := varint(i); !ok && isVarint {
+				// integer, or a size or list length
+				ok = fits(c, k)
+				if !ok && x <= uint64(end-c) {
+					n := int(x)
+					// text, binary, or a list of floating points
+					ok = fits(c+n, k) || fits(c+4*n, k) || fits(c+8*n, k)
+					// list of data structures
+					ok = ok || (n != 0 && nested[c+n-1-start] > k+1)
+					if !ok && n > budget {
+						ok = true
+					}
+					if !ok {
+						budget -= n
+						// list of integers
+						e := c
+						for j := 0; j < n; j++ {
+							_, next, isVarint := varint(e)
+							if !isVarint {
+								e = end + 1
+								break
+							}
+							e = next
+						}
+						ok = fits(e, k)
+						// list of texts or binaries
+						e = c
+						for j := 0; j < n; j++ {
+							size, next, isVarint := varint(e)
+							if !isVarint || size > uint64(end-next) {
+								e = end + 1
+								break
+							}
+							e = next + int(size)
+						}
+						ok = ok || fits(e, k)
+					}
+				}
+			}
+		}
+		if ok {
+			valid[p-start] = k + 1
+		}
+	}
+	return valid[0] != 0
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, ColferError, ColferTail, ColferDepth,
+// ColferMax, and ColferUTF8 in strict text mode.
+func (o *Struct) UnmarshalBinary(data []byte) error {
+	i, err := o.unmarshal(data, true, 1)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
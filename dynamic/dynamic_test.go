@@ -0,0 +1,399 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/colfer"
+	gen "github.com/pascaldekloe/colfer/go"
+)
+
+// TestType returns gen.o from the test schema with the options of go/Makefile.
+func testType(t *testing.T) *colfer.Struct {
+	packages, err := colfer.ParseFiles("../testdata/test.colf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := packages[0]
+	p.StrictText = true
+	p.KeepUnknown = true
+	for _, s := range p.Structs {
+		if s.Name == "o" {
+			return s
+		}
+	}
+	t.Fatal("no struct gen.o in test schema")
+	return nil
+}
+
+// Corpus returns the fuzz samples.
+func corpus(t *testing.T) [][]byte {
+	paths, err := filepath.Glob("../testdata/corpus/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var samples [][]byte
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, data)
+	}
+	return samples
+}
+
+// SameError compares errors from distinct packages on type name and message.
+func sameError(dynamic, generated error) bool {
+	if dynamic == nil || generated == nil {
+		return dynamic == generated
+	}
+	return reflect.TypeOf(dynamic).Name() == reflect.TypeOf(generated).Name() &&
+		dynamic.Error() == generated.Error()
+}
+
+func TestUnmarshalLikeGenerated(t *testing.T) {
+	typ := testType(t)
+
+	origSizeMax, origGenSizeMax := ColferSizeMax, gen.ColferSizeMax
+	origListMax, origGenListMax := ColferListMax, gen.ColferListMax
+	origDepthMax, origGenDepthMax := ColferDepthMax, gen.ColferDepthMax
+	defer func() {
+		ColferSizeMax, gen.ColferSizeMax = origSizeMax, origGenSizeMax
+		ColferListMax, gen.ColferListMax = origListMax, origGenListMax
+		ColferDepthMax, gen.ColferDepthMax = origDepthMax, origGenDepthMax
+	}()
+
+	for _, limit := range []int{origSizeMax, 64, 9, 2} {
+		ColferSizeMax, gen.ColferSizeMax = limit, limit
+		ColferListMax, gen.ColferListMax = limit, limit
+		ColferDepthMax, gen.ColferDepthMax = limit, limit
+
+		for _, sample := range corpus(t) {
+			for end := 0; end <= len(sample); end++ {
+				data := sample[:end]
+
+				want := new(gen.O)
+				wantN, wantErr := want.Unmarshal(data)
+				got := New(typ)
+				gotN, gotErr := got.Unmarshal(data)
+				if gotN != wantN || !sameError(gotErr, wantErr) {
+					t.Errorf("0x%s with limit %d: got (%d, %v), want (%d, %v)", hex.EncodeToString(data), limit, gotN, gotErr, wantN, wantErr)
+					continue
+				}
+
+				wantErr = new(gen.O).UnmarshalBinary(data)
+				gotErr = New(typ).UnmarshalBinary(data)
+				if !sameError(gotErr, wantErr) {
+					t.Errorf("0x%s with limit %d: got binary error %v, want %v", hex.EncodeToString(data), limit, gotErr, wantErr)
+				}
+
+				if wantErr != nil {
+					continue
+				}
+				wantSerial, wantErr := want.MarshalBinary()
+				gotSerial, gotErr := got.MarshalBinary()
+				if !sameError(gotErr, wantErr) {
+					t.Errorf("0x%s with limit %d: got marshal error %v, want %v", hex.EncodeToString(data), limit, gotErr, wantErr)
+				} else if !bytes.Equal(gotSerial, wantSerial) {
+					t.Errorf("0x%s with limit %d: got marshal 0x%x, want 0x%x", hex.EncodeToString(data), limit, gotSerial, wantSerial)
+				}
+			}
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	typ := testType(t)
+
+	o := New(typ)
+	if err := o.Set("s", "hello"); err != nil {
+		t.Fatal("set text:", err)
+	}
+	if err := o.Set("t", time.Unix(1441739050, 777888999).In(time.UTC)); err != nil {
+		t.Fatal("set timestamp:", err)
+	}
+	nested := New(typ)
+	if err := nested.Set("u8", uint8(42)); err != nil {
+		t.Fatal("set uint8:", err)
+	}
+	if err := o.Set("os", []*Struct{nested, nil}); err != nil {
+		t.Fatal("set struct list:", err)
+	}
+
+	if err := o.Set("s", []byte("hello")); err == nil {
+		t.Error("set text with []byte: no error")
+	}
+	if err := o.Set("u8", 42); err == nil {
+		t.Error("set uint8 with int: no error")
+	}
+	if err := o.Set("nope", true); err == nil {
+		t.Error("set unknown field: no error")
+	}
+
+	data, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal:", err)
+	}
+	var want gen.O
+	if err := want.UnmarshalBinary(data); err != nil {
+		t.Fatalf("generated unmarshal of 0x%x: %s", data, err)
+	}
+	if want.S != "hello" || want.T.Unix() != 1441739050 || len(want.Os) != 2 || want.Os[0].U8 != 42 {
+		t.Errorf("generated unmarshal of 0x%x got %s", data, &want)
+	}
+
+	got := New(typ)
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal:", err)
+	}
+	if v, ok := got.Get("s"); !ok || v != "hello" {
+		t.Errorf("got text %#v, want %q", v, "hello")
+	}
+	if v, ok := got.Get("os"); !ok || len(v.([]*Struct)) != 2 {
+		t.Errorf("got struct list %#v, want 2 elements", v)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	typ := testType(t)
+
+	nested := New(typ)
+	nested.Set("i32", int32(-1))
+	o := New(typ)
+	o.Set("b", true)
+	o.Set("u64", uint64(math.MaxUint64))
+	o.Set("f32", float32(math.Inf(-1)))
+	o.Set("f64", math.NaN())
+	o.Set("t", time.Unix(1441739050, 777888999).In(time.UTC))
+	o.Set("s", "\"\x01\xff")
+	o.Set("a", []byte{0xfb, 0xff})
+	o.Set("os", []*Struct{nested, nil})
+	o.Set("f64s", []float64{0.5, -1e100})
+
+	got, err := o.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"b":true,"u32":0,"u64":18446744073709551615,"i32":0,"i64":0,"f32":"-Infinity","f64":"NaN","t":"2015-09-08T19:04:10.777888999Z","s":"\"\u0001�","a":"+/8=","o":null,"os":[{"b":false,"u32":0,"u64":0,"i32":-1,"i64":0,"f32":0,"f64":0,"t":null,"s":"","a":"","o":null,"os":[],"ss":[],"as":[],"u8":0,"u16":0,"f32s":[],"f64s":[]},null],"ss":[],"as":[],"u8":0,"u16":0,"f32s":[],"f64s":[0.5,-1e+100]}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if !json.Valid(got) {
+		t.Error("invalid JSON")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	typ := testType(t)
+
+	for _, sample := range corpus(t) {
+		o := New(typ)
+		if n, err := o.Unmarshal(sample); err != nil || n != len(sample) {
+			continue
+		}
+		want, err := o.MarshalBinary()
+		if err != nil {
+			continue
+		}
+
+		text, err := o.MarshalJSON()
+		if err != nil {
+			t.Errorf("0x%x: marshal JSON error: %s", sample, err)
+			continue
+		}
+		if !json.Valid(text) {
+			t.Errorf("0x%x: invalid JSON %s", sample, text)
+			continue
+		}
+		o = New(typ)
+		if err := o.UnmarshalJSON(text); err != nil {
+			t.Errorf("0x%x: unmarshal JSON %s error: %s", sample, text, err)
+			continue
+		}
+		again, err := o.MarshalJSON()
+		if err != nil {
+			t.Errorf("0x%x: marshal JSON error after JSON %s: %s", sample, text, err)
+		} else if !bytes.Equal(again, text) {
+			t.Errorf("0x%x: got JSON %s after JSON %s", sample, again, text)
+		}
+
+		// NaN payloads are lost
+		if bytes.Contains(text, []byte(`"NaN"`)) {
+			continue
+		}
+		got, err := o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%x: marshal error after JSON %s: %s", sample, text, err)
+		} else if !bytes.Equal(got, want) {
+			t.Errorf("0x%x: got serial 0x%x after JSON %s, want 0x%x", sample, got, text, want)
+		}
+	}
+}
+
+func TestJSONLikeGenerated(t *testing.T) {
+	typ := testType(t)
+
+	for _, sample := range corpus(t) {
+		want := new(gen.O)
+		if n, err := want.Unmarshal(sample); err != nil || n != len(sample) {
+			continue
+		}
+		wantText, err := want.MarshalJSON()
+		if err != nil {
+			continue
+		}
+
+		got := New(typ)
+		if _, err := got.Unmarshal(sample); err != nil {
+			t.Errorf("0x%x: unmarshal error: %s", sample, err)
+			continue
+		}
+		gotText, err := got.MarshalJSON()
+		if err != nil {
+			t.Errorf("0x%x: marshal JSON error: %s", sample, err)
+		} else if !bytes.Equal(gotText, wantText) {
+			t.Errorf("0x%x: got JSON %s, want %s", sample, gotText, wantText)
+		}
+
+		parsed := new(gen.O)
+		if err := parsed.UnmarshalJSON(gotText); err != nil {
+			t.Errorf("0x%x: generated unmarshal JSON %s error: %s", sample, gotText, err)
+		} else if !parsed.Equal(want) {
+			t.Errorf("0x%x: generated unmarshal JSON %s got %s, want %s", sample, gotText, parsed, want)
+		}
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	typ := testType(t)
+
+	golden := []struct{ json, err string }{
+		{`[]`, `colfer: gen.o JSON is not an object`},
+		{`{"x": 1}`, `colfer: JSON member "x" not in gen.o`},
+		{`{"u8": 256}`, `colfer: gen.o.u8 JSON 256: strconv.ParseUint: parsing "256": value out of range`},
+		{`{"i32": 1.5}`, `colfer: gen.o.i32 JSON 1.5: strconv.ParseInt: parsing "1.5": invalid syntax`},
+		{`{"b": "true"}`, `colfer: gen.o.b JSON "true": not a boolean`},
+		{`{"u32": true}`, `colfer: gen.o.u32 JSON true: not a number`},
+		{`{"s": 1}`, `colfer: gen.o.s JSON 1: not a string`},
+		{`{"f32s": {}}`, `colfer: gen.o.f32s JSON is not an array`},
+		{`{"f64": "nan"}`, `colfer: gen.o.f64 JSON "nan": string "nan" is not a floating point`},
+		{`{"t": 1.0000000001}`, `colfer: gen.o.t JSON 1.0000000001: timestamp 1.0000000001 exceeds nanosecond precision`},
+		{`{"a": "@"}`, `colfer: gen.o.a JSON "@": illegal base64 data at input byte 0`},
+		{`{"ss": ["a", "b", "c"]}`, `colfer: gen.o.ss length 3 exceeds 2 elements`},
+		{`{"s": "abc"}`, `colfer: gen.o.s size 3 exceeds 2 bytes`},
+		{`{"o": {"u16": -1}}`, `colfer: gen.o.u16 JSON -1: strconv.ParseUint: parsing "-1": invalid syntax`},
+	}
+
+	origSizeMax, origListMax := ColferSizeMax, ColferListMax
+	defer func() {
+		ColferSizeMax, ColferListMax = origSizeMax, origListMax
+	}()
+	ColferSizeMax, ColferListMax = 2, 2
+
+	for _, gold := range golden {
+		err := New(typ).UnmarshalJSON([]byte(gold.json))
+		if err == nil {
+			t.Errorf("%s: no error, want %q", gold.json, gold.err)
+		} else if err.Error() != gold.err {
+			t.Errorf("%s: got error %q, want %q", gold.json, err, gold.err)
+		}
+	}
+}
+
+func TestJSONUnix(t *testing.T) {
+	golden := []struct {
+		json string
+		t    time.Time
+	}{
+		{"864000000000", time.Unix(864e9, 0)},
+		{"-1.5", time.Unix(-2, 5e8)},
+		{"-0.000000001", time.Unix(-1, 999999999)},
+		{"-8640000000000.000001001", time.Unix(-864e10, -1001)},
+		{"9223372036854775807.999999999", time.Unix(math.MaxInt64, 999999999)},
+	}
+	for _, gold := range golden {
+		if got := string(appendJSONUnix(nil, gold.t)); got != gold.json {
+			t.Errorf("%s: got JSON %s, want %s", gold.t, got, gold.json)
+		}
+		got, err := parseJSONUnix(json.Number(gold.json))
+		if err != nil {
+			t.Errorf("%s: parse error: %s", gold.json, err)
+		} else if !got.Equal(gold.t) {
+			t.Errorf("%s: parsed %s, want %s", gold.json, got, gold.t)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	typ := testType(t)
+
+	data, _ := hex.DecodeString("0b02007f84017f7f" + "080161")
+	var buf bytes.Buffer
+	n, err := Inspect(&buf, typ, data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 {
+		t.Errorf("got %d bytes read, want 8", n)
+	}
+	const want = `     0  0b                      header 0x0b: field 11 os []gen.o
+     1  02                        2 elements
+     2                            [0]
+     2  00                          header 0x00: field 0 b bool = true
+     3  7f                          end of gen.o
+     4                            [1]
+     4  84                          header 0x84: field 4 i64 int64, flag for negative
+     5  01                            varint = -1
+     6  7f                          end of gen.o
+     7  7f                      end of gen.o
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got dump:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if _, err := Inspect(&buf, typ, data, 8); err == nil || err.Error() != "EOF" {
+		t.Errorf("got error %v for incomplete data, want EOF", err)
+	}
+	const wantEOF = `     8  08                      header 0x08: field 8 s text
+    11                          ! gen.o.s incomplete
+`
+	if got := buf.String(); got != wantEOF {
+		t.Errorf("got dump:\n%s\nwant:\n%s", got, wantEOF)
+	}
+}
+
+func TestInspectLikeUnmarshal(t *testing.T) {
+	typ := testType(t)
+
+	orig := ColferDepthMax
+	defer func() {
+		ColferDepthMax = orig
+	}()
+
+	for _, ColferDepthMax = range []int{orig, 2} {
+		for _, sample := range corpus(t) {
+			for end := 0; end <= len(sample); end++ {
+				data := sample[:end]
+				wantN, wantErr := New(typ).Unmarshal(data)
+
+				var buf bytes.Buffer
+				gotN, gotErr := Inspect(&buf, typ, data, 0)
+				if gotN != wantN || !sameError(gotErr, wantErr) {
+					t.Errorf("0x%x with ColferDepthMax=%d: got (%d, %v), want (%d, %v)", data, ColferDepthMax, gotN, gotErr, wantN, wantErr)
+				}
+				if diverged := bytes.Contains(buf.Bytes(), []byte("! ")); diverged != (wantErr != nil) {
+					t.Errorf("0x%x with ColferDepthMax=%d: got divergence %t for error %v:\n%s", data, ColferDepthMax, diverged, wantErr, buf.Bytes())
+				}
+			}
+		}
+	}
+}
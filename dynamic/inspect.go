@@ -0,0 +1,319 @@
+package dynamic
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// Inspect prints an annotated hex dump of the serial at data[offset:] to w.
+// Each line has a byte position in data, up to 8 bytes in hexadecimal, and a
+// description of the content. When decoding fails, then the dump ends with
+// the position where the serial diverges from the schema, marked with an
+// exclamation mark. The return values are equal to those of Unmarshal on
+// data[offset:], unless w fails.
+func Inspect(w io.Writer, t *colfer.Struct, data []byte, offset int) (int, error) {
+	ins := &inspector{w: w, data: data}
+	ins.structAt(t, offset, 0)
+	if ins.err != nil {
+		return 0, ins.err
+	}
+	return New(t).Unmarshal(data[offset:])
+}
+
+// Inspector writes annotated hex dumps.
+type inspector struct {
+	w     io.Writer
+	data  []byte
+	level int   // nesting of the struct in progress
+	err   error // first write error
+}
+
+func (ins *inspector) printf(format string, args ...interface{}) {
+	if ins.err == nil {
+		_, ins.err = fmt.Fprintf(ins.w, format, args...)
+	}
+}
+
+// Line prints the n bytes at data[pos:] with a description.
+func (ins *inspector) line(depth, pos, n int, format string, args ...interface{}) {
+	indent := strings.Repeat("  ", depth)
+	desc := fmt.Sprintf(format, args...)
+	if utf8.RuneCountInString(desc) > 64 {
+		desc = string([]rune(desc)[:63]) + "…"
+	}
+	for {
+		chunk := n
+		if chunk > 8 {
+			chunk = 8
+		}
+		hex := fmt.Sprintf("% x", ins.data[pos:pos+chunk])
+		ins.printf("%6d  %-24s%s%s\n", pos, hex, indent, desc)
+
+		pos += chunk
+		n -= chunk
+		if n <= 0 {
+			return
+		}
+		desc = "…"
+	}
+}
+
+// Diverge marks a decoding failure at pos.
+func (ins *inspector) diverge(depth, pos int, format string, args ...interface{}) {
+	ins.printf("%6d  %-24s%s! %s\n", pos, "", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+// StructAt annotates the struct at data[start:]. The return is the index
+// after the struct, or false when decoding diverges.
+func (ins *inspector) structAt(t *colfer.Struct, start, depth int) (end int, ok bool) {
+	ins.level++
+	defer func() { ins.level-- }()
+	if ins.level > ColferDepthMax {
+		ins.diverge(depth, start, "%s exceeds %d levels of nesting", t, ColferDepthMax)
+		return 0, false
+	}
+
+	data := ins.data[start:]
+	if len(data) == 0 {
+		ins.diverge(depth, start, "%s incomplete", t)
+		return 0, false
+	}
+	o := New(t)
+	header := data[0]
+	i := 1
+	last := -1 // field index
+
+	for fi, f := range t.Fields {
+		if header&0x7f != byte(f.Index) {
+			continue
+		}
+		headerPos := start + i - 1
+
+		if f.TypeRef != nil {
+			if header&0x80 != 0 {
+				break
+			}
+			end, ok := ins.refField(f, headerPos, depth)
+			if !ok {
+				return 0, false
+			}
+			i = end - start
+		} else {
+			next, _, err := o.unmarshalField(data, i, header, f, fi, ins.level)
+			if err != nil {
+				ins.line(depth, headerPos, 1, "%s", headerDesc(f, header))
+				if err == errEOF {
+					// the data ends within the field
+					ins.diverge(depth, len(ins.data), "%s incomplete", f)
+				} else {
+					ins.diverge(depth, headerPos+1, "%s", err)
+				}
+				return 0, false
+			}
+			if next == i {
+				continue // flag not applicable
+			}
+			ins.field(f, header, o.Values[fi], headerPos, depth)
+			i = next - 1
+		}
+
+		last = fi
+		if i >= len(data) {
+			ins.diverge(depth, start+i, "%s incomplete", t)
+			return 0, false
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		pos := start + i - 1
+		index := int(header & 0x7f)
+		switch {
+		case index >= len(t.Fields):
+			ins.line(depth, pos, 1, "header %#02x: unknown field %d", header, index)
+			ins.diverge(depth, pos, "%s has %d fields", t, len(t.Fields))
+		case index <= last:
+			ins.line(depth, pos, 1, "%s", headerDesc(t.Fields[index], header))
+			ins.diverge(depth, pos, "field %d out of order", index)
+		default:
+			ins.line(depth, pos, 1, "%s", headerDesc(t.Fields[index], header))
+			ins.diverge(depth, pos, "flag not applicable to %s", t.Fields[index])
+		}
+		return 0, false
+	}
+	ins.line(depth, start+i-1, 1, "end of %s", t)
+	if i >= ColferSizeMax {
+		ins.diverge(depth, start+i-1, "%s size %d exceeds %d bytes", t, i, ColferSizeMax)
+		return 0, false
+	}
+	return start + i, true
+}
+
+// RefField annotates a field with a struct reference. The return is the
+// index after the value, or false when decoding diverges.
+func (ins *inspector) refField(f *colfer.Field, headerPos, depth int) (end int, ok bool) {
+	ins.line(depth, headerPos, 1, "%s", headerDesc(f, ins.data[headerPos]))
+	if !f.TypeList {
+		return ins.structAt(f.TypeRef, headerPos+1, depth+1)
+	}
+
+	x, end, err := uvarint(ins.data, headerPos+1)
+	if err != nil {
+		ins.diverge(depth, end, "%s incomplete", f)
+		return 0, false
+	}
+	ins.line(depth+1, headerPos+1, end-headerPos-1, "%d elements", x)
+	if x > uint(ColferListMax) {
+		ins.diverge(depth+1, headerPos+1, "%s length %d exceeds %d elements", f, x, ColferListMax)
+		return 0, false
+	}
+	for ai := 0; ai < int(x); ai++ {
+		ins.printf("%6d  %-24s%s[%d]\n", end, "", strings.Repeat("  ", depth+1), ai)
+		end, ok = ins.structAt(f.TypeRef, end, depth+2)
+		if !ok {
+			return 0, false
+		}
+	}
+	return end, true
+}
+
+// HeaderDesc returns a description of a field header.
+func headerDesc(f *colfer.Field, header byte) string {
+	typ := f.Type
+	if f.TypeRef != nil {
+		typ = f.TypeRef.String()
+	}
+	if f.TypeList {
+		typ = "[]" + typ
+	}
+	desc := fmt.Sprintf("header %#02x: field %d %s %s", header, f.Index, f.Name, typ)
+	if header&0x80 == 0 {
+		return desc
+	}
+
+	switch f.Type {
+	case "uint16":
+		return desc + ", flag for 1 byte"
+	case "uint32", "uint64":
+		return desc + ", flag for fixed size"
+	case "int32", "int64":
+		return desc + ", flag for negative"
+	case "timestamp":
+		return desc + ", flag for 64-bit seconds"
+	}
+	return desc + ", flag"
+}
+
+// Field annotates a decoded value, which does not reference a struct.
+func (ins *inspector) field(f *colfer.Field, header byte, v interface{}, headerPos, depth int) {
+	if f.Type == "bool" {
+		ins.line(depth, headerPos, 1, "%s = true", headerDesc(f, header))
+		return
+	}
+	ins.line(depth, headerPos, 1, "%s", headerDesc(f, header))
+	depth++
+	pos := headerPos + 1
+	flag := header&0x80 != 0
+
+	if f.TypeList {
+		x, end, _ := uvarint(ins.data, pos)
+		ins.line(depth, pos, end-pos, "%d elements", x)
+		pos = end
+	}
+
+	switch v := v.(type) {
+	case uint8:
+		ins.line(depth, pos, 1, "= %d", v)
+	case uint16:
+		if flag {
+			ins.line(depth, pos, 1, "= %d", v)
+		} else {
+			ins.line(depth, pos, 2, "= %d", v)
+		}
+	case uint32:
+		if flag {
+			ins.line(depth, pos, 4, "= %d", v)
+		} else {
+			_, end, _ := fieldVarint(ins.data, pos, 0)
+			ins.line(depth, pos, end-pos, "varint = %d", v)
+		}
+	case uint64:
+		if flag {
+			ins.line(depth, pos, 8, "= %d", v)
+		} else {
+			_, end, _ := fieldVarint(ins.data, pos, 56)
+			ins.line(depth, pos, end-pos, "varint = %d", v)
+		}
+	case int32:
+		_, end, _ := fieldVarint(ins.data, pos, 0)
+		ins.line(depth, pos, end-pos, "varint = %d", v)
+	case int64:
+		_, end, _ := fieldVarint(ins.data, pos, 56)
+		ins.line(depth, pos, end-pos, "varint = %d", v)
+	case float32:
+		ins.line(depth, pos, 4, "= %v", v)
+	case float64:
+		ins.line(depth, pos, 8, "= %v", v)
+	case time.Time:
+		if flag {
+			ins.line(depth, pos, 8, "= %d s", v.Unix())
+			pos += 8
+		} else {
+			ins.line(depth, pos, 4, "= %d s", v.Unix())
+			pos += 4
+		}
+		ins.line(depth, pos, 4, "= %d ns, %s", v.Nanosecond(), v.Format(time.RFC3339Nano))
+	case string:
+		ins.sized(depth, pos, "%q", v)
+	case []byte:
+		ins.sized(depth, pos, "binary")
+
+	case []int32:
+		for ai, v := range v {
+			_, end, _ := fieldVarint(ins.data, pos, 0)
+			ins.line(depth, pos, end-pos, "[%d] zig-zag varint = %d", ai, v)
+			pos = end
+		}
+	case []int64:
+		for ai, v := range v {
+			_, end, _ := fieldVarint(ins.data, pos, 56)
+			ins.line(depth, pos, end-pos, "[%d] zig-zag varint = %d", ai, v)
+			pos = end
+		}
+	case []float32:
+		for ai, v := range v {
+			ins.line(depth, pos, 4, "[%d] = %v", ai, v)
+			pos += 4
+		}
+	case []float64:
+		for ai, v := range v {
+			ins.line(depth, pos, 8, "[%d] = %v", ai, v)
+			pos += 8
+		}
+	case []string:
+		for ai, v := range v {
+			pos = ins.sized(depth, pos, "[%d] %q", ai, v)
+		}
+	case [][]byte:
+		for ai := range v {
+			pos = ins.sized(depth, pos, "[%d] binary", ai)
+		}
+	}
+}
+
+// Sized annotates a text or binary at data[pos:], including the size, and it
+// returns the index after the content.
+func (ins *inspector) sized(depth, pos int, format string, args ...interface{}) int {
+	x, start, _ := uvarint(ins.data, pos)
+	ins.line(depth, pos, start-pos, "size %d", x)
+	if x != 0 {
+		ins.line(depth, start, int(x), format, args...)
+	}
+	return start + int(x)
+}
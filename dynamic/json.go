@@ -0,0 +1,492 @@
+package dynamic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+// MarshalJSON encodes o as a JSON object conform json.Marshaler. The members
+// are in order of the schema, with the field names from the schema.
+//
+// Timestamps are RFC 3339 strings, except for years beyond the 0–9999 range,
+// which are exact decimal numbers of seconds since the Unix epoch instead.
+// Binaries are base64 strings (standard encoding). Not-a-number and infinite floating points are represented by the
+// strings "NaN", "Infinity" and "-Infinity". A zero timestamp and an absent
+// struct are null.
+func (o *Struct) MarshalJSON() ([]byte, error) {
+	return o.AppendJSON(nil)
+}
+
+// AppendJSON appends the MarshalJSON encoding of o to buf.
+func (o *Struct) AppendJSON(buf []byte) ([]byte, error) {
+	if o == nil {
+		return append(buf, "null"...), nil
+	}
+	o.init()
+
+	buf = append(buf, '{')
+	for fi, f := range o.Type.Fields {
+		if fi != 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, f.Name)
+		buf = append(buf, ':')
+
+		switch v := o.Values[fi].(type) {
+		case bool:
+			buf = strconv.AppendBool(buf, v)
+		case uint8:
+			buf = strconv.AppendUint(buf, uint64(v), 10)
+		case uint16:
+			buf = strconv.AppendUint(buf, uint64(v), 10)
+		case uint32:
+			buf = strconv.AppendUint(buf, uint64(v), 10)
+		case uint64:
+			buf = strconv.AppendUint(buf, v, 10)
+		case int32:
+			buf = strconv.AppendInt(buf, int64(v), 10)
+		case int64:
+			buf = strconv.AppendInt(buf, v, 10)
+		case float32:
+			buf = appendJSONFloat(buf, float64(v), 32)
+		case float64:
+			buf = appendJSONFloat(buf, v, 64)
+		case time.Time:
+			switch {
+			case v.IsZero():
+				buf = append(buf, "null"...)
+			case v.Year() < 0 || v.Year() > 9999:
+				buf = appendJSONUnix(buf, v)
+			default:
+				buf = append(buf, '"')
+				buf = v.AppendFormat(buf, time.RFC3339Nano)
+				buf = append(buf, '"')
+			}
+		case string:
+			buf = appendJSONString(buf, v)
+		case []byte:
+			buf = appendJSONBinary(buf, v)
+		case *Struct:
+			var err error
+			buf, err = v.AppendJSON(buf)
+			if err != nil {
+				return nil, err
+			}
+
+		case []int32:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf = strconv.AppendInt(buf, int64(v), 10)
+			}
+			buf = append(buf, ']')
+		case []int64:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf = strconv.AppendInt(buf, v, 10)
+			}
+			buf = append(buf, ']')
+		case []float32:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf = appendJSONFloat(buf, float64(v), 32)
+			}
+			buf = append(buf, ']')
+		case []float64:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf = appendJSONFloat(buf, v, 64)
+			}
+			buf = append(buf, ']')
+		case []string:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf = appendJSONString(buf, v)
+			}
+			buf = append(buf, ']')
+		case [][]byte:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				buf = appendJSONBinary(buf, v)
+			}
+			buf = append(buf, ']')
+		case []*Struct:
+			buf = append(buf, '[')
+			for i, v := range v {
+				if i != 0 {
+					buf = append(buf, ',')
+				}
+				var err error
+				buf, err = v.AppendJSON(buf)
+				if err != nil {
+					return nil, err
+				}
+			}
+			buf = append(buf, ']')
+
+		default:
+			return nil, fmt.Errorf("colfer: %s has Go type %T", f, v)
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"Infinity"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Infinity"`...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// AppendJSONUnix appends t as a decimal number of seconds.
+func appendJSONUnix(buf []byte, t time.Time) []byte {
+	s, ns := t.Unix(), t.Nanosecond()
+	if s < 0 {
+		buf = append(buf, '-')
+		if ns != 0 {
+			s++
+			ns = 1e9 - ns
+		}
+		buf = strconv.AppendUint(buf, uint64(-s), 10)
+	} else {
+		buf = strconv.AppendInt(buf, s, 10)
+	}
+
+	if ns != 0 {
+		fraction := []byte(strconv.Itoa(ns + 1e9))[1:]
+		for fraction[len(fraction)-1] == '0' {
+			fraction = fraction[:len(fraction)-1]
+		}
+		buf = append(buf, '.')
+		buf = append(buf, fraction...)
+	}
+	return buf
+}
+
+// ParseJSONUnix is the inverse of appendJSONUnix.
+func parseJSONUnix(n json.Number) (time.Time, error) {
+	digits := n.String()
+	negative := strings.HasPrefix(digits, "-")
+	if negative {
+		digits = digits[1:]
+	}
+
+	var fraction string
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		digits, fraction = digits[:i], digits[i+1:]
+	}
+	if len(fraction) > 9 {
+		return time.Time{}, fmt.Errorf("timestamp %s exceeds nanosecond precision", n)
+	}
+
+	s, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || s > 1<<63 || (s == 1<<63 && !negative) {
+		return time.Time{}, fmt.Errorf("timestamp %s is not a whole number of seconds with an optional fraction in range", n)
+	}
+	var ns int64
+	if fraction != "" {
+		ns, err = strconv.ParseInt((fraction + "00000000")[:9], 10, 64)
+		if err != nil || fraction[0] == '-' || fraction[0] == '+' {
+			return time.Time{}, fmt.Errorf("timestamp %s has a malformed fraction", n)
+		}
+	}
+
+	if !negative {
+		return time.Unix(int64(s), ns).In(time.UTC), nil
+	}
+	if ns != 0 {
+		if s == 1<<63 {
+			return time.Time{}, fmt.Errorf("timestamp %s out of range", n)
+		}
+		s++
+		ns = 1e9 - ns
+	}
+	return time.Unix(-int64(s), ns).In(time.UTC), nil
+}
+
+func appendJSONBinary(buf []byte, v []byte) []byte {
+	buf = append(buf, '"')
+	buf = append(buf, base64.StdEncoding.EncodeToString(v)...)
+	return append(buf, '"')
+}
+
+// AppendJSONString appends s as a JSON string. Malformed UTF-8 is replaced
+// with the Unicode replacement character.
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < ' ':
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, "\ufffd"...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// UnmarshalJSON decodes data conform json.Unmarshaler, with the format of
+// MarshalJSON. Absent members and null values leave the respective fields as
+// is. Numbers must fit their type without loss. The error return options
+// include ColferMax for limit breaches.
+func (o *Struct) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return fmt.Errorf("colfer: %s JSON is not an object", o.Type)
+	}
+	o.init()
+
+	for name := range members {
+		if o.Field(name) == nil {
+			return fmt.Errorf("colfer: JSON member %q not in %s", name, o.Type)
+		}
+	}
+
+	for fi, f := range o.Type.Fields {
+		raw, ok := members[f.Name]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+		v, err := unmarshalJSONField(f, raw)
+		if err != nil {
+			return err
+		}
+		o.Values[fi] = v
+	}
+	return nil
+}
+
+func unmarshalJSONField(f *colfer.Field, raw json.RawMessage) (interface{}, error) {
+	if f.TypeList {
+		var elements []json.RawMessage
+		if err := json.Unmarshal(raw, &elements); err != nil {
+			return nil, fmt.Errorf("colfer: %s JSON is not an array", f)
+		}
+		if len(elements) > ColferListMax {
+			return nil, ColferMax(fmt.Sprintf("colfer: %s length %d exceeds %d elements", f, len(elements), ColferListMax))
+		}
+
+		list := reflect.MakeSlice(reflect.TypeOf(zero(f)), len(elements), len(elements))
+		for i, raw := range elements {
+			v, err := unmarshalJSONValue(f, raw)
+			if err != nil {
+				return nil, err
+			}
+			if v != nil {
+				list.Index(i).Set(reflect.ValueOf(v))
+			}
+		}
+		return list.Interface(), nil
+	}
+
+	return unmarshalJSONValue(f, raw)
+}
+
+// UnmarshalJSONValue decodes a single value, i.e., a list element for list
+// types. The return is nil for null.
+func unmarshalJSONValue(f *colfer.Field, raw json.RawMessage) (interface{}, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	if f.TypeRef != nil {
+		o := New(f.TypeRef)
+		if err := o.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+		return o, nil
+	}
+
+	var s string
+	var err error
+	switch f.Type {
+	case "bool":
+		var b bool
+		if json.Unmarshal(raw, &b) == nil {
+			return b, nil
+		}
+		err = errors.New("not a boolean")
+
+	case "uint8":
+		var x uint64
+		if x, err = jsonUint(raw, 8); err == nil {
+			return uint8(x), nil
+		}
+	case "uint16":
+		var x uint64
+		if x, err = jsonUint(raw, 16); err == nil {
+			return uint16(x), nil
+		}
+	case "uint32":
+		var x uint64
+		if x, err = jsonUint(raw, 32); err == nil {
+			return uint32(x), nil
+		}
+	case "uint64":
+		var x uint64
+		if x, err = jsonUint(raw, 64); err == nil {
+			return x, nil
+		}
+	case "int32":
+		var x int64
+		if x, err = jsonInt(raw, 32); err == nil {
+			return int32(x), nil
+		}
+	case "int64":
+		var x int64
+		if x, err = jsonInt(raw, 64); err == nil {
+			return x, nil
+		}
+
+	case "float32", "float64":
+		bitSize := 64
+		if f.Type == "float32" {
+			bitSize = 32
+		}
+		var x float64
+		if json.Unmarshal(raw, &s) == nil {
+			switch s {
+			case "NaN":
+				x = math.NaN()
+			case "Infinity":
+				x = math.Inf(1)
+			case "-Infinity":
+				x = math.Inf(-1)
+			default:
+				err = fmt.Errorf("string %q is not a floating point", s)
+			}
+		} else {
+			var n json.Number
+			if json.Unmarshal(raw, &n) == nil {
+				x, err = strconv.ParseFloat(n.String(), bitSize)
+			} else {
+				err = errNotNumber
+			}
+		}
+		if err == nil {
+			if bitSize == 32 {
+				return float32(x), nil
+			}
+			return x, nil
+		}
+
+	case "timestamp":
+		var t time.Time
+		if json.Unmarshal(raw, &s) == nil {
+			t, err = time.Parse(time.RFC3339Nano, s)
+		} else {
+			var n json.Number
+			if json.Unmarshal(raw, &n) == nil {
+				t, err = parseJSONUnix(n)
+			} else {
+				err = errors.New("not a string nor a number")
+			}
+		}
+		if err == nil {
+			return t.In(time.UTC), nil
+		}
+
+	case "text":
+		err = errNotString
+		if json.Unmarshal(raw, &s) == nil {
+			if len(s) > ColferSizeMax {
+				return nil, ColferMax(fmt.Sprintf("colfer: %s size %d exceeds %d bytes", f, len(s), ColferSizeMax))
+			}
+			return s, nil
+		}
+
+	case "binary":
+		err = errNotString
+		if json.Unmarshal(raw, &s) == nil {
+			var b []byte
+			b, err = base64.StdEncoding.DecodeString(s)
+			if err == nil {
+				if len(b) > ColferSizeMax {
+					return nil, ColferMax(fmt.Sprintf("colfer: %s size %d exceeds %d bytes", f, len(b), ColferSizeMax))
+				}
+				return b, nil
+			}
+		}
+
+	default:
+		panic("colfer: unknown datatype " + f.Type)
+	}
+
+	return nil, fmt.Errorf("colfer: %s JSON %s: %w", f, raw, err)
+}
+
+var (
+	errNotNumber = errors.New("not a number")
+	errNotString = errors.New("not a string")
+)
+
+func jsonUint(raw json.RawMessage, bitSize int) (uint64, error) {
+	var n json.Number
+	if json.Unmarshal(raw, &n) != nil {
+		return 0, errNotNumber
+	}
+	return strconv.ParseUint(n.String(), 10, bitSize)
+}
+
+func jsonInt(raw json.RawMessage, bitSize int) (int64, error) {
+	var n json.Number
+	if json.Unmarshal(raw, &n) != nil {
+		return 0, errNotNumber
+	}
+	return strconv.ParseInt(n.String(), 10, bitSize)
+}
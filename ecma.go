@@ -66,6 +66,9 @@ const ecmaCode = `// Code generated by colf(1); DO NOT EDIT.
 {{.DocText "// "}}
 var {{.NameNative}} = new function() {
 	const EOF = 'colfer: EOF';
+{{- if .StrictText}}
+	const MALFORMED_UTF8 = 'colfer: malformed UTF-8';
+{{- end}}
 
 	// The upper limit for serial byte sizes.
 	var colferSizeMax = {{.SizeMax}};
@@ -73,6 +76,8 @@ var {{.NameNative}} = new function() {
 	// The upper limit for the number of elements in a list.
 	var colferListMax = {{.ListMax}};
 {{- end}}
+	// The upper limit for the nesting level of data structures, with 1 for the top-level.
+	var colferDepthMax = {{.DepthMax}};
 {{range .Structs}}
 	// Constructor.
 {{.DocText "\t// "}}
@@ -93,6 +98,9 @@ var {{.NameNative}} = new function() {
 
 		for (var p in init) this[p] = init[p];
 	}
+
+	// The schema digest, which changes with any change to the fields, including the data structures referred to.
+	this.{{.NameNative}}.colferFingerprint = '{{.Fingerprint}}';
 {{template "marshal" .}}
 {{template "unmarshal" .}}
 {{end}}
@@ -135,16 +143,28 @@ var {{.NameNative}} = new function() {
 			if (c < 2048) {
 				bytes[i++] = c >> 6 | 192;
 			} else {
+{{- if .StrictText}}
+				if (c > 0xdbff && c < 0xe000)
+					throw new Error(MALFORMED_UTF8);
+{{- end}}
 				if (c > 0xd7ff && c < 0xdc00) {
 					if (++ci >= s.length) {
+{{- if .StrictText}}
+						throw new Error(MALFORMED_UTF8);
+{{- else}}
 						bytes[i++] = 63;
 						continue;
+{{- end}}
 					}
 					var c2 = s.charCodeAt(ci);
 					if (c2 < 0xdc00 || c2 > 0xdfff) {
+{{- if .StrictText}}
+						throw new Error(MALFORMED_UTF8);
+{{- else}}
 						bytes[i++] = 63;
 						--ci;
 						continue;
+{{- end}}
 					}
 					c = 0x10000 + ((c & 0x03ff) << 10) + (c2 & 0x03ff);
 					bytes[i++] = c >> 18 | 240;
@@ -161,6 +181,28 @@ var {{.NameNative}} = new function() {
 		var i = 0, s = '';
 		while (i < bytes.length) {
 			var c = bytes[i++];
+{{- if .StrictText}}
+			if (c > 127) {
+				// RFC 3629
+				var n, min;
+				if (c > 193 && c < 224) {
+					n = 1, min = 0x80, c &= 31;
+				} else if (c > 223 && c < 240) {
+					n = 2, min = 0x800, c &= 15;
+				} else if (c > 239 && c < 245) {
+					n = 3, min = 0x10000, c &= 7;
+				} else throw new Error(MALFORMED_UTF8);
+
+				if (i + n > bytes.length) throw new Error(MALFORMED_UTF8);
+				for (; n != 0; --n) {
+					var b = bytes[i++];
+					if ((b & 192) != 128) throw new Error(MALFORMED_UTF8);
+					c = c << 6 | b & 63;
+				}
+				if (c < min || c > 0x10ffff || (c > 0xd7ff && c < 0xe000))
+					throw new Error(MALFORMED_UTF8);
+			}
+{{- else}}
 			if (c > 127) {
 				if (c > 191 && c < 224) {
 					c = (i >= bytes.length) ? 63 : (c & 31) << 6 | bytes[i++] & 63;
@@ -170,6 +212,7 @@ var {{.NameNative}} = new function() {
 					c = (i + 2 >= bytes.length) ? 63 : (c & 7) << 18 | (bytes[i++] & 63) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
 				} else c = 63
 			}
+{{- end}}
 
 			if (c <= 0xffff) s += String.fromCharCode(c);
 			else if (c > 0x10ffff) s += '?';
@@ -289,7 +332,12 @@ const ecmaMarshal = `
 			a.forEach(function(f, fi) {
 				if (f > 3.4028234663852886E38 || f < -3.4028234663852886E38)
 					throw new Error('colfer: {{.String}}[' + fi + '] exceeds 32-bit range');
+ {{- if .Struct.Pkg.Canonical}}
+				if (Number.isNaN(f)) buf.set([0x7f, 0xc0, 0, 0], i);
+				else view.setFloat32(i, f);
+ {{- else}}
 				view.setFloat32(i, f);
+ {{- end}}
 				i += 4;
 			});
 		}
@@ -314,7 +362,12 @@ const ecmaMarshal = `
 			buf[i++] = {{.Index}};
 			i = encodeVarint(buf, i, a.length);
 			a.forEach(function(f) {
+ {{- if .Struct.Pkg.Canonical}}
+				if (Number.isNaN(f)) buf.set([0x7f, 0xf8, 0, 0, 0, 0, 0, 0], i);
+				else view.setFloat64(i, f);
+ {{- else}}
 				view.setFloat64(i, f);
+ {{- end}}
 				i += 8;
 			});
 		}
@@ -459,7 +512,11 @@ const ecmaMarshal = `
 
 const ecmaUnmarshal = `
 	// Deserializes the object from an Uint8Array and returns the number of bytes read.
-	this.{{.NameNative}}.prototype.unmarshal = function(data) {
+	// The optional depth is the nesting level of the object, with 1 for the top-level.
+	this.{{.NameNative}}.prototype.unmarshal = function(data, depth) {
+		if (!depth) depth = 1;
+		if (depth > colferDepthMax)
+			throw new RangeError('colfer: depth limit: struct {{.String}} exceeds ' + colferDepthMax + ' levels');
 		if (!data || ! data.length) throw new Error(EOF);
 		var header = data[0];
 		var i = 1;
@@ -631,6 +688,8 @@ const ecmaUnmarshal = `
 			var l = readVarint();
 			if (l < 0 || l > colferListMax)
 				throw new Error('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			// elements take one byte at least
+			if (i + l >= data.length) throw new Error(EOF);
 
 			this.{{.NameNative}} = new Array(l);
 			for (var n = 0; n < l; ++n) {
@@ -661,6 +720,8 @@ const ecmaUnmarshal = `
 			var l = readVarint();
 			if (l < 0 || l > colferListMax)
 				throw new Error('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			// elements take one byte at least
+			if (i + l >= data.length) throw new Error(EOF);
 
 			this.{{.NameNative}} = new Array(l);
 			for (var n = 0; n < l; ++n) {
@@ -690,10 +751,13 @@ const ecmaUnmarshal = `
 			var l = readVarint();
 			if (l < 0 || l > colferListMax)
 				throw new Error('colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements');
+			// elements take one byte at least
+			if (i + l >= data.length) throw new Error(EOF);
 
 			for (var n = 0; n < l; ++n) {
 				var o = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameNative}}();
-				i += o.unmarshal(data.subarray(i));
+				// reserve one byte for each element pending
+				i += o.unmarshal(data.subarray(i, data.length - (l - 1 - n)), depth + 1);
 				this.{{.NameNative}}[n] = o;
 			}
 			readHeader();
@@ -701,7 +765,7 @@ const ecmaUnmarshal = `
 {{else}}
 		if (header == {{.Index}}) {
 			var o = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameNative}}();
-			i += o.unmarshal(data.subarray(i));
+			i += o.unmarshal(data.subarray(i), depth + 1);
 			this.{{.NameNative}} = o;
 			readHeader();
 		}
@@ -710,4 +774,32 @@ const ecmaUnmarshal = `
 		if (i > colferSizeMax)
 			throw new Error('colfer: {{.String}} serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes');
 		return i;
-	}`
+	}
+{{- if .Pkg.Canonical}}
+
+	// Deserializes the object like unmarshal, yet it rejects any serial other than the one marshal produces for the result.
+	// Equal values have identical serials as such. Data after the serial is rejected too.
+	// The check serializes the result for comparison, which costs about as much as the deserialization.
+	this.{{.NameNative}}.prototype.unmarshalCanonical = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		var serial = this.marshal();
+		for (var i = 0; i < serial.length; i++) {
+			if (i >= n || serial[i] != data[i])
+				throw new Error('colfer: non-canonical encoding at byte ' + i);
+		}
+		if (serial.length != n)
+			throw new Error('colfer: non-canonical encoding at byte ' + serial.length);
+		return n;
+	}
+
+	// Deserializes the object from an Uint8Array, and returns the canonical serial of the result, as marshal produces, in a new Uint8Array.
+	// Data after the serial is rejected.
+	this.{{.NameNative}}.prototype.canonicalize = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		return this.marshal().slice();
+	}
+{{- end}}`
@@ -4,11 +4,14 @@
 // Package gen tests all field mapping options.
 var gen = new function() {
 	const EOF = 'colfer: EOF';
+	const MALFORMED_UTF8 = 'colfer: malformed UTF-8';
 
 	// The upper limit for serial byte sizes.
 	var colferSizeMax = 16 * 1024 * 1024;
 	// The upper limit for the number of elements in a list.
 	var colferListMax = 64 * 1024;
+	// The upper limit for the nesting level of data structures, with 1 for the top-level.
+	var colferDepthMax = 100;
 
 	// Constructor.
 	// O contains all supported data types.
@@ -55,6 +58,9 @@ var gen = new function() {
 		for (var p in init) this[p] = init[p];
 	}
 
+	// The schema digest, which changes with any change to the fields, including the data structures referred to.
+	this.O.colferFingerprint = '98768e7cc2fcda72';
+
 	// Serializes the object into an Uint8Array.
 	// All null entries in property os will be replaced with a new gen.O.
 	// All null entries in property ss will be replaced with an empty String.
@@ -292,7 +298,8 @@ var gen = new function() {
 			a.forEach(function(f, fi) {
 				if (f > 3.4028234663852886E38 || f < -3.4028234663852886E38)
 					throw new Error('colfer: gen.o.f32s[' + fi + '] exceeds 32-bit range');
-				view.setFloat32(i, f);
+				if (Number.isNaN(f)) buf.set([0x7f, 0xc0, 0, 0], i);
+				else view.setFloat32(i, f);
 				i += 4;
 			});
 		}
@@ -304,7 +311,8 @@ var gen = new function() {
 			buf[i++] = 17;
 			i = encodeVarint(buf, i, a.length);
 			a.forEach(function(f) {
-				view.setFloat64(i, f);
+				if (Number.isNaN(f)) buf.set([0x7f, 0xf8, 0, 0, 0, 0, 0, 0], i);
+				else view.setFloat64(i, f);
 				i += 8;
 			});
 		}
@@ -317,7 +325,11 @@ var gen = new function() {
 	}
 
 	// Deserializes the object from an Uint8Array and returns the number of bytes read.
-	this.O.prototype.unmarshal = function(data) {
+	// The optional depth is the nesting level of the object, with 1 for the top-level.
+	this.O.prototype.unmarshal = function(data, depth) {
+		if (!depth) depth = 1;
+		if (depth > colferDepthMax)
+			throw new RangeError('colfer: depth limit: struct gen.o exceeds ' + colferDepthMax + ' levels');
 		if (!data || ! data.length) throw new Error(EOF);
 		var header = data[0];
 		var i = 1;
@@ -467,7 +479,7 @@ var gen = new function() {
 
 		if (header == 10) {
 			var o = new gen.O();
-			i += o.unmarshal(data.subarray(i));
+			i += o.unmarshal(data.subarray(i), depth + 1);
 			this.o = o;
 			readHeader();
 		}
@@ -476,10 +488,13 @@ var gen = new function() {
 			var l = readVarint();
 			if (l < 0 || l > colferListMax)
 				throw new Error('colfer: gen.o.os length ' + l + ' exceeds ' + colferListMax + ' elements');
+			// elements take one byte at least
+			if (i + l >= data.length) throw new Error(EOF);
 
 			for (var n = 0; n < l; ++n) {
 				var o = new gen.O();
-				i += o.unmarshal(data.subarray(i));
+				// reserve one byte for each element pending
+				i += o.unmarshal(data.subarray(i, data.length - (l - 1 - n)), depth + 1);
 				this.os[n] = o;
 			}
 			readHeader();
@@ -489,6 +504,8 @@ var gen = new function() {
 			var l = readVarint();
 			if (l < 0 || l > colferListMax)
 				throw new Error('colfer: gen.o.ss length ' + l + ' exceeds ' + colferListMax + ' elements');
+			// elements take one byte at least
+			if (i + l >= data.length) throw new Error(EOF);
 
 			this.ss = new Array(l);
 			for (var n = 0; n < l; ++n) {
@@ -508,6 +525,8 @@ var gen = new function() {
 			var l = readVarint();
 			if (l < 0 || l > colferListMax)
 				throw new Error('colfer: gen.o.as length ' + l + ' exceeds ' + colferListMax + ' elements');
+			// elements take one byte at least
+			if (i + l >= data.length) throw new Error(EOF);
 
 			this.as = new Array(l);
 			for (var n = 0; n < l; ++n) {
@@ -574,6 +593,32 @@ var gen = new function() {
 		return i;
 	}
 
+	// Deserializes the object like unmarshal, yet it rejects any serial other than the one marshal produces for the result.
+	// Equal values have identical serials as such. Data after the serial is rejected too.
+	// The check serializes the result for comparison, which costs about as much as the deserialization.
+	this.O.prototype.unmarshalCanonical = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		var serial = this.marshal();
+		for (var i = 0; i < serial.length; i++) {
+			if (i >= n || serial[i] != data[i])
+				throw new Error('colfer: non-canonical encoding at byte ' + i);
+		}
+		if (serial.length != n)
+			throw new Error('colfer: non-canonical encoding at byte ' + serial.length);
+		return n;
+	}
+
+	// Deserializes the object from an Uint8Array, and returns the canonical serial of the result, as marshal produces, in a new Uint8Array.
+	// Data after the serial is rejected.
+	this.O.prototype.canonicalize = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		return this.marshal().slice();
+	}
+
 	// Constructor.
 	// DromedaryCase oposes name casings.
 	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
@@ -584,6 +629,9 @@ var gen = new function() {
 		for (var p in init) this[p] = init[p];
 	}
 
+	// The schema digest, which changes with any change to the fields, including the data structures referred to.
+	this.DromedaryCase.colferFingerprint = 'f21325a195f2e6e5';
+
 	// Serializes the object into an Uint8Array.
 	this.DromedaryCase.prototype.marshal = function(buf) {
 		if (! buf || !buf.length) buf = new Uint8Array(colferSizeMax);
@@ -607,7 +655,11 @@ var gen = new function() {
 	}
 
 	// Deserializes the object from an Uint8Array and returns the number of bytes read.
-	this.DromedaryCase.prototype.unmarshal = function(data) {
+	// The optional depth is the nesting level of the object, with 1 for the top-level.
+	this.DromedaryCase.prototype.unmarshal = function(data, depth) {
+		if (!depth) depth = 1;
+		if (depth > colferDepthMax)
+			throw new RangeError('colfer: depth limit: struct gen.dromedaryCase exceeds ' + colferDepthMax + ' levels');
 		if (!data || ! data.length) throw new Error(EOF);
 		var header = data[0];
 		var i = 1;
@@ -652,6 +704,32 @@ var gen = new function() {
 		return i;
 	}
 
+	// Deserializes the object like unmarshal, yet it rejects any serial other than the one marshal produces for the result.
+	// Equal values have identical serials as such. Data after the serial is rejected too.
+	// The check serializes the result for comparison, which costs about as much as the deserialization.
+	this.DromedaryCase.prototype.unmarshalCanonical = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		var serial = this.marshal();
+		for (var i = 0; i < serial.length; i++) {
+			if (i >= n || serial[i] != data[i])
+				throw new Error('colfer: non-canonical encoding at byte ' + i);
+		}
+		if (serial.length != n)
+			throw new Error('colfer: non-canonical encoding at byte ' + serial.length);
+		return n;
+	}
+
+	// Deserializes the object from an Uint8Array, and returns the canonical serial of the result, as marshal produces, in a new Uint8Array.
+	// Data after the serial is rejected.
+	this.DromedaryCase.prototype.canonicalize = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		return this.marshal().slice();
+	}
+
 	// Constructor.
 	// EmbedO has an inner object only.
 	// Covers regression of issue #66.
@@ -663,6 +741,9 @@ var gen = new function() {
 		for (var p in init) this[p] = init[p];
 	}
 
+	// The schema digest, which changes with any change to the fields, including the data structures referred to.
+	this.EmbedO.colferFingerprint = '1ea394cc8643da1b';
+
 	// Serializes the object into an Uint8Array.
 	this.EmbedO.prototype.marshal = function(buf) {
 		if (! buf || !buf.length) buf = new Uint8Array(colferSizeMax);
@@ -685,7 +766,11 @@ var gen = new function() {
 	}
 
 	// Deserializes the object from an Uint8Array and returns the number of bytes read.
-	this.EmbedO.prototype.unmarshal = function(data) {
+	// The optional depth is the nesting level of the object, with 1 for the top-level.
+	this.EmbedO.prototype.unmarshal = function(data, depth) {
+		if (!depth) depth = 1;
+		if (depth > colferDepthMax)
+			throw new RangeError('colfer: depth limit: struct gen.EmbedO exceeds ' + colferDepthMax + ' levels');
 		if (!data || ! data.length) throw new Error(EOF);
 		var header = data[0];
 		var i = 1;
@@ -714,7 +799,7 @@ var gen = new function() {
 
 		if (header == 0) {
 			var o = new gen.O();
-			i += o.unmarshal(data.subarray(i));
+			i += o.unmarshal(data.subarray(i), depth + 1);
 			this.inner = o;
 			readHeader();
 		}
@@ -725,6 +810,32 @@ var gen = new function() {
 		return i;
 	}
 
+	// Deserializes the object like unmarshal, yet it rejects any serial other than the one marshal produces for the result.
+	// Equal values have identical serials as such. Data after the serial is rejected too.
+	// The check serializes the result for comparison, which costs about as much as the deserialization.
+	this.EmbedO.prototype.unmarshalCanonical = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		var serial = this.marshal();
+		for (var i = 0; i < serial.length; i++) {
+			if (i >= n || serial[i] != data[i])
+				throw new Error('colfer: non-canonical encoding at byte ' + i);
+		}
+		if (serial.length != n)
+			throw new Error('colfer: non-canonical encoding at byte ' + serial.length);
+		return n;
+	}
+
+	// Deserializes the object from an Uint8Array, and returns the canonical serial of the result, as marshal produces, in a new Uint8Array.
+	// Data after the serial is rejected.
+	this.EmbedO.prototype.canonicalize = function(data) {
+		var n = this.unmarshal(data);
+		if (n != data.length)
+			throw new Error('colfer: data continuation at byte ' + n);
+		return this.marshal().slice();
+	}
+
 	// private section
 
 	var encodeVarint = function(bytes, i, x) {
@@ -764,16 +875,15 @@ var gen = new function() {
 			if (c < 2048) {
 				bytes[i++] = c >> 6 | 192;
 			} else {
+				if (c > 0xdbff && c < 0xe000)
+					throw new Error(MALFORMED_UTF8);
 				if (c > 0xd7ff && c < 0xdc00) {
 					if (++ci >= s.length) {
-						bytes[i++] = 63;
-						continue;
+						throw new Error(MALFORMED_UTF8);
 					}
 					var c2 = s.charCodeAt(ci);
 					if (c2 < 0xdc00 || c2 > 0xdfff) {
-						bytes[i++] = 63;
-						--ci;
-						continue;
+						throw new Error(MALFORMED_UTF8);
 					}
 					c = 0x10000 + ((c & 0x03ff) << 10) + (c2 & 0x03ff);
 					bytes[i++] = c >> 18 | 240;
@@ -791,13 +901,24 @@ var gen = new function() {
 		while (i < bytes.length) {
 			var c = bytes[i++];
 			if (c > 127) {
-				if (c > 191 && c < 224) {
-					c = (i >= bytes.length) ? 63 : (c & 31) << 6 | bytes[i++] & 63;
+				// RFC 3629
+				var n, min;
+				if (c > 193 && c < 224) {
+					n = 1, min = 0x80, c &= 31;
 				} else if (c > 223 && c < 240) {
-					c = (i + 1 >= bytes.length) ? 63 : (c & 15) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
-				} else if (c > 239 && c < 248) {
-					c = (i + 2 >= bytes.length) ? 63 : (c & 7) << 18 | (bytes[i++] & 63) << 12 | (bytes[i++] & 63) << 6 | bytes[i++] & 63;
-				} else c = 63
+					n = 2, min = 0x800, c &= 15;
+				} else if (c > 239 && c < 245) {
+					n = 3, min = 0x10000, c &= 7;
+				} else throw new Error(MALFORMED_UTF8);
+
+				if (i + n > bytes.length) throw new Error(MALFORMED_UTF8);
+				for (; n != 0; --n) {
+					var b = bytes[i++];
+					if ((b & 192) != 128) throw new Error(MALFORMED_UTF8);
+					c = c << 6 | b & 63;
+				}
+				if (c < min || c > 0x10ffff || (c > 0xd7ff && c < 0xe000))
+					throw new Error(MALFORMED_UTF8);
 			}
 
 			if (c <= 0xffff) s += String.fromCharCode(c);
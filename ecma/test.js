@@ -100,6 +100,101 @@ QUnit.test('unmarshal', function(assert) {
 	}
 });
 
+QUnit.test('malformed text', function(assert) {
+	['\ud800', '\udc00', 'a\ud800b', '\udc00\ud800'].forEach(function(s) {
+		var desc = JSON.stringify(s);
+		assert.throws(function() {
+			new gen.O({s: s}).marshal();
+		}, /colfer: malformed UTF-8/, 'marshal ' + desc);
+		assert.throws(function() {
+			new gen.O({ss: ['ok', s]}).marshal();
+		}, /colfer: malformed UTF-8/, 'marshal list ' + desc);
+	});
+
+	['0801ff7f', '0802c0807f', '0803eda0807f', '080361e0807f', '0804f49080807f', '0c020001ff7f', '0a0801ff7f7f'].forEach(function(hex) {
+		assert.throws(function() {
+			new gen.O().unmarshal(decodeHex(hex));
+		}, /colfer: malformed UTF-8/, 'unmarshal ' + hex);
+	});
+});
+
+QUnit.test('nesting depth', function(assert) {
+	// default maximum of 100 levels
+	var ok = '0a'.repeat(99) + '7f'.repeat(100);
+	assert.equal(new gen.O().unmarshal(decodeHex(ok)), 199, 'unmarshal 100 levels');
+	var okList = '0b01'.repeat(99) + '7f'.repeat(100);
+	assert.equal(new gen.O().unmarshal(decodeHex(okList)), 298, 'unmarshal 100 levels in lists');
+
+	['0a'.repeat(100) + '7f'.repeat(101), '0b01'.repeat(100) + '7f'.repeat(101)].forEach(function(hex) {
+		assert.throws(function() {
+			new gen.O().unmarshal(decodeHex(hex));
+		}, /colfer: depth limit: struct gen.o exceeds 100 levels/, 'unmarshal 101 levels');
+
+		// distinct from the other errors
+		try {
+			new gen.O().unmarshal(decodeHex(hex));
+		} catch (err) {
+			assert.equal(err instanceof RangeError, true, 'RangeError on 101 levels');
+		}
+	});
+});
+
+QUnit.test('budget', function(assert) {
+	// sizes and element counts with little data to back them
+	['09808080047f', '0d01808080047f', '0a09808080047f7f', '0b8080047f', '0a0b8080047f7f', '0b020b8080047f7f7f', '0c8080047f', '0d8080047f', '108080047f', '118080047f'].forEach(function(hex) {
+		assert.throws(function() {
+			new gen.O().unmarshal(decodeHex(hex));
+		}, /colfer: EOF/, 'unmarshal ' + hex);
+	});
+});
+
+QUnit.test('fingerprint', function(assert) {
+	// same in all languages, as in testdata
+	assert.equal(gen.O.colferFingerprint, '98768e7cc2fcda72');
+});
+
+QUnit.test('canonical', function(assert) {
+	var golden = newGoldenCases();
+	for (hex in golden) {
+		try {
+			var data = decodeHex(hex);
+			assert.equal(new gen.O().unmarshalCanonical(data), data.length, hex);
+		} catch (err) {
+			assert.equal(err, 'no error', hex);
+		}
+	}
+
+	[
+		// uint32 in fixed width
+		['81000000017f', '01017f', 0],
+		// uint32 in overlong varint
+		['0181007f', '01017f', 1],
+		// uint16 in 2 bytes
+		['0f00ff7f', '8fff7f', 0],
+		// int32 negative zero
+		['83007f', '7f', 0],
+		// float32 NaN with payload
+		['057fc000017f', '057fc000007f', 4],
+		// timestamp at Unix epoch
+		['0700000000000000007f', '7f', 0],
+		// empty list
+		['0b007f', '7f', 0],
+	].forEach(function(c) {
+		assert.throws(function() {
+			new gen.O().unmarshalCanonical(decodeHex(c[0]));
+		}, new RegExp('colfer: non-canonical encoding at byte ' + c[2] + '$'), 'unmarshal ' + c[0]);
+		assert.equal(encodeHex(new gen.O().canonicalize(decodeHex(c[0]))), c[1], 'canonicalize ' + c[0]);
+	});
+
+	// data after the serial, same in all languages
+	assert.throws(function() {
+		new gen.O().unmarshalCanonical(decodeHex('01017f00'));
+	}, /colfer: data continuation at byte 3$/, 'unmarshal with tail');
+	assert.throws(function() {
+		new gen.O().canonicalize(decodeHex('01017f00'));
+	}, /colfer: data continuation at byte 3$/, 'canonicalize with tail');
+});
+
 function encodeHex(bytes) {
 	var s = '';
 	if (!bytes) return s;
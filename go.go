@@ -3,6 +3,7 @@ package colfer
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -39,14 +40,73 @@ func goMod(dir string) (modDir, modPkg string, err error) {
 	return dir, modPkg, nil
 }
 
+// GoField is the template data for field code with options.
+type goField struct {
+	*Field
+	// Reuse enables recycling of slices and nested structs.
+	Reuse bool
+}
+
+// GoSchemaName returns the qualified name without any package prefix.
+func goSchemaName(t *Struct) string {
+	return t.Pkg.Name[strings.LastIndexByte(t.Pkg.Name, '/')+1:] + "." + t.Name
+}
+
+// GoTypeID returns the 32-bit FNV-1a hash of the qualified name, without any
+// package prefix, such that the identifier does not depend on the -p option.
+func goTypeID(t *Struct) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(goSchemaName(t)))
+	return h.Sum32()
+}
+
 // GenerateGo writes the code into file "Colfer.go".
 func GenerateGo(basedir string, packages Packages) error {
-	t := template.New("go-code")
+	// foreign has the structs which are referred to from another package
+	foreign := make(map[*Struct]bool)
+	for _, p := range packages {
+		for _, t := range p.Structs {
+			for _, f := range t.Fields {
+				if f.TypeRef != nil && f.TypeRef.Pkg != p {
+					foreign[f.TypeRef] = true
+				}
+			}
+		}
+	}
+
+	t := template.New("go-code").Funcs(template.FuncMap{
+		"fresh":      func(f *Field) goField { return goField{Field: f} },
+		"reuse":      func(f *Field) goField { return goField{Field: f, Reuse: true} },
+		"typeID":     goTypeID,
+		"schemaName": goSchemaName,
+		"foreign":    func(t *Struct) bool { return foreign[t] },
+	})
 	template.Must(t.Parse(goCode))
 	template.Must(t.New("marshal-field").Parse(goMarshalField))
 	template.Must(t.New("marshal-field-len").Parse(goMarshalFieldLen))
 	template.Must(t.New("unmarshal-field").Parse(goUnmarshalField))
 	template.Must(t.New("unmarshal-varint").Parse(goUnmarshalVarint))
+	template.Must(t.New("equal-field").Parse(goEqualField))
+	template.Must(t.New("clone-field").Parse(goCloneField))
+	template.Must(t.New("string-field").Parse(goStringField))
+	template.Must(t.New("json-code").Parse(goJSONCode))
+	template.Must(t.New("marshal-json-field").Parse(goMarshalJSONField))
+	template.Must(t.New("unmarshal-json-field").Parse(goUnmarshalJSONField))
+	template.Must(t.New("fuzz-code").Parse(goFuzzCode))
+	template.Must(t.New("fuzz-sample-field").Parse(goFuzzSampleField))
+	template.Must(t.New("quick-code").Parse(goQuickCode))
+	template.Must(t.New("quick-value").Parse(goQuickValue))
+	template.Must(t.New("mask-code").Parse(goMaskCode))
+	template.Must(t.New("skip-field").Parse(goSkipField))
+	template.Must(t.New("skip-ref").Parse(goSkipRef))
+	template.Must(t.New("unmarshal-len").Parse(goUnmarshalLen))
+	template.Must(t.New("lazy-code").Parse(goLazyCode))
+	template.Must(t.New("each-code").Parse(goEachCode))
+	template.Must(t.New("sql-code").Parse(goSQLCode))
+	template.Must(t.New("registry-code").Parse(goRegistryCode))
+	template.Must(t.New("registry-methods").Parse(goRegistryMethods))
+	template.Must(t.New("descriptor-code").Parse(goDescriptorCode))
+	template.Must(t.New("descriptor-methods").Parse(goDescriptorMethods))
 
 	modDir, modPkg, err := goMod(basedir)
 	if err != nil {
@@ -64,7 +124,25 @@ func GenerateGo(basedir string, packages Packages) error {
 		}
 	}
 
+	typeIDs := make(map[uint32]*Struct)
+	for _, p := range packages {
+		if !p.Registry {
+			continue
+		}
+		for _, t := range p.Structs {
+			id := goTypeID(t)
+			if other, ok := typeIDs[id]; ok {
+				return fmt.Errorf("colfer: struct %s and %s have the same type identifier %#08x", other, t, id)
+			}
+			typeIDs[id] = t
+		}
+	}
+
 	for _, p := range packages {
+		if p.Lazy && p.Canonical {
+			return fmt.Errorf("colfer: package %s: lazy decoding conflicts with canonical encoding", p.Name)
+		}
+
 		for _, t := range p.Structs {
 			for _, f := range t.Fields {
 				switch f.Type {
@@ -108,10 +186,36 @@ func GenerateGo(basedir string, packages Packages) error {
 		if _, err := FormatFile(path); err != nil {
 			return err
 		}
+
+		if p.Fuzz {
+			err := goTestFile(t, "fuzz-code", p, filepath.Join(filepath.Dir(path), "Colfer_fuzz_test.go"))
+			if err != nil {
+				return err
+			}
+		}
+		if p.Quick {
+			err := goTestFile(t, "quick-code", p, filepath.Join(filepath.Dir(path), "Colfer_quick_test.go"))
+			if err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// GoTestFile writes the output of a test template into a file at path.
+func goTestFile(t *template.Template, name string, p *Package, path string) error {
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, name, p); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	_, err := FormatFile(path)
+	return err
+}
+
 const goCode = `{{.DocText "// "}}
 package {{.NameNative}}
 
@@ -119,15 +223,34 @@ package {{.NameNative}}
 // The compiler used schema file {{.SchemaFileList}}.
 
 import (
+{{- if or .HasBinary .KeepUnknown}}
+	"bytes"
+{{- end}}
+{{- if .SQL}}
+	"database/sql/driver"
+{{- end}}
+{{- if .Registry}}
+	"encoding"
+{{- end}}
+{{- if and .JSON .HasBinary}}
+	"encoding/base64"
+{{- end}}
 	"encoding/binary"
 	"fmt"
 	"io"
 {{- if .HasFloat}}
 	"math"
 {{- end}}
+{{- if or .HasText .JSON}}
+	"strconv"
+{{- end}}
+	"strings"
 {{- if .HasTimestamp}}
 	"time"
 {{- end}}
+{{- if or (and .StrictText .HasText) .JSON}}
+	"unicode/utf8"
+{{- end}}
 {{- range .Refs}}
 	"{{.Name}}"
 {{- end}}
@@ -142,6 +265,19 @@ var (
 {{- if .HasList}}
 	// ColferListMax is the upper limit for the number of elements in a list.
 	ColferListMax = {{.ListMax}}
+{{- end}}
+	// ColferDepthMax is the upper limit for the nesting level of data
+	// structures, with 1 for the top-level. Data structures from another
+	// package count from 1 again, against the limit of their own package.
+	ColferDepthMax = {{.DepthMax}}
+)
+
+// Schema fingerprints change with any change to the fields of the respective
+// data structure, including the data structures referred to.
+const (
+{{- range .Structs}}
+	// ColferFingerprint{{.NameNative}} is the schema digest of {{.NameNative}}.
+	ColferFingerprint{{.NameNative}} = "{{.Fingerprint}}"
 {{- end}}
 )
 
@@ -151,6 +287,12 @@ type ColferMax string
 // Error honors the error interface.
 func (m ColferMax) Error() string { return string(m) }
 
+// ColferDepth signals an upper limit breach of the nesting level.
+type ColferDepth string
+
+// Error honors the error interface.
+func (m ColferDepth) Error() string { return string(m) }
+
 // ColferError signals a data mismatch as as a byte index.
 type ColferError int
 
@@ -166,11 +308,179 @@ type ColferTail int
 func (i ColferTail) Error() string {
 	return fmt.Sprintf("colfer: data continuation at byte %d", i)
 }
+{{- if .KeepUnknown}}
+
+// ColferUnknownValid returns whether data[start:end] walks as fields in
+// ascending index order, with data[end] as their terminator. The header of
+// unknown fields has no type information. The walk thus accepts any datatype
+// which fits the payload. Nested data structures are checked on their
+// terminator only. List elements beyond a work limit pass unchecked.
+func colferUnknownValid(data []byte, start, end int) bool {
+	// valid has the field index plus one per header position with a valid
+	// continuation, or zero for none. The terminator exceeds any index.
+	valid := make([]byte, end-start+1)
+	valid[end-start] = 128
+	// nested has the maximum of valid past each terminator from a position
+	// onwards, excluding the one at end, for data structure payloads.
+	nested := make([]byte, end-start+1)
+	// limit the work on list elements to linear time
+	budget := 8 * (end - start)
+
+	// fits returns whether a field with index k can continue at i.
+	fits := func(i int, k byte) bool {
+		return i <= end && valid[i-start] > k+1
+	}
+	// varint returns the value at i, with the position after it.
+	varint := func(i int) (x uint64, next int, ok bool) {
+		for shift := uint(0); shift < 64; shift += 7 {
+			if i >= end {
+				return 0, i, false
+			}
+			c := data[i]
+			i++
+			x |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return x, i, true
+			}
+		}
+		return 0, i, false
+	}
+
+	for p := end - 1; p >= start; p-- {
+		nested[p-start] = nested[p+1-start]
+		if data[p] == 0x7f && valid[p+1-start] > nested[p-start] {
+			nested[p-start] = valid[p+1-start]
+		}
+
+		header := data[p]
+		k := header & 0x7f
+		if k == 0x7f {
+			continue // terminator or invalid
+		}
+		i := p + 1
+		var ok bool
+		if header&0x80 != 0 {
+			// fixed size integers, timestamp, or a negative integer
+			ok = fits(i+1, k) || fits(i+4, k) || fits(i+8, k) || fits(i+12, k)
+			if !ok {
+				if _, next, isVarint := varint(i); isVarint {
+					ok = fits(next, k)
+				}
+			}
+		} else {
+			// boolean, fixed size numbers, timestamp, or a data structure
+			ok = fits(i, k) || fits(i+1, k) || fits(i+2, k) || fits(i+4, k) || fits(i+8, k) || nested[i-start] > k+1
+			if x, c, isVarint := varint(i); !ok && isVarint {
+				// integer, or a size or list length
+				ok = fits(c, k)
+				if !ok && x <= uint64(end-c) {
+					n := int(x)
+					// text, binary, or a list of floating points
+					ok = fits(c+n, k) || fits(c+4*n, k) || fits(c+8*n, k)
+					// list of data structures
+					ok = ok || (n != 0 && nested[c+n-1-start] > k+1)
+					if !ok && n > budget {
+						ok = true
+					}
+					if !ok {
+						budget -= n
+						// list of integers
+						e := c
+						for j := 0; j < n; j++ {
+							_, next, isVarint := varint(e)
+							if !isVarint {
+								e = end + 1
+								break
+							}
+							e = next
+						}
+						ok = fits(e, k)
+						// list of texts or binaries
+						e = c
+						for j := 0; j < n; j++ {
+							size, next, isVarint := varint(e)
+							if !isVarint || size > uint64(end-next) {
+								e = end + 1
+								break
+							}
+							e = next + int(size)
+						}
+						ok = ok || fits(e, k)
+					}
+				}
+			}
+		}
+		if ok {
+			valid[p-start] = k + 1
+		}
+	}
+	return valid[0] != 0
+}
+{{- end}}
+{{- if .StrictText}}
+
+// ColferUTF8 signals malformed UTF-8 in text.
+type ColferUTF8 string
+
+// Error honors the error interface.
+func (m ColferUTF8) Error() string { return string(m) }
+{{- end}}
+{{- if .Canonical}}
+
+// ColferCanonical signals a non-canonical encoding as a byte index.
+type ColferCanonical int
+
+// Error honors the error interface.
+func (i ColferCanonical) Error() string {
+	return fmt.Sprintf("colfer: non-canonical encoding at byte %d", i)
+}
+{{- end}}
+{{- if .FieldMask}}
+
+// ColferMask is a set of field indices, i.e., the position in the schema, for
+// selective decoding with UnmarshalFields.
+type ColferMask [2]uint64
+
+// Has returns whether the field index is in m.
+func (m ColferMask) Has(index int) bool {
+	return uint(index) < 128 && m[index>>6]&(1<<uint(index&63)) != 0
+}
+
+// With returns m with the field indices added. Any index outside the Colfer
+// range of 0 to 126 panics.
+func (m ColferMask) With(indices ...int) ColferMask {
+	for _, index := range indices {
+		if index < 0 || index > 126 {
+			panic(fmt.Sprintf("colfer: field index %d out of range", index))
+		}
+		m[index>>6] |= 1 << uint(index&63)
+	}
+	return m
+}
+{{- end}}
+{{- if .Registry}}
+{{template "registry-code" .}}
+{{- end}}
+{{- if .Descriptor}}
+{{template "descriptor-code" .}}
+{{- end}}
 {{range .Structs}}
 {{.DocText "// "}}
 type {{.NameNative}} struct {
 {{range .Fields}}{{.DocText "\t// "}}
 	{{.NameNative}}	{{if .TypeList}}[]{{end}}{{if .TypeRef}}*{{end}}{{.TypeNative}}{{range .TagAdd}} {{.}}{{end}}
+{{end}}
+{{- if .Pkg.Lazy}}{{range .Fields}}{{if .TypeRef}}
+	// lazy{{.NameNative}} has the serial of {{.NameNative}}, pending decoding.
+	lazy{{.NameNative}} []byte
+{{end}}{{end}}
+{{- if .HasRef}}
+	// lazyDepth is the nesting level of o, for decoding of pending serials.
+	lazyDepth int
+{{end}}{{end}}
+{{- if .Pkg.KeepUnknown}}
+	// unknown has the serial of trailing fields not in the schema.
+	unknown []byte
 {{end}}}
 
 // MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
@@ -181,16 +491,22 @@ type {{.NameNative}} struct {
 func (o *{{.NameNative}}) MarshalTo(buf []byte) int {
 	var i int
 {{range .Fields}}{{template "marshal-field" .}}{{end}}
+{{- if .Pkg.KeepUnknown}}
+	i += copy(buf[i:], o.unknown)
+{{- end}}
 	buf[i] = 0x7f
 	i++
 	return i
 }
 
 // MarshalLen returns the Colfer serial byte size.
-// The error return option is ColferMax.
+// The error return option is ColferMax{{if .Pkg.StrictText}} or ColferUTF8{{end}}.
 func (o *{{.NameNative}}) MarshalLen() (int, error) {
 	l := 1
 {{range .Fields}}{{template "marshal-field-len" .}}{{end}}
+{{- if .Pkg.KeepUnknown}}
+	l += len(o.unknown)
+{{- end}}
 	if l > ColferSizeMax {
 		return l, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", ColferSizeMax))
 	}
@@ -201,7 +517,7 @@ func (o *{{.NameNative}}) MarshalLen() (int, error) {
 {{- range .Fields}}{{if and .TypeList .TypeRef}}
 // All nil entries in o.{{.NameNative}} will be replaced with a new value.
 {{- end}}{{end}}
-// The error return option is ColferMax.
+// The error return option is ColferMax{{if .Pkg.StrictText}} or ColferUTF8{{end}}.
 func (o *{{.NameNative}}) MarshalBinary() (data []byte, err error) {
 	l, err := o.MarshalLen()
 	if err != nil {
@@ -212,15 +528,131 @@ func (o *{{.NameNative}}) MarshalBinary() (data []byte, err error) {
 	return data, nil
 }
 
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+{{- range .Fields}}{{if and .TypeList .TypeRef}}
+// All nil entries in o.{{.NameNative}} will be replaced with a new value.
+{{- end}}{{end}}
+// The error return option is ColferMax{{if .Pkg.StrictText}} or ColferUTF8{{end}}, in which case dst is returned as is.
+func (o *{{.NameNative}}) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
-// The error return options are io.EOF, ColferError and ColferMax.
+{{- if and .Pkg.Lazy .HasRef}}
+// Data structures are validated, yet they remain serial until their Load
+// method decodes them. Such serials refer to data, so data must not change
+// afterwards.
+{{- end}}
+// The error return options are io.EOF, ColferError{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
 func (o *{{.NameNative}}) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data{{if .Pkg.KeepUnknown}}, false{{end}}, 1)
+}
+
+{{- if foreign .}}
+
+// UnmarshalDepth decodes data as Colfer like Unmarshal, with depth as the
+// nesting level of o. Generated code from other packages uses it for nested
+// data structures, such that the ColferDepthMax limit holds.
+func (o *{{.NameNative}}) UnmarshalDepth(data []byte, depth int) (int, error) {
+	return o.unmarshal(data{{if .Pkg.KeepUnknown}}, false{{end}}, depth)
+}
+{{- end}}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+{{- if .Pkg.KeepUnknown}}
+// When keep is set, then data is the entire serial of o, and any fields beyond
+// the schema's (a forward-compatible extension) are retained, as is, for the
+// next marshal. Such unknown fields are walked on their headers, yet nested
+// data structures are validated on their terminator only.
+{{- end}}
+// The depth is the nesting level of o.
+func (o *{{.NameNative}}) unmarshal(data []byte{{if .Pkg.KeepUnknown}}, keep bool{{end}}, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct {{.String}} exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+{{- if .Pkg.KeepUnknown}}
+	o.unknown = o.unknown[:0]
+{{- end}}
+	header := data[0]
+	i := 1
+{{range .Fields}}{{template "unmarshal-field" (fresh .)}}{{end}}
+	if header != 0x7f {
+{{- if .Pkg.KeepUnknown}}
+		if keep && header&0x7f >= {{len .Fields}} && data[len(data)-1] == 0x7f {
+			if len(data) > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", ColferSizeMax))
+			}
+			if !colferUnknownValid(data, i-1, len(data)-1) {
+				return 0, ColferError(i - 1)
+			}
+			o.unknown = append(o.unknown[:0], data[i-1:len(data)-1]...)
+			return len(data), nil
+		}
+{{- end}}
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *{{.NameNative}}) Reset() {
+	*o = {{.NameNative}}{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
+func (o *{{.NameNative}}) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+{{- if foreign .}}
+
+// UnmarshalReuseDepth decodes data as Colfer like UnmarshalReuse, with depth as
+// the nesting level of o. Generated code from other packages uses it for nested
+// data structures, such that the ColferDepthMax limit holds.
+func (o *{{.NameNative}}) UnmarshalReuseDepth(data []byte, depth int) (int, error) {
+	return o.unmarshalReuse(data, depth)
+}
+{{- end}}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *{{.NameNative}}) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct {{.String}} exceeds %d levels", ColferDepthMax))
+	}
+{{- $reuse := false}}{{range .Fields}}{{if or (and .TypeRef (not $.Lazy)) (and .TypeList (not .TypeRef)) (eq .Type "binary")}}{{$reuse = true}}{{end}}{{end}}
+{{- if $reuse}}
+	prev := *o
+{{- end}}
+	*o = {{.NameNative}}{}
+
 	if len(data) == 0 {
 		return 0, io.EOF
 	}
 	header := data[0]
 	i := 1
-{{range .Fields}}{{template "unmarshal-field" .}}{{end}}
+{{range .Fields}}{{template "unmarshal-field" (reuse .)}}{{end}}
 	if header != 0x7f {
 		return 0, ColferError(i - 1)
 	}
@@ -235,104 +667,454 @@ eof:
 }
 
 // UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
-// The error return options are io.EOF, ColferError, ColferTail and ColferMax.
+{{- if .Pkg.KeepUnknown}}
+// Fields with an index beyond the schema are retained for the next marshal,
+// provided that they trail the known ones. The type of such unknown fields
+// can not be derived from their header. Their bytes are therefore retained
+// as is, provided that some choice of datatypes walks the headers in
+// ascending index order up to the terminator. Nested data structures and list
+// elements do not support unknown fields for the same reason, as their end
+// can not be found.
+{{- end}}
+// The error return options are io.EOF, ColferError, ColferTail{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
 func (o *{{.NameNative}}) UnmarshalBinary(data []byte) error {
+{{- if .Pkg.KeepUnknown}}
+	i, err := o.unmarshal(data, true, 1)
+{{- else}}
 	i, err := o.Unmarshal(data)
+{{- end}}
 	if i < len(data) && err == nil {
 		return ColferTail(i)
 	}
 	return err
 }
-{{end}}`
-
-const goMarshalField = `{{if eq .Type "bool"}}
-	if o.{{.NameNative}} {
-		buf[i] = {{.Index}}
-		i++
-	}
-{{else if eq .Type "uint8"}}
-	if x := o.{{.NameNative}}; x != 0 {
-		buf[i] = {{.Index}}
-		i++
-		buf[i] = x
-		i++
+{{- if .Pkg.Canonical}}
+
+// UnmarshalCanonical decodes data as Colfer like UnmarshalBinary, yet it
+// rejects any serial other than the one MarshalBinary produces for the result.
+// Equal values have identical serials as such. The check marshals the result
+// for comparison, which costs about as much as the decoding.
+// The error return options are io.EOF, ColferError, ColferTail{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferCanonical, ColferDepth and ColferMax.
+func (o *{{.NameNative}}) UnmarshalCanonical(data []byte) error {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return err
 	}
-{{else if eq .Type "uint16"}}
-	if x := o.{{.NameNative}}; x >= 1<<8 {
-		buf[i] = {{.Index}}
-		i++
-		buf[i] = byte(x >> 8)
-		i++
-		buf[i] = byte(x)
-		i++
-	} else if x != 0 {
-		buf[i] = {{.Index}} | 0x80
-		i++
-		buf[i] = byte(x)
-		i++
+	serial, err := o.MarshalBinary()
+	if err != nil {
+		return err
 	}
-{{else if eq .Type "uint32"}}
-	if x := o.{{.NameNative}}; x >= 1<<21 {
-		buf[i] = {{.Index}} | 0x80
-		intconv.PutUint32(buf[i+1:], x)
-		i += 5
-	} else if x != 0 {
-		buf[i] = {{.Index}}
-		i++
-		for x >= 0x80 {
-			buf[i] = byte(x | 0x80)
-			x >>= 7
-			i++
+	for i := range serial {
+		if i >= len(data) || serial[i] != data[i] {
+			return ColferCanonical(i)
 		}
-		buf[i] = byte(x)
-		i++
 	}
-{{else if eq .Type "uint64"}}
-	if x := o.{{.NameNative}}; x >= 1<<49 {
-		buf[i] = {{.Index}} | 0x80
-		intconv.PutUint64(buf[i+1:], x)
-		i += 9
-	} else if x != 0 {
-		buf[i] = {{.Index}}
-		i++
-		for x >= 0x80 {
-			buf[i] = byte(x | 0x80)
-			x >>= 7
-			i++
-		}
-		buf[i] = byte(x)
-		i++
+	if len(serial) != len(data) {
+		return ColferCanonical(len(serial))
 	}
-{{else if eq .Type "int32"}}
-{{- if .TypeList}}
-	if l := len(o.{{.NameNative}}); l != 0 {
-		buf[i] = {{.Index}}
-		i++
-		x := uint(l)
-		for x >= 0x80 {
-			buf[i] = byte(x | 0x80)
-			x >>= 7
-			i++
-		}
-		buf[i] = byte(x)
-		i++
-		for _, v := range o.{{.NameNative}} {
-			x1 := uint32(v << 1) ^ uint32(v >> 31)
-			for ; x1 >= 0x80; {
-				buf[i] = byte(x1 | 0x80)
-				x1 >>= 7
-				i++
-			}
-			buf[i] = byte(x1)
-			i++
+	return nil
+}
+
+// Canonicalize decodes data as Colfer like UnmarshalBinary, and it returns the
+// canonical serial of the result, which is what MarshalBinary produces.
+// The error return options are io.EOF, ColferError, ColferTail{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
+func (o *{{.NameNative}}) Canonicalize(data []byte) ([]byte, error) {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return o.MarshalBinary()
+}
+{{- end}}
+{{- if .Pkg.SQL}}
+{{template "sql-code" .}}
+{{- end}}
+{{- if .Pkg.Registry}}
+{{template "registry-methods" .}}
+{{- end}}
+{{- if .Pkg.Descriptor}}
+{{template "descriptor-methods" .}}
+{{- end}}
+{{- if .Pkg.FieldMask}}
+{{template "mask-code" .}}
+{{- end}}
+{{- if or .Pkg.Lazy .Pkg.Iterate}}
+{{template "unmarshal-len" .}}
+{{- end}}
+{{- if .Pkg.Lazy}}
+{{template "lazy-code" .}}
+{{- end}}
+{{- if .Pkg.Iterate}}
+{{template "each-code" .}}
+{{- end}}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *{{.NameNative}}) Equal(other *{{.NameNative}}) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+{{- if and .Pkg.Lazy .HasRef}}
+	a, err := o.loaded()
+	if err == nil {
+		var b *{{.NameNative}}
+		if b, err = other.loaded(); err == nil {
+			o, other = a, b
 		}
 	}
-{{- else}}
-	if v := o.{{.NameNative}}; v != 0 {
-		x := uint32(v)
-		if v >= 0 {
-			buf[i] = {{.Index}}
-		} else {
+	if err != nil {
+		// compare the pending serials as is
+		x, errX := o.MarshalBinary()
+		y, errY := other.MarshalBinary()
+		return errX == nil && errY == nil && string(x) == string(y)
+	}
+{{- end}}
+{{range .Fields}}{{template "equal-field" .}}{{end}}
+{{- if .Pkg.KeepUnknown}}
+	if !bytes.Equal(o.unknown, other.unknown) {
+		return false
+	}
+{{- end}}
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *{{.NameNative}}) Clone() *{{.NameNative}} {
+	if o == nil {
+		return nil
+	}
+	c := *o
+{{range .Fields}}{{template "clone-field" .}}{{end}}
+{{- if .Pkg.KeepUnknown}}
+	if o.unknown != nil {
+		c.unknown = append([]byte(nil), o.unknown...)
+	}
+{{- end}}
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *{{.NameNative}}) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+{{- if and .Pkg.Lazy .HasRef}}
+	o, err := o.loaded()
+	if err != nil {
+		return "{{.Pkg.NameNative}}.{{.Name}}{" + err.Error() + "}"
+	}
+{{- end}}
+	var fields []string
+{{range .Fields}}{{template "string-field" .}}{{end}}
+	return "{{.Pkg.NameNative}}.{{.Name}}{" + strings.Join(fields, ", ") + "}"
+}
+{{- if .Pkg.JSON}}
+
+// MarshalJSON encodes o as a JSON object conform json.Marshaler. The members
+// are in order of the schema, with the field names from the schema.
+// Timestamps are RFC 3339 strings, except for years beyond the 0–9999 range,
+// which are decimal numbers of seconds since the Unix epoch instead. Binaries
+// are base64 strings (standard encoding). Not-a-number and infinite floating
+// points are the strings "NaN", "Infinity" and "-Infinity". Zero timestamps
+// and absent structs are null.
+// The error return option is ColferMax{{if .Pkg.StrictText}} or ColferUTF8{{end}}, conform MarshalLen.
+{{- if .Pkg.Lazy}}
+// Pending serials which fail to decode return the error of their Load method.
+{{- end}}
+func (o *{{.NameNative}}) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+	if _, err := o.MarshalLen(); err != nil {
+		return nil, err
+	}
+	return o.appendJSON(nil){{if not .Pkg.Lazy}}, nil{{end}}
+}
+
+// AppendJSON encodes o, which must pass MarshalLen, as JSON to the end of buf.
+func (o *{{.NameNative}}) appendJSON(buf []byte) {{if .Pkg.Lazy}}([]byte, error){{else}}[]byte{{end}} {
+	if o == nil {
+		return append(buf, "null"...){{if .Pkg.Lazy}}, nil{{end}}
+	}
+{{- if and .Pkg.Lazy .HasRef}}
+	o, err := o.loaded()
+	if err != nil {
+		return nil, err
+	}
+{{- end}}
+	buf = append(buf, '{')
+{{range .Fields}}{{template "marshal-json-field" .}}{{end}}
+	return append(buf, '}'){{if .Pkg.Lazy}}, nil{{end}}
+}
+
+// UnmarshalJSON decodes data conform json.Unmarshaler, with the format of
+// MarshalJSON. Absent members and null values leave the respective fields as
+// is. Numbers must fit their type without loss.
+// The error return options include ColferDepth and ColferMax.
+func (o *{{.NameNative}}) UnmarshalJSON(data []byte) error {
+	r := colferJSON{data: data}
+	if err := o.unmarshalJSON(&r); err != nil {
+		return err
+	}
+	if r.peek() != 0 {
+		return fmt.Errorf("colfer: {{.String}}: JSON continuation at byte %d", r.i)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the JSON object from r.
+func (o *{{.NameNative}}) unmarshalJSON(r *colferJSON) error {
+	if r.peek() != '{' {
+		return fmt.Errorf("colfer: {{.String}}: %w", r.typeError("object"))
+	}
+	r.depth++
+	if r.depth > ColferDepthMax {
+		return ColferDepth(fmt.Sprintf("colfer: depth limit: struct {{.String}} exceeds %d levels", ColferDepthMax))
+	}
+	r.i++
+	for first := true; ; first = false {
+		more, err := r.next('}', first)
+		if err != nil {
+			return fmt.Errorf("colfer: {{.String}}: %w", err)
+		}
+		if !more {
+			r.depth--
+			return nil
+		}
+		name, err := r.text()
+		if err != nil {
+			return fmt.Errorf("colfer: {{.String}}: %w", err)
+		}
+		if !r.consume(":") {
+			return fmt.Errorf("colfer: {{.String}}: %w", r.syntaxError())
+		}
+
+		switch name {
+{{range .Fields}}{{template "unmarshal-json-field" .}}{{end}}
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in {{.String}}", name)
+		}
+	}
+}
+{{- end}}
+{{end}}
+{{- if .JSON}}{{template "json-code" .}}{{end}}`
+
+const goEqualField = `{{if .TypeList}}
+	if len(o.{{.NameNative}}) != len(other.{{.NameNative}}) {
+		return false
+	}
+	for i, v := range o.{{.NameNative}} {
+ {{- if eq .Type "float32" "float64"}}
+		if w := other.{{.NameNative}}[i]; v != w && (v == v || w == w) {
+			return false
+		}
+ {{- else if eq .Type "binary"}}
+		if !bytes.Equal(v, other.{{.NameNative}}[i]) {
+			return false
+		}
+ {{- else if .TypeRef}}
+		w := other.{{.NameNative}}[i]
+		if v == nil {
+			v = new({{.TypeNative}})
+		}
+		if w == nil {
+			w = new({{.TypeNative}})
+		}
+		if !v.Equal(w) {
+			return false
+		}
+ {{- else}}
+		if v != other.{{.NameNative}}[i] {
+			return false
+		}
+ {{- end}}
+	}
+{{else if eq .Type "float32" "float64"}}
+	if v, w := o.{{.NameNative}}, other.{{.NameNative}}; v != w && (v == v || w == w) {
+		return false
+	}
+{{else if eq .Type "timestamp"}}
+ {{- if .Struct.Pkg.Canonical}}
+	if v, w := o.{{.NameNative}}, other.{{.NameNative}}; !v.Equal(w) {
+		// the Unix epoch has no serial, just like the zero value
+		if !(v.IsZero() || v.Equal(time.Unix(0, 0))) || !(w.IsZero() || w.Equal(time.Unix(0, 0))) {
+			return false
+		}
+	}
+ {{- else}}
+	if !o.{{.NameNative}}.Equal(other.{{.NameNative}}) {
+		return false
+	}
+ {{- end}}
+{{else if eq .Type "binary"}}
+	if !bytes.Equal(o.{{.NameNative}}, other.{{.NameNative}}) {
+		return false
+	}
+{{else if .TypeRef}}
+	if !o.{{.NameNative}}.Equal(other.{{.NameNative}}) {
+		return false
+	}
+{{else}}
+	if o.{{.NameNative}} != other.{{.NameNative}} {
+		return false
+	}
+{{end}}`
+
+const goCloneField = `{{if .TypeList}}
+	if o.{{.NameNative}} != nil {
+		c.{{.NameNative}} = make({{if .TypeRef}}[]*{{.TypeNative}}{{else if eq .Type "binary"}}[][]byte{{else}}[]{{.TypeNative}}{{end}}, len(o.{{.NameNative}}))
+ {{- if .TypeRef}}
+		for i, v := range o.{{.NameNative}} {
+			c.{{.NameNative}}[i] = v.Clone()
+		}
+ {{- else if eq .Type "binary"}}
+		for i, v := range o.{{.NameNative}} {
+			if v != nil {
+				c.{{.NameNative}}[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+ {{- else}}
+		copy(c.{{.NameNative}}, o.{{.NameNative}})
+ {{- end}}
+	}
+{{else if eq .Type "binary"}}
+	if o.{{.NameNative}} != nil {
+		c.{{.NameNative}} = append(make([]byte, 0, len(o.{{.NameNative}})), o.{{.NameNative}}...)
+	}
+{{else if .TypeRef}}
+	c.{{.NameNative}} = o.{{.NameNative}}.Clone()
+{{end}}`
+
+const goStringField = `{{if .TypeList}}
+	if len(o.{{.NameNative}}) != 0 {
+		a := make([]string, len(o.{{.NameNative}}))
+		for i, v := range o.{{.NameNative}} {
+ {{- if .TypeRef}}
+			a[i] = v.String()
+ {{- else if eq .Type "text"}}
+			a[i] = strconv.Quote(v)
+ {{- else if eq .Type "binary"}}
+			a[i] = fmt.Sprintf("0x%x", v)
+ {{- else}}
+			a[i] = fmt.Sprint(v)
+ {{- end}}
+		}
+		fields = append(fields, "{{.Name}}: ["+strings.Join(a, ", ")+"]")
+	}
+{{else if eq .Type "bool"}}
+	if o.{{.NameNative}} {
+		fields = append(fields, "{{.Name}}: true")
+	}
+{{else if eq .Type "timestamp"}}
+	if v := o.{{.NameNative}}; !v.IsZero() {
+		fields = append(fields, "{{.Name}}: "+v.UTC().Format(time.RFC3339Nano))
+	}
+{{else if eq .Type "text"}}
+	if v := o.{{.NameNative}}; v != "" {
+		fields = append(fields, "{{.Name}}: "+strconv.Quote(v))
+	}
+{{else if eq .Type "binary"}}
+	if v := o.{{.NameNative}}; len(v) != 0 {
+		fields = append(fields, fmt.Sprintf("{{.Name}}: 0x%x", v))
+	}
+{{else if .TypeRef}}
+	if v := o.{{.NameNative}}; v != nil {
+		fields = append(fields, "{{.Name}}: "+v.String())
+	}
+{{else}}
+	if v := o.{{.NameNative}}; v != 0 {
+		fields = append(fields, fmt.Sprint("{{.Name}}: ", v))
+	}
+{{end}}`
+
+const goMarshalField = `{{if eq .Type "bool"}}
+	if o.{{.NameNative}} {
+		buf[i] = {{.Index}}
+		i++
+	}
+{{else if eq .Type "uint8"}}
+	if x := o.{{.NameNative}}; x != 0 {
+		buf[i] = {{.Index}}
+		i++
+		buf[i] = x
+		i++
+	}
+{{else if eq .Type "uint16"}}
+	if x := o.{{.NameNative}}; x >= 1<<8 {
+		buf[i] = {{.Index}}
+		i++
+		buf[i] = byte(x >> 8)
+		i++
+		buf[i] = byte(x)
+		i++
+	} else if x != 0 {
+		buf[i] = {{.Index}} | 0x80
+		i++
+		buf[i] = byte(x)
+		i++
+	}
+{{else if eq .Type "uint32"}}
+	if x := o.{{.NameNative}}; x >= 1<<21 {
+		buf[i] = {{.Index}} | 0x80
+		intconv.PutUint32(buf[i+1:], x)
+		i += 5
+	} else if x != 0 {
+		buf[i] = {{.Index}}
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+{{else if eq .Type "uint64"}}
+	if x := o.{{.NameNative}}; x >= 1<<49 {
+		buf[i] = {{.Index}} | 0x80
+		intconv.PutUint64(buf[i+1:], x)
+		i += 9
+	} else if x != 0 {
+		buf[i] = {{.Index}}
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+{{else if eq .Type "int32"}}
+{{- if .TypeList}}
+	if l := len(o.{{.NameNative}}); l != 0 {
+		buf[i] = {{.Index}}
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.{{.NameNative}} {
+			x1 := uint32(v << 1) ^ uint32(v >> 31)
+			for ; x1 >= 0x80; {
+				buf[i] = byte(x1 | 0x80)
+				x1 >>= 7
+				i++
+			}
+			buf[i] = byte(x1)
+			i++
+		}
+	}
+{{- else}}
+	if v := o.{{.NameNative}}; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf[i] = {{.Index}}
+		} else {
 			x = ^x + 1
 			buf[i] = {{.Index}} | 0x80
 		}
@@ -403,14 +1185,30 @@ const goMarshalField = `{{if eq .Type "bool"}}
 		buf[i] = byte(x)
 		i++
 		for _, v := range o.{{.NameNative}} {
+ {{- if .Struct.Pkg.Canonical}}
+			if v != v {
+				intconv.PutUint32(buf[i:], 0x7fc00000)
+			} else {
+				intconv.PutUint32(buf[i:], math.Float32bits(v))
+			}
+ {{- else}}
 			intconv.PutUint32(buf[i:], math.Float32bits(v))
+ {{- end}}
 			i += 4
 		}
 	}
  {{- else}}
 	if v := o.{{.NameNative}}; v != 0 {
 		buf[i] = {{.Index}}
+ {{- if .Struct.Pkg.Canonical}}
+		if v != v {
+			intconv.PutUint32(buf[i+1:], 0x7fc00000)
+		} else {
+			intconv.PutUint32(buf[i+1:], math.Float32bits(v))
+		}
+ {{- else}}
 		intconv.PutUint32(buf[i+1:], math.Float32bits(v))
+ {{- end}}
 		i += 5
 	}
  {{- end}}
@@ -428,19 +1226,35 @@ const goMarshalField = `{{if eq .Type "bool"}}
 		buf[i] = byte(x)
 		i++
 		for _, v := range o.{{.NameNative}} {
+ {{- if .Struct.Pkg.Canonical}}
+			if v != v {
+				intconv.PutUint64(buf[i:], 0x7ff8000000000000)
+			} else {
+				intconv.PutUint64(buf[i:], math.Float64bits(v))
+			}
+ {{- else}}
 			intconv.PutUint64(buf[i:], math.Float64bits(v))
+ {{- end}}
 			i += 8
 		}
 	}
  {{- else}}
 	if v := o.{{.NameNative}}; v != 0 {
 		buf[i] = {{.Index}}
+ {{- if .Struct.Pkg.Canonical}}
+		if v != v {
+			intconv.PutUint64(buf[i+1:], 0x7ff8000000000000)
+		} else {
+			intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+		}
+ {{- else}}
 		intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+ {{- end}}
 		i += 9
 	}
  {{- end}}
 {{else if eq .Type "timestamp"}}
-	if v := o.{{.NameNative}}; !v.IsZero() {
+	if v := o.{{.NameNative}}; !v.IsZero(){{if .Struct.Pkg.Canonical}} && (v.Unix() != 0 || v.Nanosecond() != 0){{end}} {
 		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
 		if s < 1<<32 {
 			buf[i] = {{.Index}}
@@ -502,12 +1316,24 @@ const goMarshalField = `{{if eq .Type "bool"}}
 			i += v.MarshalTo(buf[i:])
 		}
 	}
+{{- if .Struct.Pkg.Lazy}} else if o.{{.NameNative}} == nil && o.lazy{{.NameNative}} != nil {
+		buf[i] = {{.Index}}
+		i++
+		i += copy(buf[i:], o.lazy{{.NameNative}})
+	}
+{{- end}}
 {{else}}
 	if v := o.{{.NameNative}}; v != nil {
 		buf[i] = {{.Index}}
 		i++
 		i += v.MarshalTo(buf[i:])
 	}
+{{- if .Struct.Pkg.Lazy}} else if o.lazy{{.NameNative}} != nil {
+		buf[i] = {{.Index}}
+		i++
+		i += copy(buf[i:], o.lazy{{.NameNative}})
+	}
+{{- end}}
 {{end}}`
 
 const goMarshalFieldLen = `{{if eq .Type "bool"}}
@@ -637,7 +1463,7 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 	}
  {{- end}}
 {{else if eq .Type "timestamp"}}
-	if v := o.{{.NameNative}}; !v.IsZero() {
+	if v := o.{{.NameNative}}; !v.IsZero(){{if .Struct.Pkg.Canonical}} && (v.Unix() != 0 || v.Nanosecond() != 0){{end}} {
 		if s := uint64(v.Unix()); s < 1<<32 {
 			l += 9
 		} else {
@@ -653,11 +1479,16 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
-		for _, a := range o.{{.NameNative}} {
+		for {{if and (eq .Type "text") .Struct.Pkg.StrictText}}ai{{else}}_{{end}}, a := range o.{{.NameNative}} {
 			x = len(a)
 			if x > ColferSizeMax {
 				return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", ColferSizeMax))
 			}
+ {{- if and (eq .Type "text") .Struct.Pkg.StrictText}}
+			if !utf8.ValidString(a) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: field {{.String}} element %d has malformed UTF-8", ai))
+			}
+ {{- end}}
 			for l += x+1; x >= 0x80; l++ {
 				x >>= 7
 			}
@@ -669,6 +1500,11 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 		if x > ColferSizeMax {
 			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", ColferSizeMax))
 		}
+ {{- if and (eq .Type "text") .Struct.Pkg.StrictText}}
+		if !utf8.ValidString(o.{{.NameNative}}) {
+			return 0, ColferUTF8("colfer: field {{.String}} has malformed UTF-8")
+		}
+ {{- end}}
 		for l += x+2; x >= 0x80; l++ {
 			x >>= 7
 		}
@@ -697,6 +1533,10 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
 		}
 	}
+{{- if .Struct.Pkg.Lazy}} else if o.{{.NameNative}} == nil && o.lazy{{.NameNative}} != nil {
+		l += len(o.lazy{{.NameNative}}) + 1
+	}
+{{- end}}
 {{else}}
 	if v := o.{{.NameNative}}; v != nil {
 		vl, err := v.MarshalLen()
@@ -705,6 +1545,10 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 		}
 		l += vl + 1
 	}
+{{- if .Struct.Pkg.Lazy}} else if o.lazy{{.NameNative}} != nil {
+		l += len(o.lazy{{.NameNative}}) + 1
+	}
+{{- end}}
 {{end}}`
 
 const goUnmarshalField = `{{if eq .Type "bool"}}
@@ -832,9 +1676,22 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if x > uint(ColferListMax) {
 			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
 		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
 
 		l := int(x)
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]int32, l)
+		}
+{{- else}}
 		a := make([]int32, l)
+{{- end}}
 		for ai := range a {
 			if i+1 >= len(data) {
 				i++
@@ -934,10 +1791,23 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if x > uint(ColferListMax) {
 			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
 		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
 
 		l := int(x)
 
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]int64, l)
+		}
+{{- else}}
 		a := make([]int64, l)
+{{- end}}
 		for ai := range a {
 			if i+1 >= len(data) {
 				i++
@@ -1043,7 +1913,16 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			i = end
 			goto eof
 		}
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]float32, l)
+		}
+{{- else}}
 		a := make([]float32, l)
+{{- end}}
 		for ai := range a {
 			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
 			i += 4
@@ -1078,7 +1957,16 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			i = end
 			goto eof
 		}
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]float64, l)
+		}
+{{- else}}
 		a := make([]float64, l)
+{{- end}}
 		for ai := range a {
 			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
 			i += 8
@@ -1127,7 +2015,20 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if x > uint(ColferListMax) {
 			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
 		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a != nil && cap(a) >= int(x) {
+			a = a[:x]
+		} else {
+			a = make([]string, int(x))
+		}
+{{- else}}
 		a := make([]string, int(x))
+{{- end}}
 		o.{{.NameNative}} = a
 
 		for ai := range a {
@@ -1141,6 +2042,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			if i >= len(data) {
 				goto eof
 			}
+ {{- if .Struct.Pkg.StrictText}}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: {{.String}} element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+ {{- end}}
 			a[ai] = string(data[start:i])
 		}
 
@@ -1160,6 +2066,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if i >= len(data) {
 			goto eof
 		}
+ {{- if .Struct.Pkg.StrictText}}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: {{.String}} has malformed UTF-8 at byte %d", start))
+		}
+ {{- end}}
 		o.{{.NameNative}} = string(data[start:i])
 
 		header = data[i]
@@ -1173,13 +2084,23 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if x > uint(ColferSizeMax) {
 			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, ColferSizeMax))
 		}
-		v := make([]byte, int(x))
 
+		// no allocation before the data is there
 		start := i
-		i += len(v)
+		i += int(x)
 		if i >= len(data) {
 			goto eof
 		}
+{{- if .Reuse}}
+		v := prev.{{.NameNative}}
+		if v != nil && cap(v) >= int(x) {
+			v = v[:x]
+		} else {
+			v = make([]byte, int(x))
+		}
+{{- else}}
+		v := make([]byte, int(x))
+{{- end}}
 		copy(v, data[start:i])
 		o.{{.NameNative}} = v
 
@@ -1189,21 +2110,45 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if x > uint(ColferListMax) {
 			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
 		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a == nil {
+			a = make([][]byte, int(x))
+		} else if cap(a) >= int(x) {
+			a = a[:x]
+		} else {
+			a = append(a[:cap(a)], make([][]byte, int(x)-cap(a))...)
+		}
+{{- else}}
 		a := make([][]byte, int(x))
+{{- end}}
 		o.{{.NameNative}} = a
 		for ai := range a {
 {{template "unmarshal-varint" .}}
 			if x > uint(ColferSizeMax) {
 				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
 			}
-			v := make([]byte, int(x))
 
+			// no allocation before the data is there
 			start := i
-			i += len(v)
+			i += int(x)
 			if i >= len(data) {
 				goto eof
 			}
-
+{{- if .Reuse}}
+			v := a[ai]
+			if v != nil && cap(v) >= int(x) {
+				v = v[:x]
+			} else {
+				v = make([]byte, int(x))
+			}
+{{- else}}
+			v := make([]byte, int(x))
+{{- end}}
 			copy(v, data[start:i])
 			a[ai] = v
 		}
@@ -1215,21 +2160,59 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		i++
  {{- end}}
 	}
+{{else if .Struct.Pkg.Lazy}}
+	if header == {{.Index}} {
+		start := i
+{{- template "skip-ref" .}}
+		o.{{.NameNative}} = nil
+		o.lazy{{.NameNative}} = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
 {{else if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
 		if x > uint(ColferListMax) {
 			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
 		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
 
 		l := int(x)
+{{- if .Reuse}}
+		a := prev.{{.NameNative}}
+		if a == nil {
+			a = make([]*{{.TypeNative}}, l)
+		} else if cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = append(a[:cap(a)], make([]*{{.TypeNative}}, l-cap(a))...)
+		}
+		for ai, v := range a {
+			if v == nil {
+				v = new({{.TypeNative}})
+				a[ai] = v
+			}
+
+			// reserve one byte for each element pending
+			n, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}v.unmarshalReuse(data[i:len(data)-(l-1-ai)], depth+1){{else}}v.UnmarshalReuseDepth(data[i:len(data)-(l-1-ai)], depth+1){{end}}
+{{- else}}
 		a := make([]*{{.TypeNative}}, l)
 		malloc := make([]{{.TypeNative}}, l)
 		for ai := range a {
 			v := &malloc[ai]
 			a[ai] = v
 
-			n, err := v.Unmarshal(data[i:])
+			// reserve one byte for each element pending
+			n, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}v.unmarshal(data[i:len(data)-(l-1-ai)]{{if .Struct.Pkg.KeepUnknown}}, false{{end}}, depth+1){{else}}v.UnmarshalDepth(data[i:len(data)-(l-1-ai)], depth+1){{end}}
+{{- end}}
 			if err != nil {
 				if err == io.EOF && len(data) >= ColferSizeMax {
 					return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
@@ -1248,8 +2231,17 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
 {{else}}
 	if header == {{.Index}} {
+{{- if .Reuse}}
+		v := prev.{{.NameNative}}
+		if v == nil {
+			v = new({{.TypeNative}})
+		}
+		o.{{.NameNative}} = v
+		n, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}v.unmarshalReuse(data[i:], depth+1){{else}}v.UnmarshalReuseDepth(data[i:], depth+1){{end}}
+{{- else}}
 		o.{{.NameNative}} = new({{.TypeNative}})
-		n, err := o.{{.NameNative}}.Unmarshal(data[i:])
+		n, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}o.{{.NameNative}}.unmarshal(data[i:]{{if .Struct.Pkg.KeepUnknown}}, false{{end}}, depth+1){{else}}o.{{.NameNative}}.UnmarshalDepth(data[i:], depth+1){{end}}
+{{- end}}
 		if err != nil {
 			if err == io.EOF && len(data) >= ColferSizeMax {
 				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
@@ -1289,3 +2281,1733 @@ const goUnmarshalVarint = `		if i >= len(data) {
 			}
 		}
 `
+
+const goMarshalJSONField = `
+	buf = append(buf, "{{if .Index}},{{end}}\"{{.Name}}\":"...)
+{{- $v := printf "o.%s" .NameNative}}
+{{- if .TypeList}}{{$v = "v"}}
+	buf = append(buf, '[')
+	for i, v := range o.{{.NameNative}} {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+{{- end}}
+{{- if eq .Type "bool"}}
+	buf = strconv.AppendBool(buf, {{$v}})
+{{- else if eq .Type "uint64"}}
+	buf = strconv.AppendUint(buf, {{$v}}, 10)
+{{- else if eq .Type "uint8" "uint16" "uint32"}}
+	buf = strconv.AppendUint(buf, uint64({{$v}}), 10)
+{{- else if eq .Type "int64"}}
+	buf = strconv.AppendInt(buf, {{$v}}, 10)
+{{- else if eq .Type "int32"}}
+	buf = strconv.AppendInt(buf, int64({{$v}}), 10)
+{{- else if eq .Type "float64"}}
+	buf = colferJSONFloat(buf, {{$v}}, 64)
+{{- else if eq .Type "float32"}}
+	buf = colferJSONFloat(buf, float64({{$v}}), 32)
+{{- else if eq .Type "timestamp"}}
+	buf = colferJSONTime(buf, {{$v}})
+{{- else if eq .Type "text"}}
+	buf = colferJSONText(buf, {{$v}})
+{{- else if eq .Type "binary"}}
+	buf = colferJSONBinary(buf, {{$v}})
+{{- else if .Struct.Pkg.Lazy}}
+	buf, err = {{if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}colferJSONAppend(buf, {{$v}}){{else}}{{$v}}.appendJSON(buf){{end}}
+	if err != nil {
+		return nil, err
+	}
+{{- else if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}
+	buf = colferJSONAppend(buf, {{$v}})
+{{- else}}
+	buf = {{$v}}.appendJSON(buf)
+{{- end}}
+{{- if .TypeList}}
+	}
+	buf = append(buf, ']')
+{{- end}}
+`
+
+const goUnmarshalJSONField = `		case "{{.Name}}":
+			if r.consume("null") {
+				break
+			}
+{{- if .TypeList}}
+			if r.peek() != '[' {
+				return fmt.Errorf("colfer: {{.String}}: %w", r.typeError("array"))
+			}
+			r.i++
+			var a []{{if .TypeRef}}*{{end}}{{.TypeNative}}
+			for first := true; ; first = false {
+				more, err := r.next(']', first)
+				if err != nil {
+					return fmt.Errorf("colfer: {{.String}}: %w", err)
+				}
+				if !more {
+					break
+				}
+				if len(a) >= ColferListMax {
+					return ColferMax(fmt.Sprintf("colfer: {{.String}} length exceeds %d elements", ColferListMax))
+				}
+ {{- if .TypeRef}}
+				if r.consume("null") {
+					a = append(a, nil)
+					continue
+				}
+ {{- end}}
+{{- end}}
+{{- if .TypeRef}}
+			v := new({{.TypeNative}})
+ {{- if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}
+			object, err := r.object()
+			if err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %w", err)
+			}
+			if err := v.UnmarshalJSON(object); err != nil {
+				return err
+			}
+ {{- else}}
+			if err := v.unmarshalJSON(r); err != nil {
+				return err
+			}
+ {{- end}}
+{{- else}}
+ {{- if eq .Type "bool"}}
+			v, err := r.boolean()
+ {{- else if eq .Type "uint64"}}
+			v, err := r.unsigned(64)
+ {{- else if eq .Type "uint8" "uint16" "uint32"}}
+			v, err := r.unsigned({{slice .Type 4}})
+ {{- else if eq .Type "int64"}}
+			v, err := r.signed(64)
+ {{- else if eq .Type "int32"}}
+			v, err := r.signed(32)
+ {{- else if eq .Type "float64"}}
+			v, err := r.float(64)
+ {{- else if eq .Type "float32"}}
+			v, err := r.float(32)
+ {{- else if eq .Type "timestamp"}}
+			v, err := r.timestamp()
+ {{- else if eq .Type "text"}}
+			v, err := r.text()
+ {{- else if eq .Type "binary"}}
+			v, err := r.binary()
+ {{- end}}
+			if err != nil {
+				return fmt.Errorf("colfer: {{.String}}: %w", err)
+			}
+ {{- if eq .Type "text" "binary"}}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+ {{- end}}
+{{- end}}
+{{- $v := "v"}}{{if eq .Type "uint8" "uint16" "uint32" "int32" "float32"}}{{$v = printf "%s(v)" .Type}}{{end}}
+{{- if .TypeList}}
+				a = append(a, {{$v}})
+			}
+			o.{{.NameNative}} = a
+{{- else}}
+			o.{{.NameNative}} = {{$v}}
+{{- end}}
+`
+
+const goJSONCode = `
+{{- if .HasFloat}}
+
+// ColferJSONFloat appends f as a JSON number, or as a string for not-a-number
+// and infinite values.
+func colferJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(f, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(f, -1):
+		return append(buf, "\"-Infinity\""...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+{{- end}}
+{{- if .HasTimestamp}}
+
+// ColferJSONTime appends t as an RFC 3339 string, or as a decimal number of
+// seconds since the Unix epoch for years beyond the 0–9999 range. The zero
+// value is null.
+func colferJSONTime(buf []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(buf, "null"...)
+	}
+	if year := t.Year(); year >= 0 && year <= 9999 {
+		buf = append(buf, '"')
+		buf = t.AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	}
+
+	s, ns := t.Unix(), t.Nanosecond()
+	if s < 0 {
+		buf = append(buf, '-')
+		if ns != 0 {
+			s++
+			ns = 1e9 - ns
+		}
+		buf = strconv.AppendUint(buf, uint64(-s), 10)
+	} else {
+		buf = strconv.AppendInt(buf, s, 10)
+	}
+	if ns != 0 {
+		fraction := strconv.Itoa(ns + 1e9)[1:]
+		buf = append(buf, '.')
+		buf = append(buf, strings.TrimRight(fraction, "0")...)
+	}
+	return buf
+}
+{{- end}}
+{{- if .HasText}}
+
+// ColferJSONText appends s as a JSON string. Malformed UTF-8 is replaced with
+// the Unicode replacement character.
+func colferJSONText(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < ' ':
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, "\ufffd"...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+{{- end}}
+{{- if .HasBinary}}
+
+// ColferJSONBinary appends v as a base64 string.
+func colferJSONBinary(buf, v []byte) []byte {
+	buf = append(buf, '"')
+	offset := len(buf)
+	buf = append(buf, make([]byte, base64.StdEncoding.EncodedLen(len(v)))...)
+	base64.StdEncoding.Encode(buf[offset:], v)
+	return append(buf, '"')
+}
+{{- end}}
+{{- if .Refs}}
+
+{{- if .Lazy}}
+
+// ColferJSONAppend appends the JSON of a struct from another package to buf.
+func colferJSONAppend(buf []byte, m interface{ MarshalJSON() ([]byte, error) }) ([]byte, error) {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, b...), nil
+}
+{{- else}}
+
+// ColferJSONAppend appends the JSON of a struct from another package, which
+// must pass MarshalLen, to buf.
+func colferJSONAppend(buf []byte, m interface{ MarshalJSON() ([]byte, error) }) []byte {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		panic(err) // checked by MarshalLen of the parent
+	}
+	return append(buf, b...)
+}
+{{- end}}
+{{- end}}
+
+// ColferJSON reads JSON without reflection.
+type colferJSON struct {
+	data  []byte
+	i     int // read index
+	depth int // nesting level
+}
+
+func (r *colferJSON) syntaxError() error {
+	if r.i >= len(r.data) {
+		return fmt.Errorf("unexpected end of JSON at byte %d", r.i)
+	}
+	return fmt.Errorf("JSON syntax error at byte %d", r.i)
+}
+
+func (r *colferJSON) typeError(want string) error {
+	if r.i >= len(r.data) {
+		return r.syntaxError()
+	}
+	return fmt.Errorf("JSON at byte %d is not a %s", r.i, want)
+}
+
+// Peek returns the next byte after any whitespace, or zero at the end.
+func (r *colferJSON) peek() byte {
+	for ; r.i < len(r.data); r.i++ {
+		switch c := r.data[r.i]; c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// Consume reads token when next.
+func (r *colferJSON) consume(token string) bool {
+	r.peek()
+	if len(r.data)-r.i < len(token) || string(r.data[r.i:r.i+len(token)]) != token {
+		return false
+	}
+	r.i += len(token)
+	return true
+}
+
+// Next reads up to the next member or element of an object or an array, with
+// end as the closing byte. The return is false when end was read instead.
+func (r *colferJSON) next(end byte, first bool) (bool, error) {
+	c := r.peek()
+	if c == end {
+		r.i++
+		return false, nil
+	}
+	if !first {
+		if c != ',' {
+			return false, r.syntaxError()
+		}
+		r.i++
+	}
+	return true, nil
+}
+
+func (r *colferJSON) boolean() (bool, error) {
+	switch {
+	case r.consume("true"):
+		return true, nil
+	case r.consume("false"):
+		return false, nil
+	}
+	return false, r.typeError("boolean")
+}
+
+// Number reads the digits of a number, which may be quoted.
+func (r *colferJSON) number() (string, error) {
+	switch c := r.peek(); {
+	case c == '"':
+		return r.text()
+	case c != '-' && (c < '0' || c > '9'):
+		return "", r.typeError("number")
+	}
+	start := r.i
+	for r.i++; r.i < len(r.data); r.i++ {
+		c := r.data[r.i]
+		if (c < '0' || c > '9') && c != '.' && c != 'e' && c != 'E' && c != '+' && c != '-' {
+			break
+		}
+	}
+	return string(r.data[start:r.i]), nil
+}
+
+func (r *colferJSON) unsigned(bitSize int) (uint64, error) {
+	s, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, bitSize)
+}
+
+func (r *colferJSON) signed(bitSize int) (int64, error) {
+	s, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, bitSize)
+}
+{{- if .HasFloat}}
+
+func (r *colferJSON) float(bitSize int) (float64, error) {
+	if r.peek() == '"' {
+		s, err := r.text()
+		if err != nil {
+			return 0, err
+		}
+		switch s {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return 0, fmt.Errorf("JSON string %q is not a floating point", s)
+	}
+
+	s, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, bitSize)
+}
+{{- end}}
+{{- if .HasTimestamp}}
+
+// Timestamp reads the format of colferJSONTime.
+func (r *colferJSON) timestamp() (time.Time, error) {
+	if r.peek() == '"' {
+		s, err := r.text()
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.In(time.UTC), nil
+	}
+
+	digits, err := r.number()
+	if err != nil {
+		return time.Time{}, err
+	}
+	negative := strings.HasPrefix(digits, "-")
+	if negative {
+		digits = digits[1:]
+	}
+	var fraction string
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		digits, fraction = digits[:i], digits[i+1:]
+	}
+
+	s, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || s > 1<<63 || (s == 1<<63 && !negative) {
+		return time.Time{}, fmt.Errorf("JSON timestamp before byte %d is not a whole number of seconds with an optional fraction in range", r.i)
+	}
+	var ns int64
+	if fraction != "" {
+		ns, err = strconv.ParseInt((fraction + "00000000")[:9], 10, 64)
+		if err != nil || len(fraction) > 9 || fraction[0] == '-' || fraction[0] == '+' {
+			return time.Time{}, fmt.Errorf("JSON timestamp before byte %d has a malformed fraction or it exceeds nanosecond precision", r.i)
+		}
+	}
+
+	if !negative {
+		return time.Unix(int64(s), ns).In(time.UTC), nil
+	}
+	if ns != 0 {
+		if s == 1<<63 {
+			return time.Time{}, fmt.Errorf("JSON timestamp before byte %d out of range", r.i)
+		}
+		s++
+		ns = 1e9 - ns
+	}
+	return time.Unix(-int64(s), ns).In(time.UTC), nil
+}
+{{- end}}
+{{- if .HasBinary}}
+
+// Binary reads a base64 string.
+func (r *colferJSON) binary() ([]byte, error) {
+	s, err := r.text()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+{{- end}}
+{{- if .Refs}}
+
+// Object reads a JSON object as is, without validation of the content.
+func (r *colferJSON) object() ([]byte, error) {
+	if r.peek() != '{' {
+		return nil, r.typeError("object")
+	}
+	start := r.i
+	for depth := 0; ; {
+		switch r.peek() {
+		case 0:
+			return nil, r.syntaxError()
+		case '"':
+			if _, err := r.text(); err != nil {
+				return nil, err
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				r.i++
+				return r.data[start:r.i], nil
+			}
+		}
+		r.i++
+	}
+}
+{{- end}}
+
+// Text reads a JSON string.
+func (r *colferJSON) text() (string, error) {
+	if r.peek() != '"' {
+		return "", r.typeError("string")
+	}
+	r.i++
+
+	var buf []byte // unescaped content
+	for start := r.i; r.i < len(r.data); {
+		c := r.data[r.i]
+		switch {
+		case c == '"':
+			s := string(append(buf, r.data[start:r.i]...))
+			r.i++
+			if !utf8.ValidString(s) {
+				return "", fmt.Errorf("JSON string with malformed UTF-8 before byte %d", r.i)
+			}
+			return s, nil
+		case c < ' ':
+			return "", r.syntaxError()
+		case c != '\\':
+			r.i++
+			continue
+		}
+
+		buf = append(buf, r.data[start:r.i]...)
+		r.i++
+		if r.i >= len(r.data) {
+			break
+		}
+		switch c := r.data[r.i]; c {
+		case '"', '\\', '/':
+			buf = append(buf, c)
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			x, ok := r.hex4(r.i + 1)
+			if !ok {
+				return "", r.syntaxError()
+			}
+			r.i += 4
+			if x >= 0xd800 && x < 0xdc00 && len(r.data)-r.i > 2 && r.data[r.i+1] == '\\' && r.data[r.i+2] == 'u' {
+				// surrogate pair
+				if y, ok := r.hex4(r.i + 3); ok && y >= 0xdc00 && y < 0xe000 {
+					x = 0x10000 + (x-0xd800)<<10 | (y - 0xdc00)
+					r.i += 6
+				}
+			}
+			if x >= 0xd800 && x < 0xe000 {
+				x = utf8.RuneError
+			}
+			var encoded [utf8.UTFMax]byte
+			buf = append(buf, encoded[:utf8.EncodeRune(encoded[:], x)]...)
+		default:
+			return "", r.syntaxError()
+		}
+		r.i++
+		start = r.i
+	}
+	return "", r.syntaxError()
+}
+
+// Hex4 parses the 4 hexadecimal digits at data[i:].
+func (r *colferJSON) hex4(i int) (rune, bool) {
+	if len(r.data)-i < 4 {
+		return 0, false
+	}
+	var x rune
+	for _, c := range r.data[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			x = x<<4 | rune(c-'0')
+		case c >= 'a' && c <= 'f':
+			x = x<<4 | rune(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			x = x<<4 | rune(c-'A'+10)
+		default:
+			return 0, false
+		}
+	}
+	return x, true
+}
+`
+
+const goFuzzCode = `//go:build go1.18
+// +build go1.18
+
+package {{.NameNative}}
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}}.
+
+import (
+	"bytes"
+	"testing"
+{{- if .HasTimestamp}}
+	"time"
+{{- end}}
+{{- range .Refs}}
+	"{{.Name}}"
+{{- end}}
+)
+{{range .Structs}}
+// ColferSample{{.NameNative}} returns a value with each field set.
+func colferSample{{.NameNative}}() *{{.NameNative}} {
+	return &{{.NameNative}}{
+{{- range .Fields}}
+		{{.NameNative}}: {{template "fuzz-sample-field" .}},
+{{- end}}
+	}
+}
+
+// Fuzz{{.NameNative}} verifies that any serial which unmarshals without error,
+// marshals back into a serial which unmarshals into the same value, and that
+// no input causes a panic.
+func Fuzz{{.NameNative}}(f *testing.F) {
+	for _, o := range []*{{.NameNative}}{new({{.NameNative}}), colferSample{{.NameNative}}()} {
+		serial, err := o.MarshalBinary()
+		if err != nil {
+			f.Fatal("seed marshal error:", err)
+		}
+		f.Add(serial)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+{{- if .Pkg.JSON}}
+		// JSON must not panic either
+		new({{.NameNative}}).UnmarshalJSON(data)
+{{end}}
+		o := new({{.NameNative}})
+		if err := o.UnmarshalBinary(data); err != nil {
+			return // invalid input
+		}
+		serial, err := o.MarshalBinary()
+		if err != nil {
+			t.Fatalf("marshal of %s: %s", o, err)
+		}
+
+		got := new({{.NameNative}})
+		if err := got.UnmarshalBinary(serial); err != nil {
+			t.Fatalf("unmarshal of %#x: %s", serial, err)
+		}
+		if !got.Equal(o) {
+			t.Errorf("round-trip of %#x got %s, want %s", serial, got, o)
+		}
+		again, err := got.MarshalBinary()
+		if err != nil {
+			t.Fatalf("marshal of %s: %s", got, err)
+		}
+		if !bytes.Equal(again, serial) {
+			t.Errorf("second marshal got %#x, want %#x", again, serial)
+		}
+	})
+}
+{{end}}`
+
+const goFuzzSampleField = `
+{{- if .TypeRef}}
+ {{- if .TypeList}}[]*{{.TypeNative}}{new({{.TypeNative}}), new({{.TypeNative}})}
+ {{- else}}new({{.TypeNative}})
+ {{- end}}
+{{- else if .TypeList}}
+ {{- if eq .Type "float32"}}[]float32{-1.5, 0x1p-149, 3.4028234663852886e38}
+ {{- else if eq .Type "float64"}}[]float64{-1.5, 5e-324, 1.7976931348623157e308}
+ {{- else if eq .Type "int32"}}[]int32{-2147483648, 0, 2147483647}
+ {{- else if eq .Type "int64"}}[]int64{-9223372036854775808, 0, 9223372036854775807}
+ {{- else if eq .Type "text"}}[]string{"", "λ"}
+ {{- else if eq .Type "binary"}}[][]byte{nil, {0, 0xff}}
+ {{- end}}
+{{- else if eq .Type "bool"}}true
+{{- else if eq .Type "uint8"}}255
+{{- else if eq .Type "uint16"}}65535
+{{- else if eq .Type "uint32"}}4294967295
+{{- else if eq .Type "uint64"}}18446744073709551615
+{{- else if eq .Type "int32"}}-2147483648
+{{- else if eq .Type "int64"}}-9223372036854775808
+{{- else if eq .Type "float32"}}3.4028234663852886e38
+{{- else if eq .Type "float64"}}5e-324
+{{- else if eq .Type "timestamp"}}time.Unix(1<<40, 999999999).UTC()
+{{- else if eq .Type "text"}}"λ"
+{{- else if eq .Type "binary"}}[]byte{0, 0xff}
+{{- end}}`
+
+const goQuickCode = `package {{.NameNative}}
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}}.
+
+import (
+{{- if .HasFloat}}
+	"math"
+{{- end}}
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+{{- if .HasTimestamp}}
+	"time"
+{{- end}}
+{{- if .HasText}}
+	"unicode/utf8"
+{{- end}}
+{{- range .Refs}}
+	"{{.Name}}"
+{{- end}}
+)
+{{range .Structs}}
+// ColferRandom{{.NameNative}} returns a random value with a bias towards the
+// edge cases of the encoding. The size bounds the number of list elements and
+// the length of text and binaries. Nested data structures get a quarter of the
+// size.
+{{- if .Pkg.Refs}}
+// Data structures from other packages are left empty.
+{{- end}}
+func colferRandom{{.NameNative}}(r *rand.Rand, size int) *{{.NameNative}} {
+	o := new({{.NameNative}})
+{{- range .Fields}}
+{{- if .TypeRef}}
+ {{- if .TypeList}}
+	if n := colferQuickLen(r, size/4); n != 0 {
+		o.{{.NameNative}} = make([]*{{.TypeNative}}, n)
+		for i := range o.{{.NameNative}} {
+ {{- if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}
+			o.{{.NameNative}}[i] = colferRandom{{.TypeRef.NameNative}}(r, size/4)
+ {{- else}}
+			o.{{.NameNative}}[i] = new({{.TypeNative}})
+ {{- end}}
+		}
+	}
+ {{- else}}
+	if size >= 4 && r.Intn(2) == 0 {
+ {{- if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}
+		o.{{.NameNative}} = colferRandom{{.TypeRef.NameNative}}(r, size/4)
+ {{- else}}
+		o.{{.NameNative}} = new({{.TypeNative}})
+ {{- end}}
+	}
+ {{- end}}
+{{- else if .TypeList}}
+	o.{{.NameNative}} = make([]{{.TypeNative}}, colferQuickLen(r, size))
+	for i := range o.{{.NameNative}} {
+		o.{{.NameNative}}[i] = {{template "quick-value" .}}
+	}
+{{- else}}
+	o.{{.NameNative}} = {{template "quick-value" .}}
+{{- end}}
+{{- end}}
+	return o
+}
+
+// Generate implements testing/quick.Generator with values which do not
+// exceed ColferSizeMax.
+func (*{{.NameNative}}) Generate(r *rand.Rand, size int) reflect.Value {
+	for {
+		o := colferRandom{{.NameNative}}(r, size)
+		if _, err := o.MarshalLen(); err == nil || size == 0 {
+			return reflect.ValueOf(o)
+		}
+		size /= 2
+	}
+}
+
+// TestQuick{{.NameNative}} verifies that marshal followed by unmarshal is the
+// identity, and that MarshalLen matches the number of bytes written.
+func TestQuick{{.NameNative}}(t *testing.T) {
+	f := func(o *{{.NameNative}}) bool {
+		n, err := o.MarshalLen()
+		if err != nil {
+			t.Errorf("marshal length of %s: %s", o, err)
+			return false
+		}
+		buf := make([]byte, n)
+		if written := o.MarshalTo(buf); written != n {
+			t.Errorf("marshal of %s wrote %d bytes, want %d from MarshalLen", o, written, n)
+			return false
+		}
+
+		got := new({{.NameNative}})
+		if err := got.UnmarshalBinary(buf); err != nil {
+			t.Errorf("unmarshal of %#x: %s", buf, err)
+			return false
+		}
+		if !got.Equal(o) {
+			t.Errorf("round-trip of %s got %s", o, got)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+{{end}}
+// ColferQuickLen returns a random list length within ColferListMax.
+func colferQuickLen(r *rand.Rand, size int) int {
+{{- if .HasList}}
+	if size > ColferListMax {
+		size = ColferListMax
+	}
+{{- end}}
+	if size <= 0 {
+		return 0
+	}
+	return r.Intn(size + 1)
+}
+
+// ColferQuickUint returns a random integer of bits in size, with a bias towards
+// zero, the maximum and the bit position of the 0x80 header flag.
+func colferQuickUint(r *rand.Rand, bits, flag uint) uint64 {
+	max := uint64(1)<<bits - 1
+	var x uint64
+	switch r.Intn(8) {
+	case 0:
+		x = 0
+	case 1:
+		x = max
+	case 2:
+		x = uint64(1)<<flag - 1
+	case 3:
+		x = uint64(1) << flag
+	case 4:
+		x = uint64(r.Intn(0x80))
+	default:
+		// random magnitude
+		x = r.Uint64() >> uint(r.Intn(64))
+	}
+	return x & max
+}
+
+// ColferQuickInt returns a random integer of bits in size, with a bias towards
+// zero, the minimum, the maximum and negative values.
+func colferQuickInt(r *rand.Rand, bits uint) int64 {
+	switch r.Intn(6) {
+	case 0:
+		return 0
+	case 1:
+		return -1 << (bits - 1)
+	case 2:
+		return 1<<(bits-1) - 1
+	case 3:
+		return -int64(r.Intn(0x80))
+	default:
+		// random sign and magnitude
+		return int64(r.Uint64()) >> (64 - bits) >> uint(r.Intn(int(bits)))
+	}
+}
+{{- if .HasFloat}}
+
+// ColferQuickFloat returns a random floating point of bits in size, with a bias
+// towards zero, NaN, the infinities and the extremes.
+func colferQuickFloat(r *rand.Rand, bits int) float64 {
+	switch r.Intn(8) {
+	case 0:
+		return 0
+	case 1:
+		return math.NaN()
+	case 2:
+		return math.Inf(1)
+	case 3:
+		return math.Inf(-1)
+	case 4:
+		if bits == 32 {
+			return -math.MaxFloat32
+		}
+		return math.MaxFloat64
+	case 5:
+		if bits == 32 {
+			return math.SmallestNonzeroFloat32
+		}
+		return -math.SmallestNonzeroFloat64
+	default:
+		if bits == 32 {
+			return float64(math.Float32frombits(r.Uint32()))
+		}
+		return math.Float64frombits(r.Uint64())
+	}
+}
+{{- end}}
+{{- if .HasTimestamp}}
+
+// ColferQuickTime returns a random timestamp, with a bias towards the zero
+// value, and towards seconds outside of the 32-bit range, before and after.
+func colferQuickTime(r *rand.Rand) time.Time {
+	ns := int64(r.Intn(1e9))
+	switch r.Intn(5) {
+	case 0:
+		return time.Time{}
+	case 1:
+		return time.Unix(int64(r.Uint32()), ns)
+	case 2:
+		return time.Unix(-1-int64(r.Uint32()), ns)
+	case 3:
+		return time.Unix(1<<32+int64(r.Uint32()), ns)
+	default:
+		return time.Unix(r.Int63n(1<<40)-1<<39, ns)
+	}
+}
+{{- end}}
+{{- if .HasText}}
+
+// ColferQuickText returns random UTF-8 with up to size characters.
+func colferQuickText(r *rand.Rand, size int) string {
+	if size <= 0 {
+		return ""
+	}
+	runes := make([]rune, r.Intn(size+1))
+	for i := range runes {
+		switch r.Intn(4) {
+		case 0:
+			runes[i] = rune(r.Intn(0x80))
+		case 1:
+			runes[i] = rune(r.Intn(0x800))
+		default:
+			runes[i] = rune(r.Intn(utf8.MaxRune + 1))
+		}
+		if !utf8.ValidRune(runes[i]) {
+			runes[i] = utf8.RuneError
+		}
+	}
+	return string(runes)
+}
+{{- end}}
+{{- if .HasBinary}}
+
+// ColferQuickBinary returns up to size random bytes.
+func colferQuickBinary(r *rand.Rand, size int) []byte {
+	if size <= 0 {
+		return nil
+	}
+	b := make([]byte, r.Intn(size+1))
+	r.Read(b)
+	return b
+}
+{{- end}}
+`
+
+const goQuickValue = `
+{{- if eq .Type "bool"}}r.Intn(2) == 0
+{{- else if eq .Type "uint8"}}uint8(colferQuickUint(r, 8, 8))
+{{- else if eq .Type "uint16"}}uint16(colferQuickUint(r, 16, 8))
+{{- else if eq .Type "uint32"}}uint32(colferQuickUint(r, 32, 21))
+{{- else if eq .Type "uint64"}}colferQuickUint(r, 64, 49)
+{{- else if eq .Type "int32"}}int32(colferQuickInt(r, 32))
+{{- else if eq .Type "int64"}}colferQuickInt(r, 64)
+{{- else if eq .Type "float32"}}float32(colferQuickFloat(r, 32))
+{{- else if eq .Type "float64"}}colferQuickFloat(r, 64)
+{{- else if eq .Type "timestamp"}}colferQuickTime(r)
+{{- else if eq .Type "text"}}colferQuickText(r, size)
+{{- else if eq .Type "binary"}}colferQuickBinary(r, size)
+{{- end}}`
+
+const goMaskCode = `
+// FieldMask returns the fields with their name from the schema as a mask.
+func (*{{.NameNative}}) FieldMask(names ...string) (ColferMask, error) {
+	var m ColferMask
+	for _, name := range names {
+		switch name {
+{{- range .Fields}}
+		case "{{.Name}}":
+			m = m.With({{.Index}})
+{{- end}}
+		default:
+			return m, fmt.Errorf("colfer: field %q not in {{.String}}", name)
+		}
+	}
+	return m, nil
+}
+
+// UnmarshalFields decodes data as Colfer like Unmarshal does, yet it sets the
+// fields in mask only. Nested data structures in mask are decoded
+{{- if .Pkg.Lazy}} like
+// Unmarshal does.{{else}} in full.{{end}}
+// All other fields are skipped without allocation, while their structure and
+// their limits are validated still.
+{{- if not (or .Pkg.Lazy .Pkg.Iterate)}} Text which is skipped is not validated on
+// UTF-8.
+{{- end}}
+// The error return options are io.EOF, ColferError{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
+func (o *{{.NameNative}}) UnmarshalFields(data []byte, mask ColferMask) (int, error) {
+	return o.unmarshalFields(data, mask, 1)
+}
+
+{{- if foreign .}}
+
+// UnmarshalFieldsDepth decodes data as Colfer like UnmarshalFields, with depth
+// as the nesting level of o. Generated code from other packages uses it for
+// nested data structures, such that the ColferDepthMax limit holds.
+func (o *{{.NameNative}}) UnmarshalFieldsDepth(data []byte, mask ColferMask, depth int) (int, error) {
+	return o.unmarshalFields(data, mask, depth)
+}
+{{- end}}
+
+// UnmarshalFields decodes data as Colfer like UnmarshalFields, with depth as
+// the nesting level of o.
+func (o *{{.NameNative}}) unmarshalFields(data []byte, mask ColferMask, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct {{.String}} exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+{{range .Fields}}
+	if mask.Has({{.Index}}) {
+{{- template "unmarshal-field" (fresh .) -}}
+	} else {
+{{- template "skip-field" . -}}
+	}
+{{end}}
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+`
+
+const goSkipField = `{{if eq .Type "bool"}}
+	if header == {{.Index}} {
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else if eq .Type "uint8"}}
+	if header == {{.Index}} {
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else if eq .Type "uint16"}}
+	if header == {{.Index}} || header == {{.Index}}|0x80 {
+		if header == {{.Index}} {
+			i += 2
+		} else {
+			i++
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else if eq .Type "timestamp"}}
+	if header == {{.Index}} || header == {{.Index}}|0x80 {
+		if header == {{.Index}} {
+			i += 8
+		} else {
+			i += 12
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else if and (eq .Type "float32" "float64") (not .TypeList)}}
+	if header == {{.Index}} {
+		i += {{if eq .Type "float32"}}4{{else}}8{{end}}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else if and (eq .Type "uint32" "uint64" "int32" "int64") (not .TypeList)}}
+	if header == {{.Index}} || header == {{.Index}}|0x80 {
+ {{- if eq .Type "uint32" "uint64"}}
+		if header == {{.Index}}|0x80 {
+			i += {{if eq .Type "uint32"}}4{{else}}8{{end}}
+		} else {
+ {{- else}}
+		{
+ {{- end}}
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80{{if eq .Type "uint64" "int64"}} || n == 9{{end}} {
+					break
+				}
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else if or (eq .Type "text" "binary") (not .TypeRef)}}
+	if header == {{.Index}} {
+{{template "unmarshal-varint" .}}
+ {{- if .TypeList}}
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		}
+  {{- if eq .Type "float32" "float64"}}
+		i += int(x) * {{if eq .Type "float32"}}4{{else}}8{{end}}
+  {{- else if eq .Type "int32" "int64"}}
+		for ai := int(x); ai > 0; ai-- {
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80{{if eq .Type "int64"}} || n == 9{{end}} {
+					break
+				}
+			}
+		}
+  {{- else}}
+		for ai, l := 0, int(x); ai < l; ai++ {
+{{template "unmarshal-varint" .}}
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+  {{- if and (eq .Type "text") .Struct.Pkg.StrictText (or .Struct.Pkg.Lazy .Struct.Pkg.Iterate)}}
+			start := i
+  {{- end}}
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+  {{- if and (eq .Type "text") .Struct.Pkg.StrictText (or .Struct.Pkg.Lazy .Struct.Pkg.Iterate)}}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: {{.String}} element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+  {{- end}}
+		}
+  {{- end}}
+ {{- else}}
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+  {{- if and (eq .Type "text") .Struct.Pkg.StrictText (or .Struct.Pkg.Lazy .Struct.Pkg.Iterate)}}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: {{.String}} has malformed UTF-8 at byte %d", start))
+		}
+  {{- else}}
+		i += int(x)
+  {{- end}}
+ {{- end}}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else}}
+	if header == {{.Index}} {
+{{- template "skip-ref" .}}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{end}}`
+
+const goSkipRef = `
+{{- if .TypeList}}
+{{template "unmarshal-varint" .}}
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		}
+		for l := int(x); l > 0; l-- {
+{{- end}}
+		// nil receiver for no allocation, as there is no field to set
+{{- if or .Struct.Pkg.Lazy .Struct.Pkg.Iterate}}
+		n, err := (*{{.TypeNative}})(nil).{{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}unmarshalLen(data[i:], depth+1){{else}}UnmarshalLenDepth(data[i:], depth+1){{end}}
+{{- else}}
+		n, err := (*{{.TypeNative}})(nil).{{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}unmarshalFields(data[i:], ColferMask{}, depth+1){{else}}UnmarshalFieldsDepth(data[i:], {{.TypeRef.Pkg.NameNative}}.ColferMask{}, depth+1){{end}}
+{{- end}}
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+{{- if .TypeList}}
+		}
+{{- end}}`
+
+const goUnmarshalLen = `
+{{- if foreign .}}
+// UnmarshalLenDepth returns the number of bytes Unmarshal would read from data,
+// or the error it would return, without decoding, with depth as the nesting
+// level of o. Generated code from other packages uses it to skip nested data
+// structures, such that the ColferDepthMax limit holds.
+func (o *{{.NameNative}}) UnmarshalLenDepth(data []byte, depth int) (int, error) {
+	return o.unmarshalLen(data, depth)
+}
+{{end}}
+// UnmarshalLen returns the number of bytes unmarshal would read from data, or
+// the error it would return, without decoding. Validation is the same. The
+// depth is the nesting level of o.
+func (*{{.NameNative}}) unmarshalLen(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct {{.String}} exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+{{range .Fields}}{{template "skip-field" .}}{{end}}
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+`
+
+const goLazyCode = `
+{{- range .Fields}}{{if .TypeRef}}
+
+// Load{{.NameNative}} returns o.{{.NameNative}}, with the serial from Unmarshal, if any,
+// decoded on first use. Assignments to o.{{.NameNative}} take precedence over such
+// serial, except for nil, which needs Set{{.NameNative}} instead. Decoding continues
+// at the nesting level of o, and the serial remains pending when decoding fails.
+// The error return options are io.EOF, ColferError{{if .Struct.Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
+func (o *{{.Struct.NameNative}}) Load{{.NameNative}}() ({{if .TypeList}}[]{{end}}*{{.TypeNative}}, error) {
+	if data := o.lazy{{.NameNative}}; data != nil {
+		if o.{{.NameNative}} == nil {
+ {{- if .TypeList}}
+			x := uint(data[0])
+			i := 1
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			a := make([]*{{.TypeNative}}, x)
+			malloc := make([]{{.TypeNative}}, x)
+			for ai := range a {
+				v := &malloc[ai]
+				a[ai] = v
+
+				n, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}v.unmarshal(data[i:]{{if .Struct.Pkg.KeepUnknown}}, false{{end}}, o.lazyDepth+1){{else}}v.UnmarshalDepth(data[i:], o.lazyDepth+1){{end}}
+				if err != nil {
+					return nil, err
+				}
+				i += n
+			}
+			o.{{.NameNative}} = a
+ {{- else}}
+			v := new({{.TypeNative}})
+			if _, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}v.unmarshal(data{{if .Struct.Pkg.KeepUnknown}}, false{{end}}, o.lazyDepth+1){{else}}v.UnmarshalDepth(data, o.lazyDepth+1){{end}}; err != nil {
+				return nil, err
+			}
+			o.{{.NameNative}} = v
+ {{- end}}
+		}
+		o.lazy{{.NameNative}} = nil
+	}
+	return o.{{.NameNative}}, nil
+}
+
+// Set{{.NameNative}} assigns v to o.{{.NameNative}}, and it discards any serial from
+// Unmarshal pending decoding. A nil v clears the field as such.
+func (o *{{.Struct.NameNative}}) Set{{.NameNative}}(v {{if .TypeList}}[]{{end}}*{{.TypeNative}}) {
+	o.{{.NameNative}} = v
+	o.lazy{{.NameNative}} = nil
+}
+{{- end}}{{end}}
+{{- if .HasRef}}
+
+// Loaded returns o with all pending serials decoded, as a copy when needed.
+// Unmarshal validated the serials, so decoding fails only when the limits
+// changed since.
+func (o *{{.NameNative}}) loaded() (*{{.NameNative}}, error) {
+	c := *o
+	var pending bool
+{{- range .Fields}}{{if .TypeRef}}
+	if c.lazy{{.NameNative}} != nil {
+		pending = true
+		if _, err := c.Load{{.NameNative}}(); err != nil {
+			return nil, err
+		}
+	}
+{{- end}}{{end}}
+	if !pending {
+		return o, nil
+	}
+	return &c, nil
+}
+{{- end}}
+`
+
+const goEachCode = `
+{{- range $f := .Fields}}{{if and .TypeList .TypeRef}}
+
+// Each{{.NameNative}} decodes data as Colfer like Unmarshal does, yet it passes the
+// elements of field {{.Name}} to yield, one at a time, instead of setting
+// o.{{.NameNative}}. All other fields are set before the first call. The element is
+// reused, so yield must not retain it, nor any of its slices or nested data
+// structures. Iteration stops on the first error from yield, which is
+// returned as is.
+// The error return options are io.EOF, ColferError{{if .Struct.Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax.
+func (o *{{.Struct.NameNative}}) Each{{.NameNative}}(data []byte, yield func(*{{.TypeNative}}) error) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+	const depth = 1 // nesting level of o
+	var list []byte
+{{range .Struct.Fields}}{{if eq .Index $f.Index}}
+	if header == {{.Index}} {
+		start := i
+{{- template "skip-ref" .}}
+		list = data[start:i]
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+{{else}}{{template "unmarshal-field" (fresh .)}}{{end}}{{end}}
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		if list != nil {
+			// element count precedes the serials
+			x := uint(list[0])
+			li := 1
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint(list[li])
+					li++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			var v {{.TypeNative}}
+			for ; x != 0; x-- {
+				n, err := {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}v.unmarshalReuse(list[li:], depth+1){{else}}v.UnmarshalReuseDepth(list[li:], depth+1){{end}}
+				if err != nil {
+					return 0, err
+				}
+				li += n
+
+				if err := yield(&v); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+{{- end}}{{end}}
+`
+
+const goSQLCode = `
+// Value encodes o as Colfer conform driver.Valuer, with a nil o as SQL NULL.
+// The error return option is ColferMax{{if .Pkg.StrictText}} or ColferUTF8{{end}}.
+func (o *{{.NameNative}}) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes a column value as Colfer conform sql.Scanner. The source must be
+// either a []byte or a string with one serial exactly. SQL NULL resets o to its
+// zero value. Scan into a **{{.NameNative}} with database/sql for nil on NULL instead.
+// The error return options are io.EOF, ColferError, ColferTail{{if .Pkg.StrictText}}, ColferUTF8{{end}}, ColferDepth and ColferMax,
+// with an error of another type for other source types.
+func (o *{{.NameNative}}) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = {{.NameNative}}{}
+		return nil
+	case []byte:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct {{.String}} size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+{{- if .Pkg.Lazy}}
+		// the driver owns the memory, while pending serials refer to data
+		data = append([]byte(nil), v...)
+{{- else}}
+		data = v
+{{- end}}
+	case string:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct {{.String}} size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct {{.String}} can't scan SQL value of type %T", src)
+	}
+	return o.UnmarshalBinary(data)
+}`
+
+const goRegistryCode = `
+// ColferTypeNames has a constructor for each data structure in the package, by
+// qualified name from the schema.
+var ColferTypeNames = map[string]func() encoding.BinaryUnmarshaler{
+{{- range .Structs}}
+	"{{schemaName .}}": func() encoding.BinaryUnmarshaler { return new({{.NameNative}}) },
+{{- end}}
+}
+
+// ColferTypeIDs has a constructor for each data structure in the package, by
+// ColferTypeID. ColferEnvelope resolves types with this registry, which may get
+// the entries of other packages too.
+var ColferTypeIDs = map[uint32]func() encoding.BinaryUnmarshaler{
+{{- range .Structs}}
+	{{printf "%#08x" (typeID .)}}: func() encoding.BinaryUnmarshaler { return new({{.NameNative}}) },
+{{- end}}
+}
+
+// ColferMessage is a data structure with a type identifier.
+type ColferMessage interface {
+	encoding.BinaryMarshaler
+	// ColferTypeID returns the stable numeric identifier of the type.
+	ColferTypeID() uint32
+}
+
+// ColferUnknownType signals a type identifier without registration.
+type ColferUnknownType uint32
+
+// Error honors the error interface.
+func (id ColferUnknownType) Error() string {
+	return fmt.Sprintf("colfer: unknown type identifier %#08x", uint32(id))
+}
+
+// ColferEnvelope is a data structure with its type identifier, for streams of
+// mixed types. The encoding is Colfer conform a struct with TypeID as uint32
+// field 0 and Serial as binary field 1, which is the same for all packages.
+type ColferEnvelope struct {
+	// TypeID identifies the data structure in Serial.
+	TypeID uint32
+	// Serial is the data structure in Colfer encoding.
+	Serial []byte
+}
+
+// Wrap sets e to the serial of m. The error return is the one of the
+// MarshalBinary from m, if any.
+func (e *ColferEnvelope) Wrap(m ColferMessage) error {
+	serial, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	e.TypeID = m.ColferTypeID()
+	e.Serial = serial
+	return nil
+}
+
+// Unwrap decodes the serial into a new value from the constructor registered
+// in ColferTypeIDs. The error return option is ColferUnknownType, next to the
+// ones from the UnmarshalBinary of the type.
+func (e *ColferEnvelope) Unwrap() (encoding.BinaryUnmarshaler, error) {
+	f, ok := ColferTypeIDs[e.TypeID]
+	if !ok {
+		return nil, ColferUnknownType(e.TypeID)
+	}
+	m := f()
+	if err := m.UnmarshalBinary(e.Serial); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MarshalBinary encodes e as Colfer conform encoding.BinaryMarshaler.
+// The error return option is ColferMax.
+func (e *ColferEnvelope) MarshalBinary() (data []byte, err error) {
+	l := 1
+	if x := e.TypeID; x >= 1<<21 {
+		l += 5
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+	if x := len(e.Serial); x != 0 {
+		if x > ColferSizeMax {
+			return nil, ColferMax(fmt.Sprintf("colfer: envelope serial size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+	if l > ColferSizeMax {
+		return nil, ColferMax(fmt.Sprintf("colfer: envelope exceeds %d bytes", ColferSizeMax))
+	}
+
+	data = make([]byte, 0, l)
+	if x := e.TypeID; x >= 1<<21 {
+		data = append(data, 0|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(data[1:], x)
+	} else if x != 0 {
+		data = append(data, 0)
+		for x >= 0x80 {
+			data = append(data, byte(x|0x80))
+			x >>= 7
+		}
+		data = append(data, byte(x))
+	}
+	if x := uint(len(e.Serial)); x != 0 {
+		data = append(data, 1)
+		for x >= 0x80 {
+			data = append(data, byte(x|0x80))
+			x >>= 7
+		}
+		data = append(data, byte(x))
+		data = append(data, e.Serial...)
+	}
+	return append(data, 0x7f), nil
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, ColferError, ColferTail and ColferMax.
+func (e *ColferEnvelope) UnmarshalBinary(data []byte) error {
+	*e = ColferEnvelope{}
+	if len(data) == 0 {
+		return io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+{{template "unmarshal-varint"}}
+		e.TypeID = uint32(x)
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	} else if header == 0|0x80 {
+		if i+4 >= len(data) {
+			i += 4
+			goto eof
+		}
+		e.TypeID = intconv.Uint32(data[i:])
+		header = data[i+4]
+		i += 5
+	}
+
+	if header == 1 {
+{{template "unmarshal-varint"}}
+		if x > uint(ColferSizeMax) {
+			return ColferMax(fmt.Sprintf("colfer: envelope serial size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		e.Serial = make([]byte, int(x))
+		copy(e.Serial, data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		if i < len(data) {
+			return ColferTail(i)
+		}
+		return nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return ColferMax(fmt.Sprintf("colfer: envelope size exceeds %d bytes", ColferSizeMax))
+	}
+	return io.EOF
+}`
+
+const goRegistryMethods = `
+// ColferTypeName returns the qualified name from the schema.
+func (*{{.NameNative}}) ColferTypeName() string { return "{{schemaName .}}" }
+
+// ColferTypeID returns the stable numeric identifier, which is the 32-bit
+// FNV-1a hash of the qualified name from the schema.
+func (*{{.NameNative}}) ColferTypeID() uint32 { return {{printf "%#08x" (typeID .)}} }`
+
+const goDescriptorCode = `
+// ColferDescriptor is a static description of a data structure from the schema.
+// The type alias is identical in all generated packages, such that generic code
+// can declare the same.
+type ColferDescriptor = struct {
+	Name   string // qualified name
+	Docs   string // documentation text
+	Fields []ColferFieldDescriptor
+}
+
+// ColferFieldDescriptor is a static description of a field from the schema.
+// Desc holds the *ColferDescriptor of data structures, which is an interface
+// because type aliases can't refer to themselves.
+type ColferFieldDescriptor = struct {
+	Index int         // position in the schema
+	Name  string      // name from the schema
+	Type  string      // datatype, or the qualified name of a data structure
+	List  bool        // whether the field is a list of Type
+	Ref   bool        // whether Type is a data structure
+	Desc  interface{} // description of Type when Ref, or nil
+	Docs  string      // documentation text
+}
+{{range .Structs}}
+// colferDescriptor{{.NameNative}} is the static description of {{.NameNative}}.
+var colferDescriptor{{.NameNative}} = ColferDescriptor{
+	Name: "{{schemaName .}}",
+	Docs: {{printf "%q" (.DocText "")}},
+	Fields: []ColferFieldDescriptor{
+{{- range .Fields}}
+		{Index: {{.Index}}, Name: "{{.Name}}", Type: "{{if .TypeRef}}{{schemaName .TypeRef}}{{else}}{{.Type}}{{end}}", List: {{.TypeList}}, Ref: {{if .TypeRef}}true{{else}}false{{end}}, Docs: {{printf "%q" (.DocText "")}}},
+{{- end}}
+	},
+}
+{{- end}}
+{{- $refs := false}}{{range .Structs}}{{range .Fields}}{{if .TypeRef}}{{$refs = true}}{{end}}{{end}}{{end}}
+{{- if $refs}}
+
+// Descriptors refer to each other after initialization, as recursion is not
+// permitted in variable declarations.
+func init() {
+{{- range .Structs}}
+{{- range .Fields}}
+{{- if .TypeRef}}
+{{- if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}
+	colferDescriptor{{.Struct.NameNative}}.Fields[{{.Index}}].Desc = &colferDescriptor{{.TypeRef.NameNative}}
+{{- else}}
+	colferDescriptor{{.Struct.NameNative}}.Fields[{{.Index}}].Desc = (*{{.TypeNative}})(nil).ColferDescriptor()
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+}
+{{- end}}`
+
+const goDescriptorMethods = `
+// ColferDescriptor returns the static description of {{.NameNative}}, which must
+// not be modified.
+func (*{{.NameNative}}) ColferDescriptor() *ColferDescriptor {
+	return &colferDescriptor{{.NameNative}}
+}
+
+// ColferGet returns the value of a field by index, with the type of the field
+{{- if .Pkg.Lazy}}
+// as an interface. Data structures decode like their Load method does.
+{{- else}}
+// as an interface.
+{{- end}}
+func (o *{{.NameNative}}) ColferGet(index int) (interface{}, error) {
+	switch index {
+{{- range .Fields}}
+	case {{.Index}}:
+{{- if and .TypeRef .Struct.Pkg.Lazy}}
+		return o.Load{{.NameNative}}()
+{{- else}}
+		return o.{{.NameNative}}, nil
+{{- end}}
+{{- end}}
+	}
+	return nil, fmt.Errorf("colfer: struct {{.String}} has no field index %d", index)
+}
+
+// ColferSet assigns the value of a field by index, with the type of the field
+// as an interface. An untyped nil sets the zero value. Typed nils must match
+// the type of the field, like any other value.
+func (o *{{.NameNative}}) ColferSet(index int, value interface{}) error {
+	switch index {
+{{- range .Fields}}
+	case {{.Index}}:
+		var v {{if .TypeList}}[]{{end}}{{if .TypeRef}}*{{end}}{{.TypeNative}}
+		if value != nil {
+			var ok bool
+			v, ok = value.({{if .TypeList}}[]{{end}}{{if .TypeRef}}*{{end}}{{.TypeNative}})
+			if !ok {
+				return fmt.Errorf("colfer: field {{.String}} can't take a value of type %T", value)
+			}
+		}
+		o.{{.NameNative}} = v
+{{- if and .TypeRef .Struct.Pkg.Lazy}}
+		o.lazy{{.NameNative}} = nil
+{{- end}}
+		return nil
+{{- end}}
+	}
+	return fmt.Errorf("colfer: struct {{.String}} has no field index %d", index)
+}`
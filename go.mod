@@ -3,7 +3,6 @@ module github.com/pascaldekloe/colfer
 go 1.14
 
 require (
-	github.com/dvyukov/go-fuzz v0.0.0-20200318091601-be3528f3a813 // indirect
 	github.com/gogo/protobuf v1.3.1
 	github.com/google/flatbuffers v1.12.0
 	github.com/pascaldekloe/name v1.0.0
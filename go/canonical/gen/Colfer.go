@@ -0,0 +1,3005 @@
+// Package gen tests all field mapping options.
+package gen
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file test.colf.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var intconv = binary.BigEndian
+
+// Colfer configuration attributes
+var (
+	// ColferSizeMax is the upper limit for serial byte sizes.
+	ColferSizeMax = 16 * 1024 * 1024
+	// ColferListMax is the upper limit for the number of elements in a list.
+	ColferListMax = 64 * 1024
+	// ColferDepthMax is the upper limit for the nesting level of data
+	// structures, with 1 for the top-level. Data structures from another
+	// package count from 1 again, against the limit of their own package.
+	ColferDepthMax = 100
+)
+
+// Schema fingerprints change with any change to the fields of the respective
+// data structure, including the data structures referred to.
+const (
+	// ColferFingerprintO is the schema digest of O.
+	ColferFingerprintO = "98768e7cc2fcda72"
+	// ColferFingerprintDromedaryCase is the schema digest of DromedaryCase.
+	ColferFingerprintDromedaryCase = "f21325a195f2e6e5"
+	// ColferFingerprintEmbedO is the schema digest of EmbedO.
+	ColferFingerprintEmbedO = "1ea394cc8643da1b"
+)
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferDepth signals an upper limit breach of the nesting level.
+type ColferDepth string
+
+// Error honors the error interface.
+func (m ColferDepth) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// ColferUTF8 signals malformed UTF-8 in text.
+type ColferUTF8 string
+
+// Error honors the error interface.
+func (m ColferUTF8) Error() string { return string(m) }
+
+// ColferCanonical signals a non-canonical encoding as a byte index.
+type ColferCanonical int
+
+// Error honors the error interface.
+func (i ColferCanonical) Error() string {
+	return fmt.Sprintf("colfer: non-canonical encoding at byte %d", i)
+}
+
+// O contains all supported data types.
+type O struct {
+	// B tests booleans.
+	B bool
+	// U32 tests unsigned 32-bit integers.
+	U32 uint32
+	// U64 tests unsigned 64-bit integers.
+	U64 uint64
+	// I32 tests signed 32-bit integers.
+	I32 int32
+	// I64 tests signed 64-bit integers.
+	I64 int64
+	// F32 tests 32-bit floating points.
+	F32 float32
+	// F64 tests 64-bit floating points.
+	F64 float64
+	// T tests timestamps.
+	T time.Time
+	// S tests text.
+	S string
+	// A tests binaries.
+	A []byte
+	// O tests nested data structures.
+	O *O
+	// Os tests data structure lists.
+	Os []*O
+	// Ss tests text lists.
+	Ss []string
+	// As tests binary lists.
+	As [][]byte
+	// U8 tests unsigned 8-bit integers.
+	U8 uint8
+	// U16 tests unsigned 16-bit integers.
+	U16 uint16
+	// F32s tests 32-bit floating point lists.
+	F32s []float32
+	// F64s tests 64-bit floating point lists.
+	F64s []float64
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+// All nil entries in o.Os will be replaced with a new value.
+func (o *O) MarshalTo(buf []byte) int {
+	var i int
+
+	if o.B {
+		buf[i] = 0
+		i++
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		buf[i] = 1 | 0x80
+		intconv.PutUint32(buf[i+1:], x)
+		i += 5
+	} else if x != 0 {
+		buf[i] = 1
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		buf[i] = 2 | 0x80
+		intconv.PutUint64(buf[i+1:], x)
+		i += 9
+	} else if x != 0 {
+		buf[i] = 2
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf[i] = 3
+		} else {
+			x = ^x + 1
+			buf[i] = 3 | 0x80
+		}
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf[i] = 4
+		} else {
+			x = ^x + 1
+			buf[i] = 4 | 0x80
+		}
+		i++
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.F32; v != 0 {
+		buf[i] = 5
+		if v != v {
+			intconv.PutUint32(buf[i+1:], 0x7fc00000)
+		} else {
+			intconv.PutUint32(buf[i+1:], math.Float32bits(v))
+		}
+		i += 5
+	}
+
+	if v := o.F64; v != 0 {
+		buf[i] = 6
+		if v != v {
+			intconv.PutUint64(buf[i+1:], 0x7ff8000000000000)
+		} else {
+			intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+		}
+		i += 9
+	}
+
+	if v := o.T; !v.IsZero() && (v.Unix() != 0 || v.Nanosecond() != 0) {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf[i] = 7
+			intconv.PutUint32(buf[i+1:], uint32(s))
+			i += 5
+		} else {
+			buf[i] = 7 | 0x80
+			intconv.PutUint64(buf[i+1:], s)
+			i += 9
+		}
+		intconv.PutUint32(buf[i:], ns)
+		i += 4
+	}
+
+	if l := len(o.S); l != 0 {
+		buf[i] = 8
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.S)
+	}
+
+	if l := len(o.A); l != 0 {
+		buf[i] = 9
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.A)
+	}
+
+	if v := o.O; v != nil {
+		buf[i] = 10
+		i++
+		i += v.MarshalTo(buf[i:])
+	}
+
+	if l := len(o.Os); l != 0 {
+		buf[i] = 11
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi, v := range o.Os {
+			if v == nil {
+				v = new(O)
+				o.Os[vi] = v
+			}
+			i += v.MarshalTo(buf[i:])
+		}
+	}
+
+	if l := len(o.Ss); l != 0 {
+		buf[i] = 12
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Ss {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		buf[i] = 13
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.As {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf[i] = 14
+		i++
+		buf[i] = x
+		i++
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf[i] = 15
+		i++
+		buf[i] = byte(x >> 8)
+		i++
+		buf[i] = byte(x)
+		i++
+	} else if x != 0 {
+		buf[i] = 15 | 0x80
+		i++
+		buf[i] = byte(x)
+		i++
+	}
+
+	if l := len(o.F32s); l != 0 {
+		buf[i] = 16
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.F32s {
+			if v != v {
+				intconv.PutUint32(buf[i:], 0x7fc00000)
+			} else {
+				intconv.PutUint32(buf[i:], math.Float32bits(v))
+			}
+			i += 4
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		buf[i] = 17
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.F64s {
+			if v != v {
+				intconv.PutUint64(buf[i:], 0x7ff8000000000000)
+			} else {
+				intconv.PutUint64(buf[i:], math.Float64bits(v))
+			}
+			i += 8
+		}
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax or ColferUTF8.
+func (o *O) MarshalLen() (int, error) {
+	l := 1
+
+	if o.B {
+		l++
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		l += 5
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		l += 9
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.I64; v != 0 {
+		l += 2
+		x := uint64(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			x >>= 7
+			l++
+		}
+	}
+
+	if o.F32 != 0 {
+		l += 5
+	}
+
+	if o.F64 != 0 {
+		l += 9
+	}
+
+	if v := o.T; !v.IsZero() && (v.Unix() != 0 || v.Nanosecond() != 0) {
+		if s := uint64(v.Unix()); s < 1<<32 {
+			l += 9
+		} else {
+			l += 13
+		}
+	}
+
+	if x := len(o.S); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.s exceeds %d bytes", ColferSizeMax))
+		}
+		if !utf8.ValidString(o.S) {
+			return 0, ColferUTF8("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.s has malformed UTF-8")
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.A); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.a exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.O; v != nil {
+		vl, err := v.MarshalLen()
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	}
+
+	if x := len(o.Os); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.os exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, v := range o.Os {
+			if v == nil {
+				l++
+				continue
+			}
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if l > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.Ss); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.ss exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for ai, a := range o.Ss {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.ss exceeds %d bytes", ColferSizeMax))
+			}
+			if !utf8.ValidString(a) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.ss element %d has malformed UTF-8", ai))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.As); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.as exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.As {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.as exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		l += 2
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		l += 3
+	} else if x != 0 {
+		l += 2
+	}
+
+	if x := len(o.F32s); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.f32s exceeds %d elements", ColferListMax))
+		}
+		for l += 2 + x*4; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.F64s); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.o.f64s exceeds %d elements", ColferListMax))
+		}
+		for l += 2 + x*8; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.o exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in o.Os will be replaced with a new value.
+// The error return option is ColferMax or ColferUTF8.
+func (o *O) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// All nil entries in o.Os will be replaced with a new value.
+// The error return option is ColferMax or ColferUTF8, in which case dst is returned as is.
+func (o *O) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The depth is the nesting level of o.
+func (o *O) unmarshal(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/canonical/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.s has malformed UTF-8 at byte %d", start))
+		}
+		o.S = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		// no allocation before the data is there
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		v := make([]byte, int(x))
+		copy(v, data[start:i])
+		o.A = v
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		o.O = new(O)
+		n, err := o.O.unmarshal(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+
+		l := int(x)
+		a := make([]*O, l)
+		malloc := make([]O, l)
+		for ai := range a {
+			v := &malloc[ai]
+			a[ai] = v
+
+			// reserve one byte for each element pending
+			n, err := v.unmarshal(data[i:len(data)-(l-1-ai)], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Os = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := make([]string, int(x))
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := make([][]byte, int(x))
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			// no allocation before the data is there
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			v := make([]byte, int(x))
+			copy(v, data[start:i])
+			a[ai] = v
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float32, l)
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float64, l)
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *O) Reset() {
+	*o = O{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *O) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/canonical/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	prev := *o
+	*o = O{}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.s has malformed UTF-8 at byte %d", start))
+		}
+		o.S = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		// no allocation before the data is there
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		v := prev.A
+		if v != nil && cap(v) >= int(x) {
+			v = v[:x]
+		} else {
+			v = make([]byte, int(x))
+		}
+		copy(v, data[start:i])
+		o.A = v
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		v := prev.O
+		if v == nil {
+			v = new(O)
+		}
+		o.O = v
+		n, err := v.unmarshalReuse(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+
+		l := int(x)
+		a := prev.Os
+		if a == nil {
+			a = make([]*O, l)
+		} else if cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = append(a[:cap(a)], make([]*O, l-cap(a))...)
+		}
+		for ai, v := range a {
+			if v == nil {
+				v = new(O)
+				a[ai] = v
+			}
+
+			// reserve one byte for each element pending
+			n, err := v.unmarshalReuse(data[i:len(data)-(l-1-ai)], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Os = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := prev.Ss
+		if a != nil && cap(a) >= int(x) {
+			a = a[:x]
+		} else {
+			a = make([]string, int(x))
+		}
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := prev.As
+		if a == nil {
+			a = make([][]byte, int(x))
+		} else if cap(a) >= int(x) {
+			a = a[:x]
+		} else {
+			a = append(a[:cap(a)], make([][]byte, int(x)-cap(a))...)
+		}
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			// no allocation before the data is there
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			v := a[ai]
+			if v != nil && cap(v) >= int(x) {
+				v = v[:x]
+			} else {
+				v = make([]byte, int(x))
+			}
+			copy(v, data[start:i])
+			a[ai] = v
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := prev.F32s
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]float32, l)
+		}
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := prev.F64s
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]float64, l)
+		}
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// UnmarshalCanonical decodes data as Colfer like UnmarshalBinary, yet it
+// rejects any serial other than the one MarshalBinary produces for the result.
+// Equal values have identical serials as such. The check marshals the result
+// for comparison, which costs about as much as the decoding.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferCanonical, ColferDepth and ColferMax.
+func (o *O) UnmarshalCanonical(data []byte) error {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	serial, err := o.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for i := range serial {
+		if i >= len(data) || serial[i] != data[i] {
+			return ColferCanonical(i)
+		}
+	}
+	if len(serial) != len(data) {
+		return ColferCanonical(len(serial))
+	}
+	return nil
+}
+
+// Canonicalize decodes data as Colfer like UnmarshalBinary, and it returns the
+// canonical serial of the result, which is what MarshalBinary produces.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) Canonicalize(data []byte) ([]byte, error) {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return o.MarshalBinary()
+}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *O) Equal(other *O) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.B != other.B {
+		return false
+	}
+
+	if o.U32 != other.U32 {
+		return false
+	}
+
+	if o.U64 != other.U64 {
+		return false
+	}
+
+	if o.I32 != other.I32 {
+		return false
+	}
+
+	if o.I64 != other.I64 {
+		return false
+	}
+
+	if v, w := o.F32, other.F32; v != w && (v == v || w == w) {
+		return false
+	}
+
+	if v, w := o.F64, other.F64; v != w && (v == v || w == w) {
+		return false
+	}
+
+	if v, w := o.T, other.T; !v.Equal(w) {
+		// the Unix epoch has no serial, just like the zero value
+		if !(v.IsZero() || v.Equal(time.Unix(0, 0))) || !(w.IsZero() || w.Equal(time.Unix(0, 0))) {
+			return false
+		}
+	}
+
+	if o.S != other.S {
+		return false
+	}
+
+	if !bytes.Equal(o.A, other.A) {
+		return false
+	}
+
+	if !o.O.Equal(other.O) {
+		return false
+	}
+
+	if len(o.Os) != len(other.Os) {
+		return false
+	}
+	for i, v := range o.Os {
+		w := other.Os[i]
+		if v == nil {
+			v = new(O)
+		}
+		if w == nil {
+			w = new(O)
+		}
+		if !v.Equal(w) {
+			return false
+		}
+	}
+
+	if len(o.Ss) != len(other.Ss) {
+		return false
+	}
+	for i, v := range o.Ss {
+		if v != other.Ss[i] {
+			return false
+		}
+	}
+
+	if len(o.As) != len(other.As) {
+		return false
+	}
+	for i, v := range o.As {
+		if !bytes.Equal(v, other.As[i]) {
+			return false
+		}
+	}
+
+	if o.U8 != other.U8 {
+		return false
+	}
+
+	if o.U16 != other.U16 {
+		return false
+	}
+
+	if len(o.F32s) != len(other.F32s) {
+		return false
+	}
+	for i, v := range o.F32s {
+		if w := other.F32s[i]; v != w && (v == v || w == w) {
+			return false
+		}
+	}
+
+	if len(o.F64s) != len(other.F64s) {
+		return false
+	}
+	for i, v := range o.F64s {
+		if w := other.F64s[i]; v != w && (v == v || w == w) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *O) Clone() *O {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if o.A != nil {
+		c.A = append(make([]byte, 0, len(o.A)), o.A...)
+	}
+
+	c.O = o.O.Clone()
+
+	if o.Os != nil {
+		c.Os = make([]*O, len(o.Os))
+		for i, v := range o.Os {
+			c.Os[i] = v.Clone()
+		}
+	}
+
+	if o.Ss != nil {
+		c.Ss = make([]string, len(o.Ss))
+		copy(c.Ss, o.Ss)
+	}
+
+	if o.As != nil {
+		c.As = make([][]byte, len(o.As))
+		for i, v := range o.As {
+			if v != nil {
+				c.As[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+	}
+
+	if o.F32s != nil {
+		c.F32s = make([]float32, len(o.F32s))
+		copy(c.F32s, o.F32s)
+	}
+
+	if o.F64s != nil {
+		c.F64s = make([]float64, len(o.F64s))
+		copy(c.F64s, o.F64s)
+	}
+
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *O) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	var fields []string
+
+	if o.B {
+		fields = append(fields, "b: true")
+	}
+
+	if v := o.U32; v != 0 {
+		fields = append(fields, fmt.Sprint("u32: ", v))
+	}
+
+	if v := o.U64; v != 0 {
+		fields = append(fields, fmt.Sprint("u64: ", v))
+	}
+
+	if v := o.I32; v != 0 {
+		fields = append(fields, fmt.Sprint("i32: ", v))
+	}
+
+	if v := o.I64; v != 0 {
+		fields = append(fields, fmt.Sprint("i64: ", v))
+	}
+
+	if v := o.F32; v != 0 {
+		fields = append(fields, fmt.Sprint("f32: ", v))
+	}
+
+	if v := o.F64; v != 0 {
+		fields = append(fields, fmt.Sprint("f64: ", v))
+	}
+
+	if v := o.T; !v.IsZero() {
+		fields = append(fields, "t: "+v.UTC().Format(time.RFC3339Nano))
+	}
+
+	if v := o.S; v != "" {
+		fields = append(fields, "s: "+strconv.Quote(v))
+	}
+
+	if v := o.A; len(v) != 0 {
+		fields = append(fields, fmt.Sprintf("a: 0x%x", v))
+	}
+
+	if v := o.O; v != nil {
+		fields = append(fields, "o: "+v.String())
+	}
+
+	if len(o.Os) != 0 {
+		a := make([]string, len(o.Os))
+		for i, v := range o.Os {
+			a[i] = v.String()
+		}
+		fields = append(fields, "os: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if len(o.Ss) != 0 {
+		a := make([]string, len(o.Ss))
+		for i, v := range o.Ss {
+			a[i] = strconv.Quote(v)
+		}
+		fields = append(fields, "ss: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if len(o.As) != 0 {
+		a := make([]string, len(o.As))
+		for i, v := range o.As {
+			a[i] = fmt.Sprintf("0x%x", v)
+		}
+		fields = append(fields, "as: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if v := o.U8; v != 0 {
+		fields = append(fields, fmt.Sprint("u8: ", v))
+	}
+
+	if v := o.U16; v != 0 {
+		fields = append(fields, fmt.Sprint("u16: ", v))
+	}
+
+	if len(o.F32s) != 0 {
+		a := make([]string, len(o.F32s))
+		for i, v := range o.F32s {
+			a[i] = fmt.Sprint(v)
+		}
+		fields = append(fields, "f32s: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if len(o.F64s) != 0 {
+		a := make([]string, len(o.F64s))
+		for i, v := range o.F64s {
+			a[i] = fmt.Sprint(v)
+		}
+		fields = append(fields, "f64s: ["+strings.Join(a, ", ")+"]")
+	}
+
+	return "gen.o{" + strings.Join(fields, ", ") + "}"
+}
+
+// DromedaryCase oposes name casings.
+type DromedaryCase struct {
+	PascalCase string `xml:"pascal-case" json:"pascal_case,omitempty"`
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *DromedaryCase) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.PascalCase); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.PascalCase)
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax or ColferUTF8.
+func (o *DromedaryCase) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.PascalCase); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase.PascalCase exceeds %d bytes", ColferSizeMax))
+		}
+		if !utf8.ValidString(o.PascalCase) {
+			return 0, ColferUTF8("colfer: field github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase.PascalCase has malformed UTF-8")
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is ColferMax or ColferUTF8.
+func (o *DromedaryCase) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// The error return option is ColferMax or ColferUTF8, in which case dst is returned as is.
+func (o *DromedaryCase) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The depth is the nesting level of o.
+func (o *DromedaryCase) unmarshal(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+		}
+		o.PascalCase = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *DromedaryCase) Reset() {
+	*o = DromedaryCase{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *DromedaryCase) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	*o = DromedaryCase{}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+		}
+		o.PascalCase = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// UnmarshalCanonical decodes data as Colfer like UnmarshalBinary, yet it
+// rejects any serial other than the one MarshalBinary produces for the result.
+// Equal values have identical serials as such. The check marshals the result
+// for comparison, which costs about as much as the decoding.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferCanonical, ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalCanonical(data []byte) error {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	serial, err := o.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for i := range serial {
+		if i >= len(data) || serial[i] != data[i] {
+			return ColferCanonical(i)
+		}
+	}
+	if len(serial) != len(data) {
+		return ColferCanonical(len(serial))
+	}
+	return nil
+}
+
+// Canonicalize decodes data as Colfer like UnmarshalBinary, and it returns the
+// canonical serial of the result, which is what MarshalBinary produces.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) Canonicalize(data []byte) ([]byte, error) {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return o.MarshalBinary()
+}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *DromedaryCase) Equal(other *DromedaryCase) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.PascalCase != other.PascalCase {
+		return false
+	}
+
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *DromedaryCase) Clone() *DromedaryCase {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *DromedaryCase) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	var fields []string
+
+	if v := o.PascalCase; v != "" {
+		fields = append(fields, "PascalCase: "+strconv.Quote(v))
+	}
+
+	return "gen.dromedaryCase{" + strings.Join(fields, ", ") + "}"
+}
+
+// EmbedO has an inner object only.
+// Covers regression of issue #66.
+type EmbedO struct {
+	Inner *O
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *EmbedO) MarshalTo(buf []byte) int {
+	var i int
+
+	if v := o.Inner; v != nil {
+		buf[i] = 0
+		i++
+		i += v.MarshalTo(buf[i:])
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax or ColferUTF8.
+func (o *EmbedO) MarshalLen() (int, error) {
+	l := 1
+
+	if v := o.Inner; v != nil {
+		vl, err := v.MarshalLen()
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	}
+
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is ColferMax or ColferUTF8.
+func (o *EmbedO) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// The error return option is ColferMax or ColferUTF8, in which case dst is returned as is.
+func (o *EmbedO) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The depth is the nesting level of o.
+func (o *EmbedO) unmarshal(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		o.Inner = new(O)
+		n, err := o.Inner.unmarshal(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *EmbedO) Reset() {
+	*o = EmbedO{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *EmbedO) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	prev := *o
+	*o = EmbedO{}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		v := prev.Inner
+		if v == nil {
+			v = new(O)
+		}
+		o.Inner = v
+		n, err := v.unmarshalReuse(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/canonical/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// UnmarshalCanonical decodes data as Colfer like UnmarshalBinary, yet it
+// rejects any serial other than the one MarshalBinary produces for the result.
+// Equal values have identical serials as such. The check marshals the result
+// for comparison, which costs about as much as the decoding.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferCanonical, ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalCanonical(data []byte) error {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	serial, err := o.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for i := range serial {
+		if i >= len(data) || serial[i] != data[i] {
+			return ColferCanonical(i)
+		}
+	}
+	if len(serial) != len(data) {
+		return ColferCanonical(len(serial))
+	}
+	return nil
+}
+
+// Canonicalize decodes data as Colfer like UnmarshalBinary, and it returns the
+// canonical serial of the result, which is what MarshalBinary produces.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) Canonicalize(data []byte) ([]byte, error) {
+	if err := o.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return o.MarshalBinary()
+}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *EmbedO) Equal(other *EmbedO) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if !o.Inner.Equal(other.Inner) {
+		return false
+	}
+
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *EmbedO) Clone() *EmbedO {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	c.Inner = o.Inner.Clone()
+
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *EmbedO) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	var fields []string
+
+	if v := o.Inner; v != nil {
+		fields = append(fields, "inner: "+v.String())
+	}
+
+	return "gen.EmbedO{" + strings.Join(fields, ", ") + "}"
+}
@@ -0,0 +1,117 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestUnmarshalCanonical(t *testing.T) {
+	f := func(o *O) bool {
+		data, err := o.MarshalBinary()
+		if err != nil {
+			t.Fatal("marshal error:", err)
+		}
+		if err := new(O).UnmarshalCanonical(data); err != nil {
+			t.Errorf("0x%x: got error %v", data, err)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	golden := []struct {
+		serial    string
+		canonical string
+		index     int
+	}{
+		// uint32 in fixed width
+		{"81000000017f", "01017f", 0},
+		// uint32 in overlong varint
+		{"0181007f", "01017f", 1},
+		// uint32 zero
+		{"01007f", "7f", 0},
+		// uint16 in 2 bytes
+		{"0f00ff7f", "8fff7f", 0},
+		// int32 negative zero
+		{"83007f", "7f", 0},
+		// float32 negative zero
+		{"05800000007f", "7f", 0},
+		// float32 NaN with payload
+		{"057fc000017f", "057fc000007f", 4},
+		// timestamp with nanoseconds overflow
+		{"07000000003b9aca007f", "0700000001000000007f", 4},
+		// timestamp in 12 bytes
+		{"870000000000000001000000007f", "0700000001000000007f", 0},
+		// timestamp at Unix epoch
+		{"0700000000000000007f", "7f", 0},
+		// empty text
+		{"08007f", "7f", 0},
+		// empty list
+		{"0b007f", "7f", 0},
+		// nested uint32 in fixed width
+		{"0a81000000017f7f", "0a01017f7f", 1},
+	}
+	for _, gold := range golden {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = new(O).UnmarshalCanonical(data)
+		if want := ColferCanonical(gold.index); err != want {
+			t.Errorf("0x%s: got error %v, want %v", gold.serial, err, want)
+		}
+
+		got, err := new(O).Canonicalize(data)
+		if err != nil {
+			t.Errorf("0x%s: canonicalize error: %s", gold.serial, err)
+			continue
+		}
+		if hex.EncodeToString(got) != gold.canonical {
+			t.Errorf("0x%s: canonicalized to 0x%x, want 0x%s", gold.serial, got, gold.canonical)
+		}
+	}
+
+	if err := new(O).UnmarshalCanonical([]byte{0x7f, 0x7f}); err != ColferTail(1) {
+		t.Errorf("got error %v, want ColferTail(1)", err)
+	}
+	// data after the serial, same in all languages
+	data := []byte{0x01, 0x01, 0x7f, 0x00}
+	if err := new(O).UnmarshalCanonical(data); err != ColferTail(3) {
+		t.Errorf("0x%x: got error %v, want ColferTail(3)", data, err)
+	}
+	if _, err := new(O).Canonicalize(data); err != ColferTail(3) {
+		t.Errorf("0x%x: got canonicalize error %v, want ColferTail(3)", data, err)
+	}
+}
+
+func TestEqualCanonical(t *testing.T) {
+	a, b := &O{T: time.Unix(0, 0)}, &O{}
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Error("Unix epoch not equal to zero value")
+	}
+	if data, err := a.MarshalBinary(); err != nil || string(data) != "\x7f" {
+		t.Errorf("got Unix epoch serial 0x%x, %v; want 0x7f", data, err)
+	}
+}
+
+func FuzzCanonicalize(f *testing.F) {
+	f.Add([]byte{0x81, 0, 0, 0, 1, 0x7f})
+	f.Add([]byte{0x0a, 0x05, 0x7f, 0xc0, 0, 1, 0x7f, 0x7f})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		serial, err := new(O).Canonicalize(data)
+		if err != nil {
+			return
+		}
+		if err := new(O).UnmarshalCanonical(serial); err != nil {
+			t.Errorf("0x%x canonicalized to 0x%x, which got error %v", data, serial, err)
+		}
+		if new(O).UnmarshalCanonical(data) == nil && !bytes.Equal(data, serial) {
+			t.Errorf("canonical 0x%x canonicalized to 0x%x", data, serial)
+		}
+	})
+}
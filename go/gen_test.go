@@ -3,10 +3,13 @@ package gen
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"math"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -93,6 +96,49 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestMarshalAppend(t *testing.T) {
+	var buf []byte
+	var want string
+	for _, gold := range newGoldenCases() {
+		var err error
+		buf, err = gold.object.MarshalAppend(buf)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		want += gold.serial
+	}
+	if got := hex.EncodeToString(buf); got != want {
+		t.Errorf("got 0x%s, want 0x%s", got, want)
+	}
+
+	o := &O{S: "reuse", Os: []*O{{B: true}}}
+	buf = buf[:0]
+	allocs := testing.AllocsPerRun(10, func() {
+		buf, _ = o.MarshalAppend(buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("got %f allocations with sufficient capacity", allocs)
+	}
+}
+
+func TestMarshalAppendMax(t *testing.T) {
+	orig := ColferSizeMax
+	defer func() {
+		ColferSizeMax = orig
+	}()
+	ColferSizeMax = 4
+
+	prefix := []byte{1, 2, 3}
+	got, err := (&O{S: "overflow"}).MarshalAppend(prefix)
+	if _, ok := err.(ColferMax); !ok {
+		t.Fatalf("got error %v, want a ColferMax", err)
+	}
+	if !bytes.Equal(got, prefix) {
+		t.Errorf("got 0x%x, want the original 0x%x", got, prefix)
+	}
+}
+
 func TestUnmarshal(t *testing.T) {
 	for _, gold := range newGoldenCases() {
 		data, err := hex.DecodeString(gold.serial)
@@ -120,6 +166,106 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+func TestUnmarshalReuse(t *testing.T) {
+	dirty := O{B: true, U32: 7, S: "stale", A: []byte("stale"), O: &O{S: "stale"},
+		Os: []*O{{U8: 1}, {U8: 2}, {U8: 3}}, Ss: []string{"stale"},
+		As: [][]byte{[]byte("stale")}, F32s: []float32{1, 2, 3}, F64s: []float64{4}}
+	staleSerial, err := dirty.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got O
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := got.UnmarshalReuse(staleSerial); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := got.UnmarshalReuse(data); err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		} else if n != len(data) {
+			t.Errorf("0x%s: got %d bytes read, want %d", gold.serial, n, len(data))
+		}
+
+		// work around NaN != NaN
+		a, b := got, gold.object
+		if math.IsNaN(float64(a.F32)) && math.IsNaN(float64(b.F32)) {
+			a.F32, b.F32 = 0, 0
+		}
+		if math.IsNaN(a.F64) && math.IsNaN(b.F64) {
+			a.F64, b.F64 = 0, 0
+		}
+		// compare content only as capacity is reused
+		if ad, err := a.MarshalBinary(); err != nil {
+			t.Error(err)
+		} else if bd, err := b.MarshalBinary(); err != nil {
+			t.Error(err)
+		} else if !bytes.Equal(ad, bd) {
+			t.Errorf("0x%s: got %+v, want %+v", gold.serial, got, gold.object)
+		}
+	}
+}
+
+func TestUnmarshalReuseAllocs(t *testing.T) {
+	data, err := (&O{A: []byte{1, 2}, O: &O{B: true}, Os: []*O{{}, {U8: 1}},
+		As: [][]byte{{3}}, F32s: []float32{4, 5}, F64s: []float64{6}}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o O
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := o.UnmarshalReuse(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("got %f allocations on reuse", allocs)
+	}
+}
+
+func TestUnmarshalReuseEmpty(t *testing.T) {
+	// zero-length binary and lists
+	data, err := hex.DecodeString("09000b000c000d0010001100" + "7f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := O{A: []byte{}, Os: []*O{}, Ss: []string{}, As: [][]byte{},
+		F32s: []float32{}, F64s: []float64{}}
+
+	var fresh O
+	if _, err := fresh.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fresh, want) {
+		t.Errorf("unmarshal got %#v, want %#v", fresh, want)
+	}
+
+	for _, prev := range []O{{}, {A: []byte("stale"), Os: []*O{{}}, Ss: []string{"stale"},
+		As: [][]byte{[]byte("stale")}, F32s: []float32{1}, F64s: []float64{2}}} {
+		got := prev
+		if _, err := got.UnmarshalReuse(data); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("reuse of %+v got %#v, want %#v", prev, got, want)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	o := O{B: true, S: "x", O: &O{}, Os: []*O{{}}}
+	o.Reset()
+	if !reflect.DeepEqual(o, O{}) {
+		t.Errorf("got %+v after reset", o)
+	}
+}
+
 func TestUnmarshalEOF(t *testing.T) {
 	for _, gold := range newGoldenCases() {
 		data, err := hex.DecodeString(gold.serial)
@@ -132,6 +278,9 @@ func TestUnmarshalEOF(t *testing.T) {
 			if _, err := new(O).Unmarshal(incomplete); err != io.EOF {
 				t.Errorf("0x%s: got error %T: %q", hex.EncodeToString(incomplete), err, err)
 			}
+			if _, err := gold.object.UnmarshalReuse(incomplete); err != io.EOF {
+				t.Errorf("0x%s: reuse got error %T: %q", hex.EncodeToString(incomplete), err, err)
+			}
 		}
 	}
 }
@@ -175,6 +324,321 @@ func TestUnmarshalSizeMax(t *testing.T) {
 	}
 }
 
+func TestUnmarshalDepthMax(t *testing.T) {
+	orig := ColferDepthMax
+	defer func() {
+		ColferDepthMax = orig
+	}()
+	ColferDepthMax = 3
+
+	decoders := map[string]func(data []byte) error{
+		"Unmarshal": func(data []byte) error {
+			_, err := new(O).Unmarshal(data)
+			return err
+		},
+		"UnmarshalReuse": func(data []byte) error {
+			_, err := new(O).UnmarshalReuse(data)
+			return err
+		},
+		"UnmarshalBinary": func(data []byte) error {
+			return new(O).UnmarshalBinary(data)
+		},
+		"UnmarshalFields": func(data []byte) error {
+			_, err := new(O).UnmarshalFields(data, ColferMask{}.With(10, 11))
+			return err
+		},
+		"UnmarshalFields skip": func(data []byte) error {
+			_, err := new(O).UnmarshalFields(data, ColferMask{})
+			return err
+		},
+		"UnmarshalLen": func(data []byte) error {
+			_, err := (*O)(nil).unmarshalLen(data, 1)
+			return err
+		},
+		"EachOs": func(data []byte) error {
+			_, err := new(O).EachOs(data, func(*O) error { return nil })
+			return err
+		},
+	}
+
+	for _, gold := range []struct {
+		serial string
+		depth  int
+	}{
+		{"0a0a7f7f7f", 3},
+		{"0a0a0a7f7f7f7f", 4},
+		{"0b010b017f7f7f", 3},
+		{"0b010b010b017f7f7f7f", 4},
+		{"0a0b017f7f7f", 3},
+		{"0b010a0a7f7f7f7f", 4},
+	} {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, decode := range decoders {
+			err := decode(data)
+			if gold.depth <= ColferDepthMax {
+				if err != nil {
+					t.Errorf("%s 0x%s: got error %q with ColferDepthMax=%d", name, gold.serial, err, ColferDepthMax)
+				}
+			} else if _, ok := err.(ColferDepth); !ok {
+				t.Errorf("%s 0x%s: got error %T with ColferDepthMax=%d: %v", name, gold.serial, err, ColferDepthMax, err)
+			} else if !strings.HasPrefix(err.Error(), "colfer: depth limit: ") {
+				t.Errorf("%s 0x%s: got error %q, want the depth limit prefix of all languages", name, gold.serial, err)
+			}
+		}
+	}
+
+	for _, gold := range []struct {
+		json  string
+		depth int
+	}{
+		{`{"o":{"o":{}}}`, 3},
+		{`{"o":{"o":{"o":{}}}}`, 4},
+		{`{"os":[{"os":[{}]}]}`, 3},
+		{`{"os":[{},{"o":{"o":{}}}]}`, 4},
+		{`{"o":{},"os":[{"o":{}},{"os":[]}]}`, 3},
+	} {
+		err := new(O).UnmarshalJSON([]byte(gold.json))
+		if gold.depth <= ColferDepthMax {
+			if err != nil {
+				t.Errorf("%s: got error %q with ColferDepthMax=%d", gold.json, err, ColferDepthMax)
+			}
+		} else if _, ok := err.(ColferDepth); !ok {
+			t.Errorf("%s: got error %T with ColferDepthMax=%d: %v", gold.json, err, ColferDepthMax, err)
+		}
+	}
+}
+
+func TestUnmarshalBudget(t *testing.T) {
+	// sizes and element counts with little data to back them
+	serials := []string{
+		// binary and text of 8 MiB
+		"09808080047f",
+		"08808080047f",
+		// binary and text element of 8 MiB
+		"0d01808080047f",
+		"0c01808080047f",
+		// nested binary of 8 MiB
+		"0a09808080047f7f",
+		// lists of 64 Ki elements
+		"0b8080047f",
+		"0a0b8080047f7f",
+		"0b020b8080047f7f7f",
+		"0c8080047f",
+		"0d8080047f",
+		"108080047f",
+		"118080047f",
+	}
+
+	// recycled slices must not grow either
+	dirty := &O{A: []byte{1}, Os: []*O{{}}, As: [][]byte{{2}}, Ss: []string{""}}
+	decoders := map[string]func(data []byte) error{
+		"Unmarshal": func(data []byte) error {
+			_, err := new(O).Unmarshal(data)
+			return err
+		},
+		"UnmarshalReuse": func(data []byte) error {
+			_, err := dirty.UnmarshalReuse(data)
+			return err
+		},
+	}
+
+	for _, serial := range serials {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, decode := range decoders {
+			if err := decode(data); err != io.EOF {
+				t.Errorf("%s 0x%s: got error %T: %q", name, serial, err, err)
+			}
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			const n = 10
+			for i := 0; i < n; i++ {
+				decode(data)
+			}
+			runtime.ReadMemStats(&after)
+			// O itself takes a few hundred bytes
+			if got := (after.TotalAlloc - before.TotalAlloc) / n; got > 1024 {
+				t.Errorf("%s 0x%s: got %d bytes allocated for %d bytes of input", name, serial, got, len(data))
+			}
+		}
+	}
+}
+
+func TestMarshalUTF8(t *testing.T) {
+	malformed := []*O{
+		{S: "\xff"},
+		{S: "\xc0\x80"},
+		{S: "\xed\xa0\x80"},
+		{S: "a\xe0\x80"},
+		{Ss: []string{"ok", "\x80"}},
+		{O: &O{S: "\xf8\x88\x80\x80\x80"}},
+		{Os: []*O{{}, {Ss: []string{"\xf4\x90\x80\x80"}}}},
+	}
+	for _, o := range malformed {
+		_, err := o.MarshalBinary()
+		if _, ok := err.(ColferUTF8); !ok {
+			t.Errorf("%s: got error %v, want a ColferUTF8", o, err)
+		}
+	}
+}
+
+func TestUnmarshalUTF8(t *testing.T) {
+	malformed := []string{
+		"0801ff7f",
+		"0802c0807f",
+		"0803eda0807f",
+		"080361e0807f",
+		"0c020001ff7f",
+		"0a0801ff7f7f",
+		"0b027f0c010261807f7f",
+	}
+	for _, serial := range malformed {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(O).Unmarshal(data); err == nil {
+			t.Errorf("0x%s: no error", serial)
+		} else if _, ok := err.(ColferUTF8); !ok {
+			t.Errorf("0x%s: got error %T: %q, want a ColferUTF8", serial, err, err)
+		}
+		if _, err := new(O).UnmarshalReuse(data); err == nil {
+			t.Errorf("0x%s: reuse no error", serial)
+		} else if _, ok := err.(ColferUTF8); !ok {
+			t.Errorf("0x%s: reuse got error %T: %q, want a ColferUTF8", serial, err, err)
+		}
+	}
+}
+
+func TestUnmarshalBinaryUnknown(t *testing.T) {
+	// extensions with index 18 (a bool), 19 (a flagged uint32) and 20 (a text)
+	const ext = "1293000000011403616263"
+
+	for _, gold := range newGoldenCases() {
+		serial := gold.serial[:len(gold.serial)-2] + ext + "7f"
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := new(O).Unmarshal(data); err != ColferError(len(gold.serial)/2-1) {
+			t.Errorf("0x%s: got unmarshal error %v, want a ColferError", serial, err)
+		}
+
+		var got O
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: unmarshal binary error: %s", serial, err)
+			continue
+		}
+		remarshal, err := got.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: marshal error: %s", serial, err)
+			continue
+		}
+		if got := hex.EncodeToString(remarshal); got != serial {
+			t.Errorf("got serial 0x%s, want 0x%s", got, serial)
+		}
+
+		if got.Equal(&gold.object) {
+			t.Errorf("0x%s: equal to 0x%s", serial, gold.serial)
+		}
+		if c := got.Clone(); !c.Equal(&got) {
+			t.Errorf("0x%s: clone not equal", serial)
+		}
+	}
+}
+
+func TestUnmarshalBinaryUnknownMismatch(t *testing.T) {
+	mismatches := []string{
+		// known index out of order
+		"08014100127f",
+		// unknown index in a nested data structure
+		"0a127f7f",
+		// unknown index in a list element
+		"0b01127f7f",
+		// unknown index in the second list element
+		"0b027f127f7f",
+		// no terminator
+		"1201",
+		// no terminator after a known field
+		"0e011201",
+		// terminator missing at the end
+		"127f01",
+		// header 0xff is not a field
+		"ff7f",
+		// descending index after any payload length
+		"13ff12ffffffff7f",
+		// text size beyond the terminator
+		"1403ffff7f",
+	}
+	for _, serial := range mismatches {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := new(O).UnmarshalBinary(data); err == nil {
+			t.Errorf("0x%s: no error", serial)
+		} else if _, ok := err.(ColferError); !ok {
+			t.Errorf("0x%s: got error %T: %q, want a ColferError", serial, err, err)
+		}
+	}
+}
+
+func TestUnmarshalBinaryUnknownReuse(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ext := append(data[:len(data)-1:len(data)-1], 0x12, 0x7f)
+
+		var o O
+		if err := o.UnmarshalBinary(ext); err != nil {
+			t.Errorf("0x%x: unmarshal binary error: %s", ext, err)
+			continue
+		}
+		// same known fields without the unknown one
+		if err := o.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: unmarshal binary error: %s", gold.serial, err)
+			continue
+		}
+		if got, err := o.MarshalBinary(); err != nil {
+			t.Errorf("0x%s: marshal error: %s", gold.serial, err)
+		} else if !bytes.Equal(got, data) {
+			t.Errorf("0x%s: remarshal got 0x%x", gold.serial, got)
+		}
+	}
+}
+
+func TestUnmarshalBinaryUnknownOpaque(t *testing.T) {
+	// The payload of unknown fields can not be decoded, as the header does
+	// not tell the type. Such bytes are retained verbatim instead, as long
+	// as some datatype fits.
+	for _, serial := range []string{"12ff7f", "0e0113807f7f", "127f7f", "13ff14ffffffff7f"} {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var o O
+		if err := o.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: unmarshal binary error: %s", serial, err)
+			continue
+		}
+		if got, err := o.MarshalBinary(); err != nil {
+			t.Errorf("0x%s: marshal error: %s", serial, err)
+		} else if !bytes.Equal(got, data) {
+			t.Errorf("0x%s: remarshal got 0x%x", serial, got)
+		}
+	}
+}
+
 // TestFuzzSeed updates the initial input corpus for fuzz testing.
 func TestFuzzSeed(t *testing.T) {
 	for _, gold := range newGoldenCases() {
@@ -187,3 +651,675 @@ func TestFuzzSeed(t *testing.T) {
 		}
 	}
 }
+
+func TestEqual(t *testing.T) {
+	golden := newGoldenCases()
+	for i, a := range golden {
+		for j, b := range golden {
+			if got, want := a.object.Equal(&b.object), i == j; got != want {
+				t.Errorf("0x%s equals 0x%s: got %t, want %t", a.serial, b.serial, got, want)
+			}
+		}
+
+		data, err := hex.DecodeString(a.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got O
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(&a.object) {
+			t.Errorf("0x%s: unmarshalled %s not equal to %s", a.serial, &got, &a.object)
+		}
+	}
+
+	var zero O
+	sameAsZero := []*O{
+		{A: []byte{}, Os: []*O{}, Ss: []string{}, As: [][]byte{}, F32s: []float32{}, F64s: []float64{}},
+		{F32: float32(math.Copysign(0, -1)), F64: math.Copysign(0, -1)},
+	}
+	for _, o := range sameAsZero {
+		if !o.Equal(&zero) || !zero.Equal(o) {
+			t.Errorf("%s not equal to zero", o)
+		}
+	}
+	if !(&O{Os: []*O{nil}}).Equal(&O{Os: []*O{{}}}) {
+		t.Error("nil list entry not equal to new value")
+	}
+	if (&O{O: &O{}}).Equal(&zero) {
+		t.Error("empty nested struct equal to nil")
+	}
+	if (*O)(nil).Equal(&zero) || !(*O)(nil).Equal(nil) {
+		t.Error("nil receiver mismatch")
+	}
+}
+
+func TestClone(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		c := gold.object.Clone()
+		if !c.Equal(&gold.object) {
+			t.Errorf("0x%s: got clone %s, want %s", gold.serial, c, &gold.object)
+		}
+	}
+
+	o := &O{A: []byte{1}, O: &O{S: "inner"}, Os: []*O{{U8: 2}, nil}, As: [][]byte{{3}, nil}, F32s: []float32{4}}
+	c := o.Clone()
+	c.A[0] = 0
+	c.O.S = ""
+	c.Os[0].U8 = 0
+	c.As[0][0] = 0
+	c.F32s[0] = 0
+	want := &O{A: []byte{1}, O: &O{S: "inner"}, Os: []*O{{U8: 2}, nil}, As: [][]byte{{3}, nil}, F32s: []float32{4}}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("original modified to %s", o)
+	}
+	if (*O)(nil).Clone() != nil {
+		t.Error("clone of nil is not nil")
+	}
+}
+
+func TestString(t *testing.T) {
+	golden := []struct {
+		o    *O
+		want string
+	}{
+		{nil, "<nil>"},
+		{&O{}, "gen.o{}"},
+		{&O{B: true, I32: -1, F64: math.NaN()}, "gen.o{b: true, i32: -1, f64: NaN}"},
+		{&O{T: time.Unix(1441739050, 777888999)}, "gen.o{t: 2015-09-08T19:04:10.777888999Z}"},
+		{&O{S: "a\x00", A: []byte{2, 0}}, `gen.o{s: "a\x00", a: 0x0200}`},
+		{&O{O: &O{U8: 1}, Os: []*O{{}, nil}}, "gen.o{o: gen.o{u8: 1}, os: [gen.o{}, <nil>]}"},
+		{&O{Ss: []string{"", "b"}, As: [][]byte{{}, {1}}, F32s: []float32{0.5}}, `gen.o{ss: ["", "b"], as: [0x, 0x01], f32s: [0.5]}`},
+	}
+	for _, gold := range golden {
+		if got := gold.o.String(); got != gold.want {
+			t.Errorf("got %s, want %s", got, gold.want)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	golden := []struct {
+		o    *O
+		want string
+	}{
+		{nil, `null`},
+		{&O{B: true, U32: 1, U64: math.MaxUint64, I32: -2, I64: math.MinInt64, U8: 3, U16: 4},
+			`{"b":true,"u32":1,"u64":18446744073709551615,"i32":-2,"i64":-9223372036854775808,"f32":0,"f64":0,"t":null,"s":"","a":"","o":null,"os":[],"ss":[],"as":[],"u8":3,"u16":4,"f32s":[],"f64s":[]}`},
+		{&O{F32: 0.1, F64: math.NaN(), F32s: []float32{float32(math.Inf(1)), -0.5}, F64s: []float64{math.Inf(-1), 1e100}},
+			`{"b":false,"u32":0,"u64":0,"i32":0,"i64":0,"f32":0.1,"f64":"NaN","t":null,"s":"","a":"","o":null,"os":[],"ss":[],"as":[],"u8":0,"u16":0,"f32s":["Infinity",-0.5],"f64s":["-Infinity",1e+100]}`},
+		{&O{T: time.Unix(1441739050, 777888999).In(time.UTC), S: "\"\\\n\x00", A: []byte{0xfb, 0xff}, Ss: []string{"é"}, As: [][]byte{nil, {1}}},
+			`{"b":false,"u32":0,"u64":0,"i32":0,"i64":0,"f32":0,"f64":0,"t":"2015-09-08T19:04:10.777888999Z","s":"\"\\\n\u0000","a":"+/8=","o":null,"os":[],"ss":["é"],"as":["","AQ=="],"u8":0,"u16":0,"f32s":[],"f64s":[]}`},
+		{&O{T: time.Unix(-864e10, 1001), O: &O{U8: 1}, Os: []*O{nil, {}}},
+			`{"b":false,"u32":0,"u64":0,"i32":0,"i64":0,"f32":0,"f64":0,"t":-8639999999999.999998999,"s":"","a":"","o":{"b":false,"u32":0,"u64":0,"i32":0,"i64":0,"f32":0,"f64":0,"t":null,"s":"","a":"","o":null,"os":[],"ss":[],"as":[],"u8":1,"u16":0,"f32s":[],"f64s":[]},"os":[null,{"b":false,"u32":0,"u64":0,"i32":0,"i64":0,"f32":0,"f64":0,"t":null,"s":"","a":"","o":null,"os":[],"ss":[],"as":[],"u8":0,"u16":0,"f32s":[],"f64s":[]}],"ss":[],"as":[],"u8":0,"u16":0,"f32s":[],"f64s":[]}`},
+	}
+	for _, gold := range golden {
+		got, err := gold.o.MarshalJSON()
+		if err != nil {
+			t.Errorf("%s: %s", gold.o, err)
+			continue
+		}
+		if string(got) != gold.want {
+			t.Errorf("%s: got JSON %s, want %s", gold.o, got, gold.want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("%s: invalid JSON %s", gold.o, got)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := json.Marshal(&gold.object)
+		if err != nil {
+			t.Errorf("0x%s: marshal error: %s", gold.serial, err)
+			continue
+		}
+		var got O
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Errorf("0x%s: unmarshal %s error: %s", gold.serial, data, err)
+			continue
+		}
+		if !got.Equal(&gold.object) {
+			t.Errorf("0x%s: JSON %s unmarshalled as %s", gold.serial, data, &got)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	golden := []struct {
+		json string
+		want *O
+	}{
+		{` { } `, &O{}},
+		{`{"b":true,"u32":"7","i64":-1e0,"u16":null}`, nil},
+		{`{"b":true,"u32":"7","i64":-1,"u16":null}`, &O{B: true, U32: 7, I64: -1}},
+		{`{"s":"é😀\ud800\/","a":"AAE="}`, &O{S: "é😀�/", A: []byte{0, 1}}},
+		{"{\"t\":\"2015-09-08T21:04:10.777888999+02:00\",\"f32s\":[\"NaN\", 2]}", &O{T: time.Unix(1441739050, 777888999).In(time.UTC), F32s: []float32{float32(math.NaN()), 2}}},
+		{`{"t":-8639999999999.999998999,"o":{"s":"x"},"os":[null,{"u8":2}],"ss":[],"as":["AQ=="]}`, &O{T: time.Unix(-864e10, 1001).In(time.UTC), O: &O{S: "x"}, Os: []*O{nil, {U8: 2}}, As: [][]byte{{1}}}},
+	}
+	for _, gold := range golden {
+		var got O
+		err := got.UnmarshalJSON([]byte(gold.json))
+		if gold.want == nil {
+			if err == nil {
+				t.Errorf("%s: no error", gold.json)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", gold.json, err)
+			continue
+		}
+		if !got.Equal(gold.want) || !got.T.Equal(gold.want.T) {
+			t.Errorf("%s: got %s, want %s", gold.json, &got, gold.want)
+		}
+	}
+}
+
+func TestUnmarshalJSONError(t *testing.T) {
+	golden := []struct {
+		json string
+		want string
+	}{
+		{``, "colfer: gen.o: unexpected end of JSON at byte 0"},
+		{`[]`, "colfer: gen.o: JSON at byte 0 is not a object"},
+		{`{"b":true}}`, "colfer: gen.o: JSON continuation at byte 10"},
+		{`{"b":true,}`, "colfer: gen.o: JSON at byte 10 is not a string"},
+		{`{"b" true}`, "colfer: gen.o: JSON syntax error at byte 5"},
+		{`{"x":1}`, `colfer: JSON member "x" not in gen.o`},
+		{`{"b":1}`, "colfer: gen.o.b: JSON at byte 5 is not a boolean"},
+		{`{"u8":256}`, `colfer: gen.o.u8: strconv.ParseUint: parsing "256": value out of range`},
+		{`{"f64":"inf"}`, `colfer: gen.o.f64: JSON string "inf" is not a floating point`},
+		{`{"s":"\x"}`, "colfer: gen.o.s: JSON syntax error at byte 7"},
+		{"{\"s\":\"\xff\"}", "colfer: gen.o.s: JSON string with malformed UTF-8 before byte 8"},
+		{`{"os":[{"b":0}]}`, "colfer: gen.o.b: JSON at byte 12 is not a boolean"},
+		{`{"ss":["a" "b"]}`, "colfer: gen.o.ss: JSON syntax error at byte 11"},
+	}
+	for _, gold := range golden {
+		err := new(O).UnmarshalJSON([]byte(gold.json))
+		if err == nil {
+			t.Errorf("%s: no error, want %q", gold.json, gold.want)
+		} else if err.Error() != gold.want {
+			t.Errorf("%s: got error %q, want %q", gold.json, err, gold.want)
+		}
+	}
+}
+
+func TestJSONMax(t *testing.T) {
+	origSize, origList := ColferSizeMax, ColferListMax
+	defer func() {
+		ColferSizeMax, ColferListMax = origSize, origList
+	}()
+	ColferSizeMax, ColferListMax = 16, 2
+
+	for _, o := range []*O{
+		{S: strings.Repeat("x", 17)},
+		{A: make([]byte, 17)},
+		{Ss: make([]string, 3)},
+		{O: &O{F64s: make([]float64, 3)}},
+	} {
+		if _, err := o.MarshalJSON(); err == nil {
+			t.Errorf("%s: marshal no error", o)
+		} else if _, ok := err.(ColferMax); !ok {
+			t.Errorf("%s: got marshal error %T: %q, want a ColferMax", o, err, err)
+		}
+	}
+
+	for _, s := range []string{
+		`{"s":"xxxxxxxxxxxxxxxxx"}`,
+		`{"a":"AAAAAAAAAAAAAAAAAAAAAAA="}`,
+		`{"ss":["","",""]}`,
+		`{"o":{"f64s":[0,0,0]}}`,
+	} {
+		if err := new(O).UnmarshalJSON([]byte(s)); err == nil {
+			t.Errorf("%s: unmarshal no error", s)
+		} else if _, ok := err.(ColferMax); !ok {
+			t.Errorf("%s: got unmarshal error %T: %q, want a ColferMax", s, err, err)
+		}
+	}
+}
+
+func TestUnmarshalFields(t *testing.T) {
+	masks := []ColferMask{{}, ColferMask{}.With(9, 11, 12)}
+	for i := 0; i < 18; i++ {
+		masks = append(masks, ColferMask{}.With(i))
+	}
+	var all ColferMask
+	for i := 0; i < 18; i++ {
+		all = all.With(i)
+	}
+	masks = append(masks, all)
+
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, mask := range masks {
+			// expect golden with all fields outside mask zeroed
+			want := gold.object
+			v := reflect.ValueOf(&want).Elem()
+			for i := 0; i < 18; i++ {
+				if !mask.Has(i) {
+					f := v.Field(i)
+					f.Set(reflect.Zero(f.Type()))
+				}
+			}
+
+			got := new(O)
+			n, err := got.UnmarshalFields(data, mask)
+			if err != nil {
+				t.Errorf("0x%s with mask %#x: got error %q", gold.serial, mask, err)
+				continue
+			}
+			if n != len(data) {
+				t.Errorf("0x%s with mask %#x: read %d bytes, want %d", gold.serial, mask, n, len(data))
+			}
+			if !got.Equal(&want) {
+				t.Errorf("0x%s with mask %#x: got %s, want %s", gold.serial, mask, got, &want)
+			}
+
+			for i := range data {
+				incomplete := data[:i]
+				if _, err := new(O).UnmarshalFields(incomplete, mask); err != io.EOF {
+					t.Errorf("0x%s with mask %#x: got error %T: %q", hex.EncodeToString(incomplete), mask, err, err)
+				}
+			}
+		}
+	}
+}
+
+func TestUnmarshalFieldsMax(t *testing.T) {
+	origSize, origList := ColferSizeMax, ColferListMax
+	defer func() {
+		ColferSizeMax, ColferListMax = origSize, origList
+	}()
+
+	ColferListMax = 2
+	for _, serial := range []string{
+		"0b037f7f7f7f",
+		"0c03000000" + "7f",
+		"0d03000000" + "7f",
+		"1003000000000000000000000000" + "7f",
+		"1103" + strings.Repeat("00", 24) + "7f",
+	} {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(O).UnmarshalFields(data, ColferMask{}); err == nil {
+			t.Errorf("0x%s: no error with ColferListMax=%d", serial, ColferListMax)
+		} else if _, ok := err.(ColferMax); !ok {
+			t.Errorf("0x%s: got error %T with ColferListMax=%d: %q", serial, err, ColferListMax, err)
+		}
+	}
+	ColferListMax = origList
+
+	ColferSizeMax = 3
+	for _, serial := range []string{"08047f", "09047f", "0c0104", "0d0104", "0a0a7f7f"} {
+		data, err := hex.DecodeString(serial + "00000000007f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(O).UnmarshalFields(data, ColferMask{}); err == nil {
+			t.Errorf("0x%s: no error with ColferSizeMax=%d", serial, ColferSizeMax)
+		} else if _, ok := err.(ColferMax); !ok {
+			t.Errorf("0x%s: got error %T with ColferSizeMax=%d: %q", serial, err, ColferSizeMax, err)
+		}
+	}
+}
+
+func TestUnmarshalFieldsAllocs(t *testing.T) {
+	data, err := (&O{A: []byte{1, 2}, S: "x", O: &O{Os: []*O{{B: true}}},
+		Os: []*O{{}, {Ss: []string{"y"}}}, As: [][]byte{{3}}}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o O
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := o.UnmarshalFields(data, ColferMask{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("got %f allocations on skip", allocs)
+	}
+}
+
+func TestEachOs(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got O
+		var elems []*O
+		n, err := got.EachOs(data, func(v *O) error {
+			elems = append(elems, v.Clone())
+			return nil
+		})
+		if err != nil || n != len(data) {
+			t.Errorf("0x%s: got %d, %v; want %d", gold.serial, n, err, len(data))
+			continue
+		}
+		if got.Os != nil {
+			t.Errorf("0x%s: got Os %v, want unset", gold.serial, got.Os)
+		}
+		got.Os = elems
+		if !got.Equal(&gold.object) {
+			t.Errorf("0x%s: got %v, want %v", gold.serial, &got, &gold.object)
+		}
+
+		for i := range data {
+			incomplete := data[:i]
+			_, err := new(O).EachOs(incomplete, func(*O) error {
+				t.Errorf("0x%s: yield on incomplete serial", hex.EncodeToString(incomplete))
+				return nil
+			})
+			if err != io.EOF {
+				t.Errorf("0x%s: got error %T: %q", hex.EncodeToString(incomplete), err, err)
+			}
+		}
+	}
+}
+
+func TestEachOsFields(t *testing.T) {
+	want := &O{S: "before", Os: []*O{{U8: 1}, nil, {Ss: []string{"x"}}}, U8: 42}
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o O
+	var count int
+	n, err := o.EachOs(data, func(v *O) error {
+		if o.S != want.S || o.U8 != want.U8 {
+			t.Errorf("element %d: got outer %v, want all fields set", count, &o)
+		}
+		w := want.Os[count]
+		if w == nil {
+			w = new(O)
+		}
+		if !v.Equal(w) {
+			t.Errorf("element %d: got %v, want %v", count, v, w)
+		}
+		count++
+		return nil
+	})
+	if err != nil || n != len(data) {
+		t.Errorf("got %d, %v; want %d", n, err, len(data))
+	}
+	if count != len(want.Os) {
+		t.Errorf("got %d elements, want %d", count, len(want.Os))
+	}
+
+	stop := errors.New("stop")
+	count = 0
+	_, err = o.EachOs(data, func(*O) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Errorf("got error %v after %d elements, want stop after 1", err, count)
+	}
+
+	// malformed last element
+	data, _ = hex.DecodeString("0b02" + "7f" + "7e" + "7f")
+	_, err = o.EachOs(data, func(*O) error {
+		t.Error("yield on malformed serial")
+		return nil
+	})
+	if err != ColferError(0) {
+		t.Errorf("got error %v, want ColferError(0)", err)
+	}
+}
+
+func TestEachOsAllocs(t *testing.T) {
+	allocsFor := func(elems int) float64 {
+		o := O{Os: make([]*O, elems)}
+		for i := range o.Os {
+			o.Os[i] = &O{A: []byte{1, 2}, Ss: []string{"x"}}
+		}
+		data, err := o.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return testing.AllocsPerRun(10, func() {
+			_, err := new(O).EachOs(data, func(*O) error { return nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if few, many := allocsFor(2), allocsFor(1000); few != many {
+		t.Errorf("got %f allocations for 2 elements and %f for 1000", few, many)
+	}
+}
+
+func TestFieldMask(t *testing.T) {
+	mask, err := new(O).FieldMask("s", "os", "f64s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ColferMask{}).With(8, 11, 17); mask != want {
+		t.Errorf("got mask %#x, want %#x", mask, want)
+	}
+
+	_, err = new(O).FieldMask("s", "S")
+	const want = `colfer: field "S" not in gen.o`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestTypeRegistry(t *testing.T) {
+	for name, f := range ColferTypeNames {
+		m, ok := f().(ColferMessage)
+		if !ok {
+			t.Errorf("%s: constructor type %T is not a ColferMessage", name, f())
+			continue
+		}
+		byID, ok := ColferTypeIDs[m.ColferTypeID()]
+		if !ok {
+			t.Errorf("%s: type identifier %#08x not registered", name, m.ColferTypeID())
+			continue
+		}
+		if got, want := reflect.TypeOf(byID()), reflect.TypeOf(m); got != want {
+			t.Errorf("%s: type identifier %#08x got %s, want %s", name, m.ColferTypeID(), got, want)
+		}
+	}
+	if len(ColferTypeNames) != len(ColferTypeIDs) {
+		t.Errorf("got %d names and %d identifiers", len(ColferTypeNames), len(ColferTypeIDs))
+	}
+
+	if got := new(O).ColferTypeName(); got != "gen.o" {
+		t.Errorf("got type name %q, want %q", got, "gen.o")
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	golden := []struct {
+		serial   string
+		envelope ColferEnvelope
+	}{
+		{"7f", ColferEnvelope{}},
+		{"00017f", ColferEnvelope{TypeID: 1}},
+		{"000101017f7f", ColferEnvelope{TypeID: 1, Serial: []byte{0x7f}}},
+		{"807ffec28a01017f7f", ColferEnvelope{TypeID: 0x7ffec28a, Serial: []byte{0x7f}}},
+	}
+	for _, gold := range golden {
+		serial, err := gold.envelope.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: marshal error: %s", gold.serial, err)
+		} else if got := hex.EncodeToString(serial); got != gold.serial {
+			t.Errorf("0x%s: marshal got 0x%s", gold.serial, got)
+		}
+
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ColferEnvelope
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: unmarshal error: %s", gold.serial, err)
+		} else if !reflect.DeepEqual(got, gold.envelope) {
+			t.Errorf("0x%s: unmarshal got %+v, want %+v", gold.serial, got, gold.envelope)
+		}
+		for i := range data {
+			if err := got.UnmarshalBinary(data[:i]); err != io.EOF {
+				t.Errorf("0x%s: unmarshal of %d bytes got error %v, want EOF", gold.serial, i, err)
+			}
+		}
+		if err := got.UnmarshalBinary(append(data, 0x7f)); err != ColferTail(len(data)) {
+			t.Errorf("0x%s: unmarshal with tail got error %v", gold.serial, err)
+		}
+	}
+
+	for _, gold := range newGoldenCases() {
+		var e ColferEnvelope
+		if err := e.Wrap(&gold.object); err != nil {
+			t.Errorf("0x%s: wrap error: %s", gold.serial, err)
+			continue
+		}
+		serial, err := e.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: marshal error: %s", gold.serial, err)
+			continue
+		}
+
+		var got ColferEnvelope
+		if err := got.UnmarshalBinary(serial); err != nil {
+			t.Errorf("0x%s: unmarshal error: %s", gold.serial, err)
+			continue
+		}
+		m, err := got.Unwrap()
+		if err != nil {
+			t.Errorf("0x%s: unwrap error: %s", gold.serial, err)
+			continue
+		}
+		if o, ok := m.(*O); !ok || !o.Equal(&gold.object) {
+			t.Errorf("0x%s: unwrap got %#v, want %v", gold.serial, m, &gold.object)
+		}
+	}
+
+	e := ColferEnvelope{TypeID: 1, Serial: []byte{0x7f}}
+	if _, err := e.Unwrap(); err != ColferUnknownType(1) {
+		t.Errorf("unwrap of unknown type got error %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	// same in all languages, as in testdata
+	if want := "98768e7cc2fcda72"; ColferFingerprintO != want {
+		t.Errorf("got fingerprint %q, want %q", ColferFingerprintO, want)
+	}
+}
+
+// GenericMessage is declared like generic code outside of the package would.
+type genericMessage interface {
+	ColferDescriptor() *struct {
+		Name   string
+		Docs   string
+		Fields []struct {
+			Index      int
+			Name, Type string
+			List, Ref  bool
+			Desc       interface{}
+			Docs       string
+		}
+	}
+	ColferGet(index int) (interface{}, error)
+	ColferSet(index int, value interface{}) error
+}
+
+func TestDescriptor(t *testing.T) {
+	var m genericMessage = new(O)
+	d := m.ColferDescriptor()
+	if d.Name != "gen.o" || d.Docs != "O contains all supported data types." {
+		t.Errorf("got name %q and docs %q", d.Name, d.Docs)
+	}
+	if len(d.Fields) != 18 {
+		t.Fatalf("got %d fields, want 18", len(d.Fields))
+	}
+	for i, f := range d.Fields {
+		if f.Index != i {
+			t.Errorf("field %q got index %d, want %d", f.Name, f.Index, i)
+		}
+	}
+	if f := d.Fields[11]; f.Name != "os" || f.Type != "gen.o" || !f.List || !f.Ref || f.Docs != "Os tests data structure lists." {
+		t.Errorf("got field 11 descriptor %+v", f)
+	}
+	if f := d.Fields[7]; f.Name != "t" || f.Type != "timestamp" || f.List || f.Ref || f.Desc != nil {
+		t.Errorf("got field 7 descriptor %+v", f)
+	}
+	if got, ok := d.Fields[11].Desc.(*ColferDescriptor); !ok || got != new(O).ColferDescriptor() {
+		t.Errorf("got field 11 description %#v, want the one of O", d.Fields[11].Desc)
+	}
+	embed := new(EmbedO).ColferDescriptor()
+	if got, ok := embed.Fields[0].Desc.(*ColferDescriptor); !ok || got.Name != "gen.o" {
+		t.Errorf("got gen.EmbedO field 0 description %#v, want the one of O", embed.Fields[0].Desc)
+	}
+}
+
+func TestColferGetSet(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		var got O
+		for _, f := range gold.object.ColferDescriptor().Fields {
+			v, err := gold.object.ColferGet(f.Index)
+			if err != nil {
+				t.Fatalf("0x%s: get field %q error: %s", gold.serial, f.Name, err)
+			}
+			if err := got.ColferSet(f.Index, v); err != nil {
+				t.Fatalf("0x%s: set field %q error: %s", gold.serial, f.Name, err)
+			}
+		}
+		if !got.Equal(&gold.object) {
+			t.Errorf("0x%s: got %v, want %v", gold.serial, &got, &gold.object)
+		}
+	}
+
+	o := &O{S: "set", O: new(O)}
+	if err := o.ColferSet(8, nil); err != nil || o.S != "" {
+		t.Errorf("set text nil got error %v and value %q", err, o.S)
+	}
+	if err := o.ColferSet(10, nil); err != nil || o.O != nil {
+		t.Errorf("set data structure nil got error %v and value %v", err, o.O)
+	}
+	const want = "colfer: field gen.o.s can't take a value of type []uint8"
+	if err := o.ColferSet(8, []byte("set")); err == nil || err.Error() != want {
+		t.Errorf("set text with binary got error %v, want %q", err, want)
+	}
+
+	// typed nils must match the field
+	o.O, o.Os = new(O), []*O{new(O)}
+	if err := o.ColferSet(10, (*O)(nil)); err != nil || o.O != nil {
+		t.Errorf("set data structure typed nil got error %v and value %v", err, o.O)
+	}
+	if err := o.ColferSet(11, ([]*O)(nil)); err != nil || o.Os != nil {
+		t.Errorf("set data structure list typed nil got error %v and value %v", err, o.Os)
+	}
+	o.O = new(O)
+	if err := o.ColferSet(10, (*DromedaryCase)(nil)); err == nil || o.O == nil {
+		t.Errorf("set data structure with typed nil of another type got error %v and value %v", err, o.O)
+	}
+	if err := o.ColferSet(9, (*O)(nil)); err == nil {
+		t.Error("set binary with typed nil of data structure got no error")
+	}
+	if err := o.ColferSet(12, ([]byte)(nil)); err == nil {
+		t.Error("set text list with typed nil of binary got no error")
+	}
+	for _, index := range []int{-1, 18, 127} {
+		if _, err := o.ColferGet(index); err == nil {
+			t.Errorf("get index %d got no error", index)
+		}
+		if err := o.ColferSet(index, true); err == nil {
+			t.Errorf("set index %d got no error", index)
+		}
+	}
+}
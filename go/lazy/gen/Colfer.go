@@ -0,0 +1,7721 @@
+// Package gen tests all field mapping options.
+package gen
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file test.colf.
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var intconv = binary.BigEndian
+
+// Colfer configuration attributes
+var (
+	// ColferSizeMax is the upper limit for serial byte sizes.
+	ColferSizeMax = 16 * 1024 * 1024
+	// ColferListMax is the upper limit for the number of elements in a list.
+	ColferListMax = 64 * 1024
+	// ColferDepthMax is the upper limit for the nesting level of data
+	// structures, with 1 for the top-level. Data structures from another
+	// package count from 1 again, against the limit of their own package.
+	ColferDepthMax = 100
+)
+
+// Schema fingerprints change with any change to the fields of the respective
+// data structure, including the data structures referred to.
+const (
+	// ColferFingerprintO is the schema digest of O.
+	ColferFingerprintO = "98768e7cc2fcda72"
+	// ColferFingerprintDromedaryCase is the schema digest of DromedaryCase.
+	ColferFingerprintDromedaryCase = "f21325a195f2e6e5"
+	// ColferFingerprintEmbedO is the schema digest of EmbedO.
+	ColferFingerprintEmbedO = "1ea394cc8643da1b"
+)
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferDepth signals an upper limit breach of the nesting level.
+type ColferDepth string
+
+// Error honors the error interface.
+func (m ColferDepth) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// ColferUnknownValid returns whether data[start:end] walks as fields in
+// ascending index order, with data[end] as their terminator. The header of
+// unknown fields has no type information. The walk thus accepts any datatype
+// which fits the payload. Nested data structures are checked on their
+// terminator only. List elements beyond a work limit pass unchecked.
+func colferUnknownValid(data []byte, start, end int) bool {
+	// valid has the field index plus one per header position with a valid
+	// continuation, or zero for none. The terminator exceeds any index.
+	valid := make([]byte, end-start+1)
+	valid[end-start] = 128
+	// nested has the maximum of valid past each terminator from a position
+	// onwards, excluding the one at end, for data structure payloads.
+	nested := make([]byte, end-start+1)
+	// limit the work on list elements to linear time
+	budget := 8 * (end - start)
+
+	// fits returns whether a field with index k can continue at i.
+	fits := func(i int, k byte) bool {
+		return i <= end && valid[i-start] > k+1
+	}
+	// varint returns the value at i, with the position after it.
+	varint := func(i int) (x uint64, next int, ok bool) {
+		for shift := uint(0); shift < 64; shift += 7 {
+			if i >= end {
+				return 0, i, false
+			}
+			c := data[i]
+			i++
+			x |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return x, i, true
+			}
+		}
+		return 0, i, false
+	}
+
+	for p := end - 1; p >= start; p-- {
+		nested[p-start] = nested[p+1-start]
+		if data[p] == 0x7f && valid[p+1-start] > nested[p-start] {
+			nested[p-start] = valid[p+1-start]
+		}
+
+		header := data[p]
+		k := header & 0x7f
+		if k == 0x7f {
+			continue // terminator or invalid
+		}
+		i := p + 1
+		var ok bool
+		if header&0x80 != 0 {
+			// fixed size integers, timestamp, or a negative integer
+			ok = fits(i+1, k) || fits(i+4, k) || fits(i+8, k) || fits(i+12, k)
+			if !ok {
+				if _, next, isVarint := varint(i); isVarint {
+					ok = fits(next, k)
+				}
+			}
+		} else {
+			// boolean, fixed size numbers, timestamp, or a data structure
+			ok = fits(i, k) || fits(i+1, k) || fits(i+2, k) || fits(i+4, k) || fits(i+8, k) || nested[i-start] > k+1
+			if x, c, isVarint := varint(i); !ok && isVarint {
+				// integer, or a size or list length
+				ok = fits(c, k)
+				if !ok && x <= uint64(end-c) {
+					n := int(x)
+					// text, binary, or a list of floating points
+					ok = fits(c+n, k) || fits(c+4*n, k) || fits(c+8*n, k)
+					// list of data structures
+					ok = ok || (n != 0 && nested[c+n-1-start] > k+1)
+					if !ok && n > budget {
+						ok = true
+					}
+					if !ok {
+						budget -= n
+						// list of integers
+						e := c
+						for j := 0; j < n; j++ {
+							_, next, isVarint := varint(e)
+							if !isVarint {
+								e = end + 1
+								break
+							}
+							e = next
+						}
+						ok = fits(e, k)
+						// list of texts or binaries
+						e = c
+						for j := 0; j < n; j++ {
+							size, next, isVarint := varint(e)
+							if !isVarint || size > uint64(end-next) {
+								e = end + 1
+								break
+							}
+							e = next + int(size)
+						}
+						ok = ok || fits(e, k)
+					}
+				}
+			}
+		}
+		if ok {
+			valid[p-start] = k + 1
+		}
+	}
+	return valid[0] != 0
+}
+
+// ColferUTF8 signals malformed UTF-8 in text.
+type ColferUTF8 string
+
+// Error honors the error interface.
+func (m ColferUTF8) Error() string { return string(m) }
+
+// ColferMask is a set of field indices, i.e., the position in the schema, for
+// selective decoding with UnmarshalFields.
+type ColferMask [2]uint64
+
+// Has returns whether the field index is in m.
+func (m ColferMask) Has(index int) bool {
+	return uint(index) < 128 && m[index>>6]&(1<<uint(index&63)) != 0
+}
+
+// With returns m with the field indices added. Any index outside the Colfer
+// range of 0 to 126 panics.
+func (m ColferMask) With(indices ...int) ColferMask {
+	for _, index := range indices {
+		if index < 0 || index > 126 {
+			panic(fmt.Sprintf("colfer: field index %d out of range", index))
+		}
+		m[index>>6] |= 1 << uint(index&63)
+	}
+	return m
+}
+
+// ColferDescriptor is a static description of a data structure from the schema.
+// The type alias is identical in all generated packages, such that generic code
+// can declare the same.
+type ColferDescriptor = struct {
+	Name   string // qualified name
+	Docs   string // documentation text
+	Fields []ColferFieldDescriptor
+}
+
+// ColferFieldDescriptor is a static description of a field from the schema.
+// Desc holds the *ColferDescriptor of data structures, which is an interface
+// because type aliases can't refer to themselves.
+type ColferFieldDescriptor = struct {
+	Index int         // position in the schema
+	Name  string      // name from the schema
+	Type  string      // datatype, or the qualified name of a data structure
+	List  bool        // whether the field is a list of Type
+	Ref   bool        // whether Type is a data structure
+	Desc  interface{} // description of Type when Ref, or nil
+	Docs  string      // documentation text
+}
+
+// colferDescriptorO is the static description of O.
+var colferDescriptorO = ColferDescriptor{
+	Name: "gen.o",
+	Docs: "O contains all supported data types.",
+	Fields: []ColferFieldDescriptor{
+		{Index: 0, Name: "b", Type: "bool", List: false, Ref: false, Docs: "B tests booleans."},
+		{Index: 1, Name: "u32", Type: "uint32", List: false, Ref: false, Docs: "U32 tests unsigned 32-bit integers."},
+		{Index: 2, Name: "u64", Type: "uint64", List: false, Ref: false, Docs: "U64 tests unsigned 64-bit integers."},
+		{Index: 3, Name: "i32", Type: "int32", List: false, Ref: false, Docs: "I32 tests signed 32-bit integers."},
+		{Index: 4, Name: "i64", Type: "int64", List: false, Ref: false, Docs: "I64 tests signed 64-bit integers."},
+		{Index: 5, Name: "f32", Type: "float32", List: false, Ref: false, Docs: "F32 tests 32-bit floating points."},
+		{Index: 6, Name: "f64", Type: "float64", List: false, Ref: false, Docs: "F64 tests 64-bit floating points."},
+		{Index: 7, Name: "t", Type: "timestamp", List: false, Ref: false, Docs: "T tests timestamps."},
+		{Index: 8, Name: "s", Type: "text", List: false, Ref: false, Docs: "S tests text."},
+		{Index: 9, Name: "a", Type: "binary", List: false, Ref: false, Docs: "A tests binaries."},
+		{Index: 10, Name: "o", Type: "gen.o", List: false, Ref: true, Docs: "O tests nested data structures."},
+		{Index: 11, Name: "os", Type: "gen.o", List: true, Ref: true, Docs: "Os tests data structure lists."},
+		{Index: 12, Name: "ss", Type: "text", List: true, Ref: false, Docs: "Ss tests text lists."},
+		{Index: 13, Name: "as", Type: "binary", List: true, Ref: false, Docs: "As tests binary lists."},
+		{Index: 14, Name: "u8", Type: "uint8", List: false, Ref: false, Docs: "U8 tests unsigned 8-bit integers."},
+		{Index: 15, Name: "u16", Type: "uint16", List: false, Ref: false, Docs: "U16 tests unsigned 16-bit integers."},
+		{Index: 16, Name: "f32s", Type: "float32", List: true, Ref: false, Docs: "F32s tests 32-bit floating point lists."},
+		{Index: 17, Name: "f64s", Type: "float64", List: true, Ref: false, Docs: "F64s tests 64-bit floating point lists."},
+	},
+}
+
+// colferDescriptorDromedaryCase is the static description of DromedaryCase.
+var colferDescriptorDromedaryCase = ColferDescriptor{
+	Name: "gen.dromedaryCase",
+	Docs: "DromedaryCase oposes name casings.",
+	Fields: []ColferFieldDescriptor{
+		{Index: 0, Name: "PascalCase", Type: "text", List: false, Ref: false, Docs: ""},
+	},
+}
+
+// colferDescriptorEmbedO is the static description of EmbedO.
+var colferDescriptorEmbedO = ColferDescriptor{
+	Name: "gen.EmbedO",
+	Docs: "EmbedO has an inner object only.\nCovers regression of issue #66.",
+	Fields: []ColferFieldDescriptor{
+		{Index: 0, Name: "inner", Type: "gen.o", List: false, Ref: true, Docs: ""},
+	},
+}
+
+// Descriptors refer to each other after initialization, as recursion is not
+// permitted in variable declarations.
+func init() {
+	colferDescriptorO.Fields[10].Desc = &colferDescriptorO
+	colferDescriptorO.Fields[11].Desc = &colferDescriptorO
+	colferDescriptorEmbedO.Fields[0].Desc = &colferDescriptorO
+}
+
+// O contains all supported data types.
+type O struct {
+	// B tests booleans.
+	B bool
+	// U32 tests unsigned 32-bit integers.
+	U32 uint32
+	// U64 tests unsigned 64-bit integers.
+	U64 uint64
+	// I32 tests signed 32-bit integers.
+	I32 int32
+	// I64 tests signed 64-bit integers.
+	I64 int64
+	// F32 tests 32-bit floating points.
+	F32 float32
+	// F64 tests 64-bit floating points.
+	F64 float64
+	// T tests timestamps.
+	T time.Time
+	// S tests text.
+	S string
+	// A tests binaries.
+	A []byte
+	// O tests nested data structures.
+	O *O
+	// Os tests data structure lists.
+	Os []*O
+	// Ss tests text lists.
+	Ss []string
+	// As tests binary lists.
+	As [][]byte
+	// U8 tests unsigned 8-bit integers.
+	U8 uint8
+	// U16 tests unsigned 16-bit integers.
+	U16 uint16
+	// F32s tests 32-bit floating point lists.
+	F32s []float32
+	// F64s tests 64-bit floating point lists.
+	F64s []float64
+
+	// lazyO has the serial of O, pending decoding.
+	lazyO []byte
+
+	// lazyOs has the serial of Os, pending decoding.
+	lazyOs []byte
+
+	// lazyDepth is the nesting level of o, for decoding of pending serials.
+	lazyDepth int
+
+	// unknown has the serial of trailing fields not in the schema.
+	unknown []byte
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+// All nil entries in o.Os will be replaced with a new value.
+func (o *O) MarshalTo(buf []byte) int {
+	var i int
+
+	if o.B {
+		buf[i] = 0
+		i++
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		buf[i] = 1 | 0x80
+		intconv.PutUint32(buf[i+1:], x)
+		i += 5
+	} else if x != 0 {
+		buf[i] = 1
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		buf[i] = 2 | 0x80
+		intconv.PutUint64(buf[i+1:], x)
+		i += 9
+	} else if x != 0 {
+		buf[i] = 2
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf[i] = 3
+		} else {
+			x = ^x + 1
+			buf[i] = 3 | 0x80
+		}
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf[i] = 4
+		} else {
+			x = ^x + 1
+			buf[i] = 4 | 0x80
+		}
+		i++
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if v := o.F32; v != 0 {
+		buf[i] = 5
+		intconv.PutUint32(buf[i+1:], math.Float32bits(v))
+		i += 5
+	}
+
+	if v := o.F64; v != 0 {
+		buf[i] = 6
+		intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+		i += 9
+	}
+
+	if v := o.T; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf[i] = 7
+			intconv.PutUint32(buf[i+1:], uint32(s))
+			i += 5
+		} else {
+			buf[i] = 7 | 0x80
+			intconv.PutUint64(buf[i+1:], s)
+			i += 9
+		}
+		intconv.PutUint32(buf[i:], ns)
+		i += 4
+	}
+
+	if l := len(o.S); l != 0 {
+		buf[i] = 8
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.S)
+	}
+
+	if l := len(o.A); l != 0 {
+		buf[i] = 9
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.A)
+	}
+
+	if v := o.O; v != nil {
+		buf[i] = 10
+		i++
+		i += v.MarshalTo(buf[i:])
+	} else if o.lazyO != nil {
+		buf[i] = 10
+		i++
+		i += copy(buf[i:], o.lazyO)
+	}
+
+	if l := len(o.Os); l != 0 {
+		buf[i] = 11
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for vi, v := range o.Os {
+			if v == nil {
+				v = new(O)
+				o.Os[vi] = v
+			}
+			i += v.MarshalTo(buf[i:])
+		}
+	} else if o.Os == nil && o.lazyOs != nil {
+		buf[i] = 11
+		i++
+		i += copy(buf[i:], o.lazyOs)
+	}
+
+	if l := len(o.Ss); l != 0 {
+		buf[i] = 12
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Ss {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		buf[i] = 13
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.As {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf[i] = 14
+		i++
+		buf[i] = x
+		i++
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf[i] = 15
+		i++
+		buf[i] = byte(x >> 8)
+		i++
+		buf[i] = byte(x)
+		i++
+	} else if x != 0 {
+		buf[i] = 15 | 0x80
+		i++
+		buf[i] = byte(x)
+		i++
+	}
+
+	if l := len(o.F32s); l != 0 {
+		buf[i] = 16
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.F32s {
+			intconv.PutUint32(buf[i:], math.Float32bits(v))
+			i += 4
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		buf[i] = 17
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, v := range o.F64s {
+			intconv.PutUint64(buf[i:], math.Float64bits(v))
+			i += 8
+		}
+	}
+
+	i += copy(buf[i:], o.unknown)
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax or ColferUTF8.
+func (o *O) MarshalLen() (int, error) {
+	l := 1
+
+	if o.B {
+		l++
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		l += 5
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		l += 9
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.I64; v != 0 {
+		l += 2
+		x := uint64(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			x >>= 7
+			l++
+		}
+	}
+
+	if o.F32 != 0 {
+		l += 5
+	}
+
+	if o.F64 != 0 {
+		l += 9
+	}
+
+	if v := o.T; !v.IsZero() {
+		if s := uint64(v.Unix()); s < 1<<32 {
+			l += 9
+		} else {
+			l += 13
+		}
+	}
+
+	if x := len(o.S); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.s exceeds %d bytes", ColferSizeMax))
+		}
+		if !utf8.ValidString(o.S) {
+			return 0, ColferUTF8("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8")
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.A); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.a exceeds %d bytes", ColferSizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.O; v != nil {
+		vl, err := v.MarshalLen()
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	} else if o.lazyO != nil {
+		l += len(o.lazyO) + 1
+	}
+
+	if x := len(o.Os); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.os exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, v := range o.Os {
+			if v == nil {
+				l++
+				continue
+			}
+			vl, err := v.MarshalLen()
+			if err != nil {
+				return 0, err
+			}
+			l += vl
+		}
+		if l > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	} else if o.Os == nil && o.lazyOs != nil {
+		l += len(o.lazyOs) + 1
+	}
+
+	if x := len(o.Ss); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.ss exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for ai, a := range o.Ss {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.ss exceeds %d bytes", ColferSizeMax))
+			}
+			if !utf8.ValidString(a) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8", ai))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := len(o.As); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.as exceeds %d elements", ColferListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.As {
+			x = len(a)
+			if x > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.as exceeds %d bytes", ColferSizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		l += 2
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		l += 3
+	} else if x != 0 {
+		l += 2
+	}
+
+	if x := len(o.F32s); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s exceeds %d elements", ColferListMax))
+		}
+		for l += 2 + x*4; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.F64s); x != 0 {
+		if x > ColferListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s exceeds %d elements", ColferListMax))
+		}
+		for l += 2 + x*8; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	l += len(o.unknown)
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// All nil entries in o.Os will be replaced with a new value.
+// The error return option is ColferMax or ColferUTF8.
+func (o *O) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// All nil entries in o.Os will be replaced with a new value.
+// The error return option is ColferMax or ColferUTF8, in which case dst is returned as is.
+func (o *O) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// Data structures are validated, yet they remain serial until their Load
+// method decodes them. Such serials refer to data, so data must not change
+// afterwards.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, false, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// When keep is set, then data is the entire serial of o, and any fields beyond
+// the schema's (a forward-compatible extension) are retained, as is, for the
+// next marshal. Such unknown fields are walked on their headers, yet nested
+// data structures are validated on their terminator only.
+// The depth is the nesting level of o.
+func (o *O) unmarshal(data []byte, keep bool, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	o.unknown = o.unknown[:0]
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte %d", start))
+		}
+		o.S = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		// no allocation before the data is there
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		v := make([]byte, int(x))
+		copy(v, data[start:i])
+		o.A = v
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		start := i
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+		o.O = nil
+		o.lazyO = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		start := i
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+		for l := int(x); l > 0; l-- {
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Os = nil
+		o.lazyOs = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := make([]string, int(x))
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := make([][]byte, int(x))
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			// no allocation before the data is there
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			v := make([]byte, int(x))
+			copy(v, data[start:i])
+			a[ai] = v
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float32, l)
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float64, l)
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		if keep && header&0x7f >= 18 && data[len(data)-1] == 0x7f {
+			if len(data) > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			if !colferUnknownValid(data, i-1, len(data)-1) {
+				return 0, ColferError(i - 1)
+			}
+			o.unknown = append(o.unknown[:0], data[i-1:len(data)-1]...)
+			return len(data), nil
+		}
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *O) Reset() {
+	*o = O{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *O) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	prev := *o
+	*o = O{}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte %d", start))
+		}
+		o.S = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		// no allocation before the data is there
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		v := prev.A
+		if v != nil && cap(v) >= int(x) {
+			v = v[:x]
+		} else {
+			v = make([]byte, int(x))
+		}
+		copy(v, data[start:i])
+		o.A = v
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		start := i
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+		o.O = nil
+		o.lazyO = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		start := i
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+		for l := int(x); l > 0; l-- {
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Os = nil
+		o.lazyOs = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := prev.Ss
+		if a != nil && cap(a) >= int(x) {
+			a = a[:x]
+		} else {
+			a = make([]string, int(x))
+		}
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := prev.As
+		if a == nil {
+			a = make([][]byte, int(x))
+		} else if cap(a) >= int(x) {
+			a = a[:x]
+		} else {
+			a = append(a[:cap(a)], make([][]byte, int(x)-cap(a))...)
+		}
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			// no allocation before the data is there
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			v := a[ai]
+			if v != nil && cap(v) >= int(x) {
+				v = v[:x]
+			} else {
+				v = make([]byte, int(x))
+			}
+			copy(v, data[start:i])
+			a[ai] = v
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := prev.F32s
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]float32, l)
+		}
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := prev.F64s
+		if a != nil && cap(a) >= l {
+			a = a[:l]
+		} else {
+			a = make([]float64, l)
+		}
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// Fields with an index beyond the schema are retained for the next marshal,
+// provided that they trail the known ones. The type of such unknown fields
+// can not be derived from their header. Their bytes are therefore retained
+// as is, provided that some choice of datatypes walks the headers in
+// ascending index order up to the terminator. Nested data structures and list
+// elements do not support unknown fields for the same reason, as their end
+// can not be found.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) UnmarshalBinary(data []byte) error {
+	i, err := o.unmarshal(data, true, 1)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value encodes o as Colfer conform driver.Valuer, with a nil o as SQL NULL.
+// The error return option is ColferMax or ColferUTF8.
+func (o *O) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes a column value as Colfer conform sql.Scanner. The source must be
+// either a []byte or a string with one serial exactly. SQL NULL resets o to its
+// zero value. Scan into a **O with database/sql for nil on NULL instead.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax,
+// with an error of another type for other source types.
+func (o *O) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = O{}
+		return nil
+	case []byte:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		// the driver owns the memory, while pending serials refer to data
+		data = append([]byte(nil), v...)
+	case string:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o can't scan SQL value of type %T", src)
+	}
+	return o.UnmarshalBinary(data)
+}
+
+// ColferDescriptor returns the static description of O, which must
+// not be modified.
+func (*O) ColferDescriptor() *ColferDescriptor {
+	return &colferDescriptorO
+}
+
+// ColferGet returns the value of a field by index, with the type of the field
+// as an interface. Data structures decode like their Load method does.
+func (o *O) ColferGet(index int) (interface{}, error) {
+	switch index {
+	case 0:
+		return o.B, nil
+	case 1:
+		return o.U32, nil
+	case 2:
+		return o.U64, nil
+	case 3:
+		return o.I32, nil
+	case 4:
+		return o.I64, nil
+	case 5:
+		return o.F32, nil
+	case 6:
+		return o.F64, nil
+	case 7:
+		return o.T, nil
+	case 8:
+		return o.S, nil
+	case 9:
+		return o.A, nil
+	case 10:
+		return o.LoadO()
+	case 11:
+		return o.LoadOs()
+	case 12:
+		return o.Ss, nil
+	case 13:
+		return o.As, nil
+	case 14:
+		return o.U8, nil
+	case 15:
+		return o.U16, nil
+	case 16:
+		return o.F32s, nil
+	case 17:
+		return o.F64s, nil
+	}
+	return nil, fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o has no field index %d", index)
+}
+
+// ColferSet assigns the value of a field by index, with the type of the field
+// as an interface. An untyped nil sets the zero value. Typed nils must match
+// the type of the field, like any other value.
+func (o *O) ColferSet(index int, value interface{}) error {
+	switch index {
+	case 0:
+		var v bool
+		if value != nil {
+			var ok bool
+			v, ok = value.(bool)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.b can't take a value of type %T", value)
+			}
+		}
+		o.B = v
+		return nil
+	case 1:
+		var v uint32
+		if value != nil {
+			var ok bool
+			v, ok = value.(uint32)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.u32 can't take a value of type %T", value)
+			}
+		}
+		o.U32 = v
+		return nil
+	case 2:
+		var v uint64
+		if value != nil {
+			var ok bool
+			v, ok = value.(uint64)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.u64 can't take a value of type %T", value)
+			}
+		}
+		o.U64 = v
+		return nil
+	case 3:
+		var v int32
+		if value != nil {
+			var ok bool
+			v, ok = value.(int32)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.i32 can't take a value of type %T", value)
+			}
+		}
+		o.I32 = v
+		return nil
+	case 4:
+		var v int64
+		if value != nil {
+			var ok bool
+			v, ok = value.(int64)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.i64 can't take a value of type %T", value)
+			}
+		}
+		o.I64 = v
+		return nil
+	case 5:
+		var v float32
+		if value != nil {
+			var ok bool
+			v, ok = value.(float32)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.f32 can't take a value of type %T", value)
+			}
+		}
+		o.F32 = v
+		return nil
+	case 6:
+		var v float64
+		if value != nil {
+			var ok bool
+			v, ok = value.(float64)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.f64 can't take a value of type %T", value)
+			}
+		}
+		o.F64 = v
+		return nil
+	case 7:
+		var v time.Time
+		if value != nil {
+			var ok bool
+			v, ok = value.(time.Time)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.t can't take a value of type %T", value)
+			}
+		}
+		o.T = v
+		return nil
+	case 8:
+		var v string
+		if value != nil {
+			var ok bool
+			v, ok = value.(string)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.s can't take a value of type %T", value)
+			}
+		}
+		o.S = v
+		return nil
+	case 9:
+		var v []byte
+		if value != nil {
+			var ok bool
+			v, ok = value.([]byte)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.a can't take a value of type %T", value)
+			}
+		}
+		o.A = v
+		return nil
+	case 10:
+		var v *O
+		if value != nil {
+			var ok bool
+			v, ok = value.(*O)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.o can't take a value of type %T", value)
+			}
+		}
+		o.O = v
+		o.lazyO = nil
+		return nil
+	case 11:
+		var v []*O
+		if value != nil {
+			var ok bool
+			v, ok = value.([]*O)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.os can't take a value of type %T", value)
+			}
+		}
+		o.Os = v
+		o.lazyOs = nil
+		return nil
+	case 12:
+		var v []string
+		if value != nil {
+			var ok bool
+			v, ok = value.([]string)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.ss can't take a value of type %T", value)
+			}
+		}
+		o.Ss = v
+		return nil
+	case 13:
+		var v [][]byte
+		if value != nil {
+			var ok bool
+			v, ok = value.([][]byte)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.as can't take a value of type %T", value)
+			}
+		}
+		o.As = v
+		return nil
+	case 14:
+		var v uint8
+		if value != nil {
+			var ok bool
+			v, ok = value.(uint8)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.u8 can't take a value of type %T", value)
+			}
+		}
+		o.U8 = v
+		return nil
+	case 15:
+		var v uint16
+		if value != nil {
+			var ok bool
+			v, ok = value.(uint16)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.u16 can't take a value of type %T", value)
+			}
+		}
+		o.U16 = v
+		return nil
+	case 16:
+		var v []float32
+		if value != nil {
+			var ok bool
+			v, ok = value.([]float32)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s can't take a value of type %T", value)
+			}
+		}
+		o.F32s = v
+		return nil
+	case 17:
+		var v []float64
+		if value != nil {
+			var ok bool
+			v, ok = value.([]float64)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s can't take a value of type %T", value)
+			}
+		}
+		o.F64s = v
+		return nil
+	}
+	return fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o has no field index %d", index)
+}
+
+// FieldMask returns the fields with their name from the schema as a mask.
+func (*O) FieldMask(names ...string) (ColferMask, error) {
+	var m ColferMask
+	for _, name := range names {
+		switch name {
+		case "b":
+			m = m.With(0)
+		case "u32":
+			m = m.With(1)
+		case "u64":
+			m = m.With(2)
+		case "i32":
+			m = m.With(3)
+		case "i64":
+			m = m.With(4)
+		case "f32":
+			m = m.With(5)
+		case "f64":
+			m = m.With(6)
+		case "t":
+			m = m.With(7)
+		case "s":
+			m = m.With(8)
+		case "a":
+			m = m.With(9)
+		case "o":
+			m = m.With(10)
+		case "os":
+			m = m.With(11)
+		case "ss":
+			m = m.With(12)
+		case "as":
+			m = m.With(13)
+		case "u8":
+			m = m.With(14)
+		case "u16":
+			m = m.With(15)
+		case "f32s":
+			m = m.With(16)
+		case "f64s":
+			m = m.With(17)
+		default:
+			return m, fmt.Errorf("colfer: field %q not in github.com/pascaldekloe/colfer/go/lazy/gen.o", name)
+		}
+	}
+	return m, nil
+}
+
+// UnmarshalFields decodes data as Colfer like Unmarshal does, yet it sets the
+// fields in mask only. Nested data structures in mask are decoded like
+// Unmarshal does.
+// All other fields are skipped without allocation, while their structure and
+// their limits are validated still.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) UnmarshalFields(data []byte, mask ColferMask) (int, error) {
+	return o.unmarshalFields(data, mask, 1)
+}
+
+// UnmarshalFields decodes data as Colfer like UnmarshalFields, with depth as
+// the nesting level of o.
+func (o *O) unmarshalFields(data []byte, mask ColferMask, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if mask.Has(0) {
+		if header == 0 {
+			if i >= len(data) {
+				goto eof
+			}
+			o.B = true
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 0 {
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(1) {
+		if header == 1 {
+			start := i
+			i++
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint32(data[start])
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint32(data[i])
+					i++
+					if i >= len(data) {
+						goto eof
+					}
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+			o.U32 = x
+
+			header = data[i]
+			i++
+		} else if header == 1|0x80 {
+			start := i
+			i += 4
+			if i >= len(data) {
+				goto eof
+			}
+			o.U32 = intconv.Uint32(data[start:])
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 1 || header == 1|0x80 {
+			if header == 1|0x80 {
+				i += 4
+			} else {
+				for n := 1; ; n++ {
+					if i >= len(data) {
+						goto eof
+					}
+					b := data[i]
+					i++
+					if b < 0x80 {
+						break
+					}
+				}
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(2) {
+		if header == 2 {
+			start := i
+			i++
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint64(data[start])
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint64(data[i])
+					i++
+					if i >= len(data) {
+						goto eof
+					}
+
+					if b < 0x80 || shift == 56 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+			o.U64 = x
+
+			header = data[i]
+			i++
+		} else if header == 2|0x80 {
+			start := i
+			i += 8
+			if i >= len(data) {
+				goto eof
+			}
+			o.U64 = intconv.Uint64(data[start:])
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 2 || header == 2|0x80 {
+			if header == 2|0x80 {
+				i += 8
+			} else {
+				for n := 1; ; n++ {
+					if i >= len(data) {
+						goto eof
+					}
+					b := data[i]
+					i++
+					if b < 0x80 || n == 9 {
+						break
+					}
+				}
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(3) {
+		if header == 3 {
+			if i+1 >= len(data) {
+				i++
+				goto eof
+			}
+			x := uint32(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint32(data[i])
+					i++
+					if i >= len(data) {
+						goto eof
+					}
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+			o.I32 = int32(x)
+
+			header = data[i]
+			i++
+		} else if header == 3|0x80 {
+			if i+1 >= len(data) {
+				i++
+				goto eof
+			}
+			x := uint32(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint32(data[i])
+					i++
+					if i >= len(data) {
+						goto eof
+					}
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+			o.I32 = int32(^x + 1)
+
+			header = data[i]
+			i++
+		}
+
+	} else {
+		if header == 3 || header == 3|0x80 {
+			{
+				for n := 1; ; n++ {
+					if i >= len(data) {
+						goto eof
+					}
+					b := data[i]
+					i++
+					if b < 0x80 {
+						break
+					}
+				}
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(4) {
+		if header == 4 {
+			if i+1 >= len(data) {
+				i++
+				goto eof
+			}
+			x := uint64(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint64(data[i])
+					i++
+					if i >= len(data) {
+						goto eof
+					}
+
+					if b < 0x80 || shift == 56 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+			o.I64 = int64(x)
+
+			header = data[i]
+			i++
+		} else if header == 4|0x80 {
+			if i+1 >= len(data) {
+				i++
+				goto eof
+			}
+			x := uint64(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint64(data[i])
+					i++
+					if i >= len(data) {
+						goto eof
+					}
+
+					if b < 0x80 || shift == 56 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+			o.I64 = int64(^x + 1)
+
+			header = data[i]
+			i++
+		}
+
+	} else {
+		if header == 4 || header == 4|0x80 {
+			{
+				for n := 1; ; n++ {
+					if i >= len(data) {
+						goto eof
+					}
+					b := data[i]
+					i++
+					if b < 0x80 || n == 9 {
+						break
+					}
+				}
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(5) {
+		if header == 5 {
+			start := i
+			i += 4
+			if i >= len(data) {
+				goto eof
+			}
+			o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 5 {
+			i += 4
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(6) {
+		if header == 6 {
+			start := i
+			i += 8
+			if i >= len(data) {
+				goto eof
+			}
+			o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 6 {
+			i += 8
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(7) {
+		if header == 7 {
+			start := i
+			i += 8
+			if i >= len(data) {
+				goto eof
+			}
+			o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+			header = data[i]
+			i++
+		} else if header == 7|0x80 {
+			start := i
+			i += 12
+			if i >= len(data) {
+				goto eof
+			}
+			o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 7 || header == 7|0x80 {
+			if header == 7 {
+				i += 8
+			} else {
+				i += 12
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(8) {
+		if header == 8 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte %d", start))
+			}
+			o.S = string(data[start:i])
+
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 8 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+			}
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte %d", start))
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(9) {
+		if header == 9 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+			}
+
+			// no allocation before the data is there
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			v := make([]byte, int(x))
+			copy(v, data[start:i])
+			o.A = v
+
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 9 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+			}
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(10) {
+		if header == 10 {
+			start := i
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+			o.O = nil
+			o.lazyO = data[start:i]
+			o.lazyDepth = depth
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 10 {
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(11) {
+		if header == 11 {
+			start := i
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+			}
+			for l := int(x); l > 0; l-- {
+				// nil receiver for no allocation, as there is no field to set
+				n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+				if err != nil {
+					if err == io.EOF && len(data) >= ColferSizeMax {
+						return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+					}
+					return 0, err
+				}
+				i += n
+			}
+			o.Os = nil
+			o.lazyOs = data[start:i]
+			o.lazyDepth = depth
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 11 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+			}
+			for l := int(x); l > 0; l-- {
+				// nil receiver for no allocation, as there is no field to set
+				n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+				if err != nil {
+					if err == io.EOF && len(data) >= ColferSizeMax {
+						return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+					}
+					return 0, err
+				}
+				i += n
+			}
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(12) {
+		if header == 12 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+			}
+			if end := i + int(x); end >= len(data) {
+				i = end // elements take one byte at least
+				goto eof
+			}
+			a := make([]string, int(x))
+			o.Ss = a
+
+			for ai := range a {
+				if i >= len(data) {
+					goto eof
+				}
+				x := uint(data[i])
+				i++
+
+				if x >= 0x80 {
+					x &= 0x7f
+					for shift := uint(7); ; shift += 7 {
+						if i >= len(data) {
+							goto eof
+						}
+						b := uint(data[i])
+						i++
+
+						if b < 0x80 {
+							x |= b << shift
+							break
+						}
+						x |= (b & 0x7f) << shift
+					}
+				}
+
+				if x > uint(ColferSizeMax) {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+				}
+
+				start := i
+				i += int(x)
+				if i >= len(data) {
+					goto eof
+				}
+				if !utf8.Valid(data[start:i]) {
+					return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+				}
+				a[ai] = string(data[start:i])
+			}
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 12 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+			}
+			for ai, l := 0, int(x); ai < l; ai++ {
+				if i >= len(data) {
+					goto eof
+				}
+				x := uint(data[i])
+				i++
+
+				if x >= 0x80 {
+					x &= 0x7f
+					for shift := uint(7); ; shift += 7 {
+						if i >= len(data) {
+							goto eof
+						}
+						b := uint(data[i])
+						i++
+
+						if b < 0x80 {
+							x |= b << shift
+							break
+						}
+						x |= (b & 0x7f) << shift
+					}
+				}
+
+				if x > uint(ColferSizeMax) {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+				}
+				start := i
+				i += int(x)
+				if i >= len(data) {
+					goto eof
+				}
+				if !utf8.Valid(data[start:i]) {
+					return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+				}
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(13) {
+		if header == 13 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+			}
+			if end := i + int(x); end >= len(data) {
+				i = end // elements take one byte at least
+				goto eof
+			}
+			a := make([][]byte, int(x))
+			o.As = a
+			for ai := range a {
+				if i >= len(data) {
+					goto eof
+				}
+				x := uint(data[i])
+				i++
+
+				if x >= 0x80 {
+					x &= 0x7f
+					for shift := uint(7); ; shift += 7 {
+						if i >= len(data) {
+							goto eof
+						}
+						b := uint(data[i])
+						i++
+
+						if b < 0x80 {
+							x |= b << shift
+							break
+						}
+						x |= (b & 0x7f) << shift
+					}
+				}
+
+				if x > uint(ColferSizeMax) {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+				}
+
+				// no allocation before the data is there
+				start := i
+				i += int(x)
+				if i >= len(data) {
+					goto eof
+				}
+				v := make([]byte, int(x))
+				copy(v, data[start:i])
+				a[ai] = v
+			}
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 13 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+			}
+			for ai, l := 0, int(x); ai < l; ai++ {
+				if i >= len(data) {
+					goto eof
+				}
+				x := uint(data[i])
+				i++
+
+				if x >= 0x80 {
+					x &= 0x7f
+					for shift := uint(7); ; shift += 7 {
+						if i >= len(data) {
+							goto eof
+						}
+						b := uint(data[i])
+						i++
+
+						if b < 0x80 {
+							x |= b << shift
+							break
+						}
+						x |= (b & 0x7f) << shift
+					}
+				}
+
+				if x > uint(ColferSizeMax) {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+				}
+				i += int(x)
+				if i >= len(data) {
+					goto eof
+				}
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(14) {
+		if header == 14 {
+			start := i
+			i++
+			if i >= len(data) {
+				goto eof
+			}
+			o.U8 = data[start]
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 14 {
+			i++
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(15) {
+		if header == 15 {
+			start := i
+			i += 2
+			if i >= len(data) {
+				goto eof
+			}
+			o.U16 = intconv.Uint16(data[start:])
+			header = data[i]
+			i++
+		} else if header == 15|0x80 {
+			start := i
+			i++
+			if i >= len(data) {
+				goto eof
+			}
+			o.U16 = uint16(data[start])
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 15 || header == 15|0x80 {
+			if header == 15 {
+				i += 2
+			} else {
+				i++
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(16) {
+		if header == 16 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+			}
+
+			l := int(x)
+
+			if end := i + l*4; end >= len(data) {
+				i = end
+				goto eof
+			}
+			a := make([]float32, l)
+			for ai := range a {
+				a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+				i += 4
+			}
+			o.F32s = a
+
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 16 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+			}
+			i += int(x) * 4
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if mask.Has(17) {
+		if header == 17 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+			}
+			l := int(x)
+
+			if end := i + l*8; end >= len(data) {
+				i = end
+				goto eof
+			}
+			a := make([]float64, l)
+			for ai := range a {
+				a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+				i += 8
+			}
+			o.F64s = a
+
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 17 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferListMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+			}
+			i += int(x) * 8
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalLen returns the number of bytes unmarshal would read from data, or
+// the error it would return, without decoding. Validation is the same. The
+// depth is the nesting level of o.
+func (*O) unmarshalLen(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 1 || header == 1|0x80 {
+		if header == 1|0x80 {
+			i += 4
+		} else {
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 {
+					break
+				}
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 2 || header == 2|0x80 {
+		if header == 2|0x80 {
+			i += 8
+		} else {
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 || n == 9 {
+					break
+				}
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 3 || header == 3|0x80 {
+		{
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 {
+					break
+				}
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 4 || header == 4|0x80 {
+		{
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 || n == 9 {
+					break
+				}
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 7 || header == 7|0x80 {
+		if header == 7 {
+			i += 8
+		} else {
+			i += 12
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte %d", start))
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+		for l := int(x); l > 0; l-- {
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		for ai, l := 0, int(x); ai < l; ai++ {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		for ai, l := 0, int(x); ai < l; ai++ {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 15 || header == 15|0x80 {
+		if header == 15 {
+			i += 2
+		} else {
+			i++
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+		i += int(x) * 4
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		i += int(x) * 8
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// LoadO returns o.O, with the serial from Unmarshal, if any,
+// decoded on first use. Assignments to o.O take precedence over such
+// serial, except for nil, which needs SetO instead. Decoding continues
+// at the nesting level of o, and the serial remains pending when decoding fails.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) LoadO() (*O, error) {
+	if data := o.lazyO; data != nil {
+		if o.O == nil {
+			v := new(O)
+			if _, err := v.unmarshal(data, false, o.lazyDepth+1); err != nil {
+				return nil, err
+			}
+			o.O = v
+		}
+		o.lazyO = nil
+	}
+	return o.O, nil
+}
+
+// SetO assigns v to o.O, and it discards any serial from
+// Unmarshal pending decoding. A nil v clears the field as such.
+func (o *O) SetO(v *O) {
+	o.O = v
+	o.lazyO = nil
+}
+
+// LoadOs returns o.Os, with the serial from Unmarshal, if any,
+// decoded on first use. Assignments to o.Os take precedence over such
+// serial, except for nil, which needs SetOs instead. Decoding continues
+// at the nesting level of o, and the serial remains pending when decoding fails.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) LoadOs() ([]*O, error) {
+	if data := o.lazyOs; data != nil {
+		if o.Os == nil {
+			x := uint(data[0])
+			i := 1
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			a := make([]*O, x)
+			malloc := make([]O, x)
+			for ai := range a {
+				v := &malloc[ai]
+				a[ai] = v
+
+				n, err := v.unmarshal(data[i:], false, o.lazyDepth+1)
+				if err != nil {
+					return nil, err
+				}
+				i += n
+			}
+			o.Os = a
+		}
+		o.lazyOs = nil
+	}
+	return o.Os, nil
+}
+
+// SetOs assigns v to o.Os, and it discards any serial from
+// Unmarshal pending decoding. A nil v clears the field as such.
+func (o *O) SetOs(v []*O) {
+	o.Os = v
+	o.lazyOs = nil
+}
+
+// Loaded returns o with all pending serials decoded, as a copy when needed.
+// Unmarshal validated the serials, so decoding fails only when the limits
+// changed since.
+func (o *O) loaded() (*O, error) {
+	c := *o
+	var pending bool
+	if c.lazyO != nil {
+		pending = true
+		if _, err := c.LoadO(); err != nil {
+			return nil, err
+		}
+	}
+	if c.lazyOs != nil {
+		pending = true
+		if _, err := c.LoadOs(); err != nil {
+			return nil, err
+		}
+	}
+	if !pending {
+		return o, nil
+	}
+	return &c, nil
+}
+
+// EachOs decodes data as Colfer like Unmarshal does, yet it passes the
+// elements of field os to yield, one at a time, instead of setting
+// o.Os. All other fields are set before the first call. The element is
+// reused, so yield must not retain it, nor any of its slices or nested data
+// structures. Iteration stops on the first error from yield, which is
+// returned as is.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *O) EachOs(data []byte, yield func(*O) error) (int, error) {
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+	const depth = 1 // nesting level of o
+	var list []byte
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte %d", start))
+		}
+		o.S = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		// no allocation before the data is there
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		v := make([]byte, int(x))
+		copy(v, data[start:i])
+		o.A = v
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		start := i
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+		o.O = nil
+		o.lazyO = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		start := i
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length %d exceeds %d elements", x, ColferListMax))
+		}
+		for l := int(x); l > 0; l-- {
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		list = data[start:i]
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := make([]string, int(x))
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element %d has malformed UTF-8 at byte %d", ai, start))
+			}
+			a[ai] = string(data[start:i])
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length %d exceeds %d elements", x, ColferListMax))
+		}
+		if end := i + int(x); end >= len(data) {
+			i = end // elements take one byte at least
+			goto eof
+		}
+		a := make([][]byte, int(x))
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			}
+
+			// no allocation before the data is there
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			v := make([]byte, int(x))
+			copy(v, data[start:i])
+			a[ai] = v
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float32, l)
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		a := make([]float64, l)
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		if list != nil {
+			// element count precedes the serials
+			x := uint(list[0])
+			li := 1
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					b := uint(list[li])
+					li++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			var v O
+			for ; x != 0; x-- {
+				n, err := v.unmarshalReuse(list[li:], depth+1)
+				if err != nil {
+					return 0, err
+				}
+				li += n
+
+				if err := yield(&v); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.o size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *O) Equal(other *O) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	a, err := o.loaded()
+	if err == nil {
+		var b *O
+		if b, err = other.loaded(); err == nil {
+			o, other = a, b
+		}
+	}
+	if err != nil {
+		// compare the pending serials as is
+		x, errX := o.MarshalBinary()
+		y, errY := other.MarshalBinary()
+		return errX == nil && errY == nil && string(x) == string(y)
+	}
+
+	if o.B != other.B {
+		return false
+	}
+
+	if o.U32 != other.U32 {
+		return false
+	}
+
+	if o.U64 != other.U64 {
+		return false
+	}
+
+	if o.I32 != other.I32 {
+		return false
+	}
+
+	if o.I64 != other.I64 {
+		return false
+	}
+
+	if v, w := o.F32, other.F32; v != w && (v == v || w == w) {
+		return false
+	}
+
+	if v, w := o.F64, other.F64; v != w && (v == v || w == w) {
+		return false
+	}
+
+	if !o.T.Equal(other.T) {
+		return false
+	}
+
+	if o.S != other.S {
+		return false
+	}
+
+	if !bytes.Equal(o.A, other.A) {
+		return false
+	}
+
+	if !o.O.Equal(other.O) {
+		return false
+	}
+
+	if len(o.Os) != len(other.Os) {
+		return false
+	}
+	for i, v := range o.Os {
+		w := other.Os[i]
+		if v == nil {
+			v = new(O)
+		}
+		if w == nil {
+			w = new(O)
+		}
+		if !v.Equal(w) {
+			return false
+		}
+	}
+
+	if len(o.Ss) != len(other.Ss) {
+		return false
+	}
+	for i, v := range o.Ss {
+		if v != other.Ss[i] {
+			return false
+		}
+	}
+
+	if len(o.As) != len(other.As) {
+		return false
+	}
+	for i, v := range o.As {
+		if !bytes.Equal(v, other.As[i]) {
+			return false
+		}
+	}
+
+	if o.U8 != other.U8 {
+		return false
+	}
+
+	if o.U16 != other.U16 {
+		return false
+	}
+
+	if len(o.F32s) != len(other.F32s) {
+		return false
+	}
+	for i, v := range o.F32s {
+		if w := other.F32s[i]; v != w && (v == v || w == w) {
+			return false
+		}
+	}
+
+	if len(o.F64s) != len(other.F64s) {
+		return false
+	}
+	for i, v := range o.F64s {
+		if w := other.F64s[i]; v != w && (v == v || w == w) {
+			return false
+		}
+	}
+
+	if !bytes.Equal(o.unknown, other.unknown) {
+		return false
+	}
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *O) Clone() *O {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if o.A != nil {
+		c.A = append(make([]byte, 0, len(o.A)), o.A...)
+	}
+
+	c.O = o.O.Clone()
+
+	if o.Os != nil {
+		c.Os = make([]*O, len(o.Os))
+		for i, v := range o.Os {
+			c.Os[i] = v.Clone()
+		}
+	}
+
+	if o.Ss != nil {
+		c.Ss = make([]string, len(o.Ss))
+		copy(c.Ss, o.Ss)
+	}
+
+	if o.As != nil {
+		c.As = make([][]byte, len(o.As))
+		for i, v := range o.As {
+			if v != nil {
+				c.As[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+	}
+
+	if o.F32s != nil {
+		c.F32s = make([]float32, len(o.F32s))
+		copy(c.F32s, o.F32s)
+	}
+
+	if o.F64s != nil {
+		c.F64s = make([]float64, len(o.F64s))
+		copy(c.F64s, o.F64s)
+	}
+
+	if o.unknown != nil {
+		c.unknown = append([]byte(nil), o.unknown...)
+	}
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *O) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	o, err := o.loaded()
+	if err != nil {
+		return "gen.o{" + err.Error() + "}"
+	}
+	var fields []string
+
+	if o.B {
+		fields = append(fields, "b: true")
+	}
+
+	if v := o.U32; v != 0 {
+		fields = append(fields, fmt.Sprint("u32: ", v))
+	}
+
+	if v := o.U64; v != 0 {
+		fields = append(fields, fmt.Sprint("u64: ", v))
+	}
+
+	if v := o.I32; v != 0 {
+		fields = append(fields, fmt.Sprint("i32: ", v))
+	}
+
+	if v := o.I64; v != 0 {
+		fields = append(fields, fmt.Sprint("i64: ", v))
+	}
+
+	if v := o.F32; v != 0 {
+		fields = append(fields, fmt.Sprint("f32: ", v))
+	}
+
+	if v := o.F64; v != 0 {
+		fields = append(fields, fmt.Sprint("f64: ", v))
+	}
+
+	if v := o.T; !v.IsZero() {
+		fields = append(fields, "t: "+v.UTC().Format(time.RFC3339Nano))
+	}
+
+	if v := o.S; v != "" {
+		fields = append(fields, "s: "+strconv.Quote(v))
+	}
+
+	if v := o.A; len(v) != 0 {
+		fields = append(fields, fmt.Sprintf("a: 0x%x", v))
+	}
+
+	if v := o.O; v != nil {
+		fields = append(fields, "o: "+v.String())
+	}
+
+	if len(o.Os) != 0 {
+		a := make([]string, len(o.Os))
+		for i, v := range o.Os {
+			a[i] = v.String()
+		}
+		fields = append(fields, "os: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if len(o.Ss) != 0 {
+		a := make([]string, len(o.Ss))
+		for i, v := range o.Ss {
+			a[i] = strconv.Quote(v)
+		}
+		fields = append(fields, "ss: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if len(o.As) != 0 {
+		a := make([]string, len(o.As))
+		for i, v := range o.As {
+			a[i] = fmt.Sprintf("0x%x", v)
+		}
+		fields = append(fields, "as: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if v := o.U8; v != 0 {
+		fields = append(fields, fmt.Sprint("u8: ", v))
+	}
+
+	if v := o.U16; v != 0 {
+		fields = append(fields, fmt.Sprint("u16: ", v))
+	}
+
+	if len(o.F32s) != 0 {
+		a := make([]string, len(o.F32s))
+		for i, v := range o.F32s {
+			a[i] = fmt.Sprint(v)
+		}
+		fields = append(fields, "f32s: ["+strings.Join(a, ", ")+"]")
+	}
+
+	if len(o.F64s) != 0 {
+		a := make([]string, len(o.F64s))
+		for i, v := range o.F64s {
+			a[i] = fmt.Sprint(v)
+		}
+		fields = append(fields, "f64s: ["+strings.Join(a, ", ")+"]")
+	}
+
+	return "gen.o{" + strings.Join(fields, ", ") + "}"
+}
+
+// MarshalJSON encodes o as a JSON object conform json.Marshaler. The members
+// are in order of the schema, with the field names from the schema.
+// Timestamps are RFC 3339 strings, except for years beyond the 0–9999 range,
+// which are decimal numbers of seconds since the Unix epoch instead. Binaries
+// are base64 strings (standard encoding). Not-a-number and infinite floating
+// points are the strings "NaN", "Infinity" and "-Infinity". Zero timestamps
+// and absent structs are null.
+// The error return option is ColferMax or ColferUTF8, conform MarshalLen.
+// Pending serials which fail to decode return the error of their Load method.
+func (o *O) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+	if _, err := o.MarshalLen(); err != nil {
+		return nil, err
+	}
+	return o.appendJSON(nil)
+}
+
+// AppendJSON encodes o, which must pass MarshalLen, as JSON to the end of buf.
+func (o *O) appendJSON(buf []byte) ([]byte, error) {
+	if o == nil {
+		return append(buf, "null"...), nil
+	}
+	o, err := o.loaded()
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, '{')
+
+	buf = append(buf, "\"b\":"...)
+	buf = strconv.AppendBool(buf, o.B)
+
+	buf = append(buf, ",\"u32\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U32), 10)
+
+	buf = append(buf, ",\"u64\":"...)
+	buf = strconv.AppendUint(buf, o.U64, 10)
+
+	buf = append(buf, ",\"i32\":"...)
+	buf = strconv.AppendInt(buf, int64(o.I32), 10)
+
+	buf = append(buf, ",\"i64\":"...)
+	buf = strconv.AppendInt(buf, o.I64, 10)
+
+	buf = append(buf, ",\"f32\":"...)
+	buf = colferJSONFloat(buf, float64(o.F32), 32)
+
+	buf = append(buf, ",\"f64\":"...)
+	buf = colferJSONFloat(buf, o.F64, 64)
+
+	buf = append(buf, ",\"t\":"...)
+	buf = colferJSONTime(buf, o.T)
+
+	buf = append(buf, ",\"s\":"...)
+	buf = colferJSONText(buf, o.S)
+
+	buf = append(buf, ",\"a\":"...)
+	buf = colferJSONBinary(buf, o.A)
+
+	buf = append(buf, ",\"o\":"...)
+	buf, err = o.O.appendJSON(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	buf = append(buf, ",\"os\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Os {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf, err = v.appendJSON(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	buf = append(buf, ']')
+
+	buf = append(buf, ",\"ss\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.Ss {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferJSONText(buf, v)
+	}
+	buf = append(buf, ']')
+
+	buf = append(buf, ",\"as\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.As {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferJSONBinary(buf, v)
+	}
+	buf = append(buf, ']')
+
+	buf = append(buf, ",\"u8\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U8), 10)
+
+	buf = append(buf, ",\"u16\":"...)
+	buf = strconv.AppendUint(buf, uint64(o.U16), 10)
+
+	buf = append(buf, ",\"f32s\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.F32s {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferJSONFloat(buf, float64(v), 32)
+	}
+	buf = append(buf, ']')
+
+	buf = append(buf, ",\"f64s\":"...)
+	buf = append(buf, '[')
+	for i, v := range o.F64s {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = colferJSONFloat(buf, v, 64)
+	}
+	buf = append(buf, ']')
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON decodes data conform json.Unmarshaler, with the format of
+// MarshalJSON. Absent members and null values leave the respective fields as
+// is. Numbers must fit their type without loss.
+// The error return options include ColferDepth and ColferMax.
+func (o *O) UnmarshalJSON(data []byte) error {
+	r := colferJSON{data: data}
+	if err := o.unmarshalJSON(&r); err != nil {
+		return err
+	}
+	if r.peek() != 0 {
+		return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o: JSON continuation at byte %d", r.i)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the JSON object from r.
+func (o *O) unmarshalJSON(r *colferJSON) error {
+	if r.peek() != '{' {
+		return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o: %w", r.typeError("object"))
+	}
+	r.depth++
+	if r.depth > ColferDepthMax {
+		return ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds %d levels", ColferDepthMax))
+	}
+	r.i++
+	for first := true; ; first = false {
+		more, err := r.next('}', first)
+		if err != nil {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o: %w", err)
+		}
+		if !more {
+			r.depth--
+			return nil
+		}
+		name, err := r.text()
+		if err != nil {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o: %w", err)
+		}
+		if !r.consume(":") {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o: %w", r.syntaxError())
+		}
+
+		switch name {
+		case "b":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.boolean()
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.b: %w", err)
+			}
+			o.B = v
+		case "u32":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.unsigned(32)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.u32: %w", err)
+			}
+			o.U32 = uint32(v)
+		case "u64":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.unsigned(64)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.u64: %w", err)
+			}
+			o.U64 = v
+		case "i32":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.signed(32)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.i32: %w", err)
+			}
+			o.I32 = int32(v)
+		case "i64":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.signed(64)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.i64: %w", err)
+			}
+			o.I64 = v
+		case "f32":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.float(32)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32: %w", err)
+			}
+			o.F32 = float32(v)
+		case "f64":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.float(64)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64: %w", err)
+			}
+			o.F64 = v
+		case "t":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.timestamp()
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.t: %w", err)
+			}
+			o.T = v
+		case "s":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.text()
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s: %w", err)
+			}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+			o.S = v
+		case "a":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.binary()
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a: %w", err)
+			}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.a size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+			o.A = v
+		case "o":
+			if r.consume("null") {
+				break
+			}
+			v := new(O)
+			if err := v.unmarshalJSON(r); err != nil {
+				return err
+			}
+			o.O = v
+		case "os":
+			if r.consume("null") {
+				break
+			}
+			if r.peek() != '[' {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os: %w", r.typeError("array"))
+			}
+			r.i++
+			var a []*O
+			for first := true; ; first = false {
+				more, err := r.next(']', first)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os: %w", err)
+				}
+				if !more {
+					break
+				}
+				if len(a) >= ColferListMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.os length exceeds %d elements", ColferListMax))
+				}
+				if r.consume("null") {
+					a = append(a, nil)
+					continue
+				}
+				v := new(O)
+				if err := v.unmarshalJSON(r); err != nil {
+					return err
+				}
+				a = append(a, v)
+			}
+			o.Os = a
+		case "ss":
+			if r.consume("null") {
+				break
+			}
+			if r.peek() != '[' {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss: %w", r.typeError("array"))
+			}
+			r.i++
+			var a []string
+			for first := true; ; first = false {
+				more, err := r.next(']', first)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss: %w", err)
+				}
+				if !more {
+					break
+				}
+				if len(a) >= ColferListMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss length exceeds %d elements", ColferListMax))
+				}
+				v, err := r.text()
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss: %w", err)
+				}
+				if len(v) > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss size %d exceeds %d bytes", len(v), ColferSizeMax))
+				}
+				a = append(a, v)
+			}
+			o.Ss = a
+		case "as":
+			if r.consume("null") {
+				break
+			}
+			if r.peek() != '[' {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as: %w", r.typeError("array"))
+			}
+			r.i++
+			var a [][]byte
+			for first := true; ; first = false {
+				more, err := r.next(']', first)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as: %w", err)
+				}
+				if !more {
+					break
+				}
+				if len(a) >= ColferListMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as length exceeds %d elements", ColferListMax))
+				}
+				v, err := r.binary()
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as: %w", err)
+				}
+				if len(v) > ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.as size %d exceeds %d bytes", len(v), ColferSizeMax))
+				}
+				a = append(a, v)
+			}
+			o.As = a
+		case "u8":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.unsigned(8)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.u8: %w", err)
+			}
+			o.U8 = uint8(v)
+		case "u16":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.unsigned(16)
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.u16: %w", err)
+			}
+			o.U16 = uint16(v)
+		case "f32s":
+			if r.consume("null") {
+				break
+			}
+			if r.peek() != '[' {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s: %w", r.typeError("array"))
+			}
+			r.i++
+			var a []float32
+			for first := true; ; first = false {
+				more, err := r.next(']', first)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s: %w", err)
+				}
+				if !more {
+					break
+				}
+				if len(a) >= ColferListMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s length exceeds %d elements", ColferListMax))
+				}
+				v, err := r.float(32)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f32s: %w", err)
+				}
+				a = append(a, float32(v))
+			}
+			o.F32s = a
+		case "f64s":
+			if r.consume("null") {
+				break
+			}
+			if r.peek() != '[' {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s: %w", r.typeError("array"))
+			}
+			r.i++
+			var a []float64
+			for first := true; ; first = false {
+				more, err := r.next(']', first)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s: %w", err)
+				}
+				if !more {
+					break
+				}
+				if len(a) >= ColferListMax {
+					return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s length exceeds %d elements", ColferListMax))
+				}
+				v, err := r.float(64)
+				if err != nil {
+					return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.f64s: %w", err)
+				}
+				a = append(a, v)
+			}
+			o.F64s = a
+
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in github.com/pascaldekloe/colfer/go/lazy/gen.o", name)
+		}
+	}
+}
+
+// DromedaryCase oposes name casings.
+type DromedaryCase struct {
+	PascalCase string `xml:"pascal-case" json:"pascal_case,omitempty"`
+
+	// unknown has the serial of trailing fields not in the schema.
+	unknown []byte
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *DromedaryCase) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.PascalCase); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.PascalCase)
+	}
+
+	i += copy(buf[i:], o.unknown)
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax or ColferUTF8.
+func (o *DromedaryCase) MarshalLen() (int, error) {
+	l := 1
+
+	if x := len(o.PascalCase); x != 0 {
+		if x > ColferSizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase exceeds %d bytes", ColferSizeMax))
+		}
+		if !utf8.ValidString(o.PascalCase) {
+			return 0, ColferUTF8("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase has malformed UTF-8")
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	l += len(o.unknown)
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is ColferMax or ColferUTF8.
+func (o *DromedaryCase) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// The error return option is ColferMax or ColferUTF8, in which case dst is returned as is.
+func (o *DromedaryCase) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, false, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// When keep is set, then data is the entire serial of o, and any fields beyond
+// the schema's (a forward-compatible extension) are retained, as is, for the
+// next marshal. Such unknown fields are walked on their headers, yet nested
+// data structures are validated on their terminator only.
+// The depth is the nesting level of o.
+func (o *DromedaryCase) unmarshal(data []byte, keep bool, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	o.unknown = o.unknown[:0]
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+		}
+		o.PascalCase = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		if keep && header&0x7f >= 1 && data[len(data)-1] == 0x7f {
+			if len(data) > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+			}
+			if !colferUnknownValid(data, i-1, len(data)-1) {
+				return 0, ColferError(i - 1)
+			}
+			o.unknown = append(o.unknown[:0], data[i-1:len(data)-1]...)
+			return len(data), nil
+		}
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *DromedaryCase) Reset() {
+	*o = DromedaryCase{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *DromedaryCase) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	*o = DromedaryCase{}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+		}
+		o.PascalCase = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// Fields with an index beyond the schema are retained for the next marshal,
+// provided that they trail the known ones. The type of such unknown fields
+// can not be derived from their header. Their bytes are therefore retained
+// as is, provided that some choice of datatypes walks the headers in
+// ascending index order up to the terminator. Nested data structures and list
+// elements do not support unknown fields for the same reason, as their end
+// can not be found.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalBinary(data []byte) error {
+	i, err := o.unmarshal(data, true, 1)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value encodes o as Colfer conform driver.Valuer, with a nil o as SQL NULL.
+// The error return option is ColferMax or ColferUTF8.
+func (o *DromedaryCase) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes a column value as Colfer conform sql.Scanner. The source must be
+// either a []byte or a string with one serial exactly. SQL NULL resets o to its
+// zero value. Scan into a **DromedaryCase with database/sql for nil on NULL instead.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax,
+// with an error of another type for other source types.
+func (o *DromedaryCase) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = DromedaryCase{}
+		return nil
+	case []byte:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		// the driver owns the memory, while pending serials refer to data
+		data = append([]byte(nil), v...)
+	case string:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase can't scan SQL value of type %T", src)
+	}
+	return o.UnmarshalBinary(data)
+}
+
+// ColferDescriptor returns the static description of DromedaryCase, which must
+// not be modified.
+func (*DromedaryCase) ColferDescriptor() *ColferDescriptor {
+	return &colferDescriptorDromedaryCase
+}
+
+// ColferGet returns the value of a field by index, with the type of the field
+// as an interface. Data structures decode like their Load method does.
+func (o *DromedaryCase) ColferGet(index int) (interface{}, error) {
+	switch index {
+	case 0:
+		return o.PascalCase, nil
+	}
+	return nil, fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase has no field index %d", index)
+}
+
+// ColferSet assigns the value of a field by index, with the type of the field
+// as an interface. An untyped nil sets the zero value. Typed nils must match
+// the type of the field, like any other value.
+func (o *DromedaryCase) ColferSet(index int, value interface{}) error {
+	switch index {
+	case 0:
+		var v string
+		if value != nil {
+			var ok bool
+			v, ok = value.(string)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase can't take a value of type %T", value)
+			}
+		}
+		o.PascalCase = v
+		return nil
+	}
+	return fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase has no field index %d", index)
+}
+
+// FieldMask returns the fields with their name from the schema as a mask.
+func (*DromedaryCase) FieldMask(names ...string) (ColferMask, error) {
+	var m ColferMask
+	for _, name := range names {
+		switch name {
+		case "PascalCase":
+			m = m.With(0)
+		default:
+			return m, fmt.Errorf("colfer: field %q not in github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase", name)
+		}
+	}
+	return m, nil
+}
+
+// UnmarshalFields decodes data as Colfer like Unmarshal does, yet it sets the
+// fields in mask only. Nested data structures in mask are decoded like
+// Unmarshal does.
+// All other fields are skipped without allocation, while their structure and
+// their limits are validated still.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalFields(data []byte, mask ColferMask) (int, error) {
+	return o.unmarshalFields(data, mask, 1)
+}
+
+// UnmarshalFields decodes data as Colfer like UnmarshalFields, with depth as
+// the nesting level of o.
+func (o *DromedaryCase) unmarshalFields(data []byte, mask ColferMask, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if mask.Has(0) {
+		if header == 0 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+			}
+			o.PascalCase = string(data[start:i])
+
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 0 {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(ColferSizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+			}
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if !utf8.Valid(data[start:i]) {
+				return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+			}
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalLen returns the number of bytes unmarshal would read from data, or
+// the error it would return, without decoding. Validation is the same. The
+// depth is the nesting level of o.
+func (*DromedaryCase) unmarshalLen(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if !utf8.Valid(data[start:i]) {
+			return 0, ColferUTF8(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase has malformed UTF-8 at byte %d", start))
+		}
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *DromedaryCase) Equal(other *DromedaryCase) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.PascalCase != other.PascalCase {
+		return false
+	}
+
+	if !bytes.Equal(o.unknown, other.unknown) {
+		return false
+	}
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *DromedaryCase) Clone() *DromedaryCase {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if o.unknown != nil {
+		c.unknown = append([]byte(nil), o.unknown...)
+	}
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *DromedaryCase) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	var fields []string
+
+	if v := o.PascalCase; v != "" {
+		fields = append(fields, "PascalCase: "+strconv.Quote(v))
+	}
+
+	return "gen.dromedaryCase{" + strings.Join(fields, ", ") + "}"
+}
+
+// MarshalJSON encodes o as a JSON object conform json.Marshaler. The members
+// are in order of the schema, with the field names from the schema.
+// Timestamps are RFC 3339 strings, except for years beyond the 0–9999 range,
+// which are decimal numbers of seconds since the Unix epoch instead. Binaries
+// are base64 strings (standard encoding). Not-a-number and infinite floating
+// points are the strings "NaN", "Infinity" and "-Infinity". Zero timestamps
+// and absent structs are null.
+// The error return option is ColferMax or ColferUTF8, conform MarshalLen.
+// Pending serials which fail to decode return the error of their Load method.
+func (o *DromedaryCase) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+	if _, err := o.MarshalLen(); err != nil {
+		return nil, err
+	}
+	return o.appendJSON(nil)
+}
+
+// AppendJSON encodes o, which must pass MarshalLen, as JSON to the end of buf.
+func (o *DromedaryCase) appendJSON(buf []byte) ([]byte, error) {
+	if o == nil {
+		return append(buf, "null"...), nil
+	}
+	buf = append(buf, '{')
+
+	buf = append(buf, "\"PascalCase\":"...)
+	buf = colferJSONText(buf, o.PascalCase)
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON decodes data conform json.Unmarshaler, with the format of
+// MarshalJSON. Absent members and null values leave the respective fields as
+// is. Numbers must fit their type without loss.
+// The error return options include ColferDepth and ColferMax.
+func (o *DromedaryCase) UnmarshalJSON(data []byte) error {
+	r := colferJSON{data: data}
+	if err := o.unmarshalJSON(&r); err != nil {
+		return err
+	}
+	if r.peek() != 0 {
+		return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase: JSON continuation at byte %d", r.i)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the JSON object from r.
+func (o *DromedaryCase) unmarshalJSON(r *colferJSON) error {
+	if r.peek() != '{' {
+		return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase: %w", r.typeError("object"))
+	}
+	r.depth++
+	if r.depth > ColferDepthMax {
+		return ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase exceeds %d levels", ColferDepthMax))
+	}
+	r.i++
+	for first := true; ; first = false {
+		more, err := r.next('}', first)
+		if err != nil {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase: %w", err)
+		}
+		if !more {
+			r.depth--
+			return nil
+		}
+		name, err := r.text()
+		if err != nil {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase: %w", err)
+		}
+		if !r.consume(":") {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase: %w", r.syntaxError())
+		}
+
+		switch name {
+		case "PascalCase":
+			if r.consume("null") {
+				break
+			}
+			v, err := r.text()
+			if err != nil {
+				return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase: %w", err)
+			}
+			if len(v) > ColferSizeMax {
+				return ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase.PascalCase size %d exceeds %d bytes", len(v), ColferSizeMax))
+			}
+			o.PascalCase = v
+
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in github.com/pascaldekloe/colfer/go/lazy/gen.dromedaryCase", name)
+		}
+	}
+}
+
+// EmbedO has an inner object only.
+// Covers regression of issue #66.
+type EmbedO struct {
+	Inner *O
+
+	// lazyInner has the serial of Inner, pending decoding.
+	lazyInner []byte
+
+	// lazyDepth is the nesting level of o, for decoding of pending serials.
+	lazyDepth int
+
+	// unknown has the serial of trailing fields not in the schema.
+	unknown []byte
+}
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *EmbedO) MarshalTo(buf []byte) int {
+	var i int
+
+	if v := o.Inner; v != nil {
+		buf[i] = 0
+		i++
+		i += v.MarshalTo(buf[i:])
+	} else if o.lazyInner != nil {
+		buf[i] = 0
+		i++
+		i += copy(buf[i:], o.lazyInner)
+	}
+
+	i += copy(buf[i:], o.unknown)
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is ColferMax or ColferUTF8.
+func (o *EmbedO) MarshalLen() (int, error) {
+	l := 1
+
+	if v := o.Inner; v != nil {
+		vl, err := v.MarshalLen()
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	} else if o.lazyInner != nil {
+		l += len(o.lazyInner) + 1
+	}
+
+	l += len(o.unknown)
+	if l > ColferSizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO exceeds %d bytes", ColferSizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is ColferMax or ColferUTF8.
+func (o *EmbedO) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// The error return option is ColferMax or ColferUTF8, in which case dst is returned as is.
+func (o *EmbedO) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// Data structures are validated, yet they remain serial until their Load
+// method decodes them. Such serials refer to data, so data must not change
+// afterwards.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, false, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// When keep is set, then data is the entire serial of o, and any fields beyond
+// the schema's (a forward-compatible extension) are retained, as is, for the
+// next marshal. Such unknown fields are walked on their headers, yet nested
+// data structures are validated on their terminator only.
+// The depth is the nesting level of o.
+func (o *EmbedO) unmarshal(data []byte, keep bool, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	o.unknown = o.unknown[:0]
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		start := i
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+		o.Inner = nil
+		o.lazyInner = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		if keep && header&0x7f >= 1 && data[len(data)-1] == 0x7f {
+			if len(data) > ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+			}
+			if !colferUnknownValid(data, i-1, len(data)-1) {
+				return 0, ColferError(i - 1)
+			}
+			o.unknown = append(o.unknown[:0], data[i-1:len(data)-1]...)
+			return len(data), nil
+		}
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *EmbedO) Reset() {
+	*o = EmbedO{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *EmbedO) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	*o = EmbedO{}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		start := i
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+		o.Inner = nil
+		o.lazyInner = data[start:i]
+		o.lazyDepth = depth
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// Fields with an index beyond the schema are retained for the next marshal,
+// provided that they trail the known ones. The type of such unknown fields
+// can not be derived from their header. Their bytes are therefore retained
+// as is, provided that some choice of datatypes walks the headers in
+// ascending index order up to the terminator. Nested data structures and list
+// elements do not support unknown fields for the same reason, as their end
+// can not be found.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalBinary(data []byte) error {
+	i, err := o.unmarshal(data, true, 1)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value encodes o as Colfer conform driver.Valuer, with a nil o as SQL NULL.
+// The error return option is ColferMax or ColferUTF8.
+func (o *EmbedO) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes a column value as Colfer conform sql.Scanner. The source must be
+// either a []byte or a string with one serial exactly. SQL NULL resets o to its
+// zero value. Scan into a **EmbedO with database/sql for nil on NULL instead.
+// The error return options are io.EOF, ColferError, ColferTail, ColferUTF8, ColferDepth and ColferMax,
+// with an error of another type for other source types.
+func (o *EmbedO) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = EmbedO{}
+		return nil
+	case []byte:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		// the driver owns the memory, while pending serials refer to data
+		data = append([]byte(nil), v...)
+	case string:
+		if len(v) > ColferSizeMax {
+			return ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size %d exceeds %d bytes", len(v), ColferSizeMax))
+		}
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO can't scan SQL value of type %T", src)
+	}
+	return o.UnmarshalBinary(data)
+}
+
+// ColferDescriptor returns the static description of EmbedO, which must
+// not be modified.
+func (*EmbedO) ColferDescriptor() *ColferDescriptor {
+	return &colferDescriptorEmbedO
+}
+
+// ColferGet returns the value of a field by index, with the type of the field
+// as an interface. Data structures decode like their Load method does.
+func (o *EmbedO) ColferGet(index int) (interface{}, error) {
+	switch index {
+	case 0:
+		return o.LoadInner()
+	}
+	return nil, fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO has no field index %d", index)
+}
+
+// ColferSet assigns the value of a field by index, with the type of the field
+// as an interface. An untyped nil sets the zero value. Typed nils must match
+// the type of the field, like any other value.
+func (o *EmbedO) ColferSet(index int, value interface{}) error {
+	switch index {
+	case 0:
+		var v *O
+		if value != nil {
+			var ok bool
+			v, ok = value.(*O)
+			if !ok {
+				return fmt.Errorf("colfer: field github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO.inner can't take a value of type %T", value)
+			}
+		}
+		o.Inner = v
+		o.lazyInner = nil
+		return nil
+	}
+	return fmt.Errorf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO has no field index %d", index)
+}
+
+// FieldMask returns the fields with their name from the schema as a mask.
+func (*EmbedO) FieldMask(names ...string) (ColferMask, error) {
+	var m ColferMask
+	for _, name := range names {
+		switch name {
+		case "inner":
+			m = m.With(0)
+		default:
+			return m, fmt.Errorf("colfer: field %q not in github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO", name)
+		}
+	}
+	return m, nil
+}
+
+// UnmarshalFields decodes data as Colfer like Unmarshal does, yet it sets the
+// fields in mask only. Nested data structures in mask are decoded like
+// Unmarshal does.
+// All other fields are skipped without allocation, while their structure and
+// their limits are validated still.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalFields(data []byte, mask ColferMask) (int, error) {
+	return o.unmarshalFields(data, mask, 1)
+}
+
+// UnmarshalFields decodes data as Colfer like UnmarshalFields, with depth as
+// the nesting level of o.
+func (o *EmbedO) unmarshalFields(data []byte, mask ColferMask, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if mask.Has(0) {
+		if header == 0 {
+			start := i
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+			o.Inner = nil
+			o.lazyInner = data[start:i]
+			o.lazyDepth = depth
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	} else {
+		if header == 0 {
+			// nil receiver for no allocation, as there is no field to set
+			n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+			if err != nil {
+				if err == io.EOF && len(data) >= ColferSizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+				}
+				return 0, err
+			}
+			i += n
+
+			if i >= len(data) {
+				goto eof
+			}
+			header = data[i]
+			i++
+		}
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// UnmarshalLen returns the number of bytes unmarshal would read from data, or
+// the error it would return, without decoding. Validation is the same. The
+// depth is the nesting level of o.
+func (*EmbedO) unmarshalLen(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		// nil receiver for no allocation, as there is no field to set
+		n, err := (*O)(nil).unmarshalLen(data[i:], depth+1)
+		if err != nil {
+			if err == io.EOF && len(data) >= ColferSizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// LoadInner returns o.Inner, with the serial from Unmarshal, if any,
+// decoded on first use. Assignments to o.Inner take precedence over such
+// serial, except for nil, which needs SetInner instead. Decoding continues
+// at the nesting level of o, and the serial remains pending when decoding fails.
+// The error return options are io.EOF, ColferError, ColferUTF8, ColferDepth and ColferMax.
+func (o *EmbedO) LoadInner() (*O, error) {
+	if data := o.lazyInner; data != nil {
+		if o.Inner == nil {
+			v := new(O)
+			if _, err := v.unmarshal(data, false, o.lazyDepth+1); err != nil {
+				return nil, err
+			}
+			o.Inner = v
+		}
+		o.lazyInner = nil
+	}
+	return o.Inner, nil
+}
+
+// SetInner assigns v to o.Inner, and it discards any serial from
+// Unmarshal pending decoding. A nil v clears the field as such.
+func (o *EmbedO) SetInner(v *O) {
+	o.Inner = v
+	o.lazyInner = nil
+}
+
+// Loaded returns o with all pending serials decoded, as a copy when needed.
+// Unmarshal validated the serials, so decoding fails only when the limits
+// changed since.
+func (o *EmbedO) loaded() (*EmbedO, error) {
+	c := *o
+	var pending bool
+	if c.lazyInner != nil {
+		pending = true
+		if _, err := c.LoadInner(); err != nil {
+			return nil, err
+		}
+	}
+	if !pending {
+		return o, nil
+	}
+	return &c, nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *EmbedO) Equal(other *EmbedO) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	a, err := o.loaded()
+	if err == nil {
+		var b *EmbedO
+		if b, err = other.loaded(); err == nil {
+			o, other = a, b
+		}
+	}
+	if err != nil {
+		// compare the pending serials as is
+		x, errX := o.MarshalBinary()
+		y, errY := other.MarshalBinary()
+		return errX == nil && errY == nil && string(x) == string(y)
+	}
+
+	if !o.Inner.Equal(other.Inner) {
+		return false
+	}
+
+	if !bytes.Equal(o.unknown, other.unknown) {
+		return false
+	}
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *EmbedO) Clone() *EmbedO {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	c.Inner = o.Inner.Clone()
+
+	if o.unknown != nil {
+		c.unknown = append([]byte(nil), o.unknown...)
+	}
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *EmbedO) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	o, err := o.loaded()
+	if err != nil {
+		return "gen.EmbedO{" + err.Error() + "}"
+	}
+	var fields []string
+
+	if v := o.Inner; v != nil {
+		fields = append(fields, "inner: "+v.String())
+	}
+
+	return "gen.EmbedO{" + strings.Join(fields, ", ") + "}"
+}
+
+// MarshalJSON encodes o as a JSON object conform json.Marshaler. The members
+// are in order of the schema, with the field names from the schema.
+// Timestamps are RFC 3339 strings, except for years beyond the 0–9999 range,
+// which are decimal numbers of seconds since the Unix epoch instead. Binaries
+// are base64 strings (standard encoding). Not-a-number and infinite floating
+// points are the strings "NaN", "Infinity" and "-Infinity". Zero timestamps
+// and absent structs are null.
+// The error return option is ColferMax or ColferUTF8, conform MarshalLen.
+// Pending serials which fail to decode return the error of their Load method.
+func (o *EmbedO) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+	if _, err := o.MarshalLen(); err != nil {
+		return nil, err
+	}
+	return o.appendJSON(nil)
+}
+
+// AppendJSON encodes o, which must pass MarshalLen, as JSON to the end of buf.
+func (o *EmbedO) appendJSON(buf []byte) ([]byte, error) {
+	if o == nil {
+		return append(buf, "null"...), nil
+	}
+	o, err := o.loaded()
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, '{')
+
+	buf = append(buf, "\"inner\":"...)
+	buf, err = o.Inner.appendJSON(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON decodes data conform json.Unmarshaler, with the format of
+// MarshalJSON. Absent members and null values leave the respective fields as
+// is. Numbers must fit their type without loss.
+// The error return options include ColferDepth and ColferMax.
+func (o *EmbedO) UnmarshalJSON(data []byte) error {
+	r := colferJSON{data: data}
+	if err := o.unmarshalJSON(&r); err != nil {
+		return err
+	}
+	if r.peek() != 0 {
+		return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO: JSON continuation at byte %d", r.i)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the JSON object from r.
+func (o *EmbedO) unmarshalJSON(r *colferJSON) error {
+	if r.peek() != '{' {
+		return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO: %w", r.typeError("object"))
+	}
+	r.depth++
+	if r.depth > ColferDepthMax {
+		return ColferDepth(fmt.Sprintf("colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO exceeds %d levels", ColferDepthMax))
+	}
+	r.i++
+	for first := true; ; first = false {
+		more, err := r.next('}', first)
+		if err != nil {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO: %w", err)
+		}
+		if !more {
+			r.depth--
+			return nil
+		}
+		name, err := r.text()
+		if err != nil {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO: %w", err)
+		}
+		if !r.consume(":") {
+			return fmt.Errorf("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO: %w", r.syntaxError())
+		}
+
+		switch name {
+		case "inner":
+			if r.consume("null") {
+				break
+			}
+			v := new(O)
+			if err := v.unmarshalJSON(r); err != nil {
+				return err
+			}
+			o.Inner = v
+
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in github.com/pascaldekloe/colfer/go/lazy/gen.EmbedO", name)
+		}
+	}
+}
+
+// ColferJSONFloat appends f as a JSON number, or as a string for not-a-number
+// and infinite values.
+func colferJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(f, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(f, -1):
+		return append(buf, "\"-Infinity\""...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// ColferJSONTime appends t as an RFC 3339 string, or as a decimal number of
+// seconds since the Unix epoch for years beyond the 0–9999 range. The zero
+// value is null.
+func colferJSONTime(buf []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(buf, "null"...)
+	}
+	if year := t.Year(); year >= 0 && year <= 9999 {
+		buf = append(buf, '"')
+		buf = t.AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	}
+
+	s, ns := t.Unix(), t.Nanosecond()
+	if s < 0 {
+		buf = append(buf, '-')
+		if ns != 0 {
+			s++
+			ns = 1e9 - ns
+		}
+		buf = strconv.AppendUint(buf, uint64(-s), 10)
+	} else {
+		buf = strconv.AppendInt(buf, s, 10)
+	}
+	if ns != 0 {
+		fraction := strconv.Itoa(ns + 1e9)[1:]
+		buf = append(buf, '.')
+		buf = append(buf, strings.TrimRight(fraction, "0")...)
+	}
+	return buf
+}
+
+// ColferJSONText appends s as a JSON string. Malformed UTF-8 is replaced with
+// the Unicode replacement character.
+func colferJSONText(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < ' ':
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, "\ufffd"...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// ColferJSONBinary appends v as a base64 string.
+func colferJSONBinary(buf, v []byte) []byte {
+	buf = append(buf, '"')
+	offset := len(buf)
+	buf = append(buf, make([]byte, base64.StdEncoding.EncodedLen(len(v)))...)
+	base64.StdEncoding.Encode(buf[offset:], v)
+	return append(buf, '"')
+}
+
+// ColferJSON reads JSON without reflection.
+type colferJSON struct {
+	data  []byte
+	i     int // read index
+	depth int // nesting level
+}
+
+func (r *colferJSON) syntaxError() error {
+	if r.i >= len(r.data) {
+		return fmt.Errorf("unexpected end of JSON at byte %d", r.i)
+	}
+	return fmt.Errorf("JSON syntax error at byte %d", r.i)
+}
+
+func (r *colferJSON) typeError(want string) error {
+	if r.i >= len(r.data) {
+		return r.syntaxError()
+	}
+	return fmt.Errorf("JSON at byte %d is not a %s", r.i, want)
+}
+
+// Peek returns the next byte after any whitespace, or zero at the end.
+func (r *colferJSON) peek() byte {
+	for ; r.i < len(r.data); r.i++ {
+		switch c := r.data[r.i]; c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// Consume reads token when next.
+func (r *colferJSON) consume(token string) bool {
+	r.peek()
+	if len(r.data)-r.i < len(token) || string(r.data[r.i:r.i+len(token)]) != token {
+		return false
+	}
+	r.i += len(token)
+	return true
+}
+
+// Next reads up to the next member or element of an object or an array, with
+// end as the closing byte. The return is false when end was read instead.
+func (r *colferJSON) next(end byte, first bool) (bool, error) {
+	c := r.peek()
+	if c == end {
+		r.i++
+		return false, nil
+	}
+	if !first {
+		if c != ',' {
+			return false, r.syntaxError()
+		}
+		r.i++
+	}
+	return true, nil
+}
+
+func (r *colferJSON) boolean() (bool, error) {
+	switch {
+	case r.consume("true"):
+		return true, nil
+	case r.consume("false"):
+		return false, nil
+	}
+	return false, r.typeError("boolean")
+}
+
+// Number reads the digits of a number, which may be quoted.
+func (r *colferJSON) number() (string, error) {
+	switch c := r.peek(); {
+	case c == '"':
+		return r.text()
+	case c != '-' && (c < '0' || c > '9'):
+		return "", r.typeError("number")
+	}
+	start := r.i
+	for r.i++; r.i < len(r.data); r.i++ {
+		c := r.data[r.i]
+		if (c < '0' || c > '9') && c != '.' && c != 'e' && c != 'E' && c != '+' && c != '-' {
+			break
+		}
+	}
+	return string(r.data[start:r.i]), nil
+}
+
+func (r *colferJSON) unsigned(bitSize int) (uint64, error) {
+	s, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, bitSize)
+}
+
+func (r *colferJSON) signed(bitSize int) (int64, error) {
+	s, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, bitSize)
+}
+
+func (r *colferJSON) float(bitSize int) (float64, error) {
+	if r.peek() == '"' {
+		s, err := r.text()
+		if err != nil {
+			return 0, err
+		}
+		switch s {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return 0, fmt.Errorf("JSON string %q is not a floating point", s)
+	}
+
+	s, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, bitSize)
+}
+
+// Timestamp reads the format of colferJSONTime.
+func (r *colferJSON) timestamp() (time.Time, error) {
+	if r.peek() == '"' {
+		s, err := r.text()
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.In(time.UTC), nil
+	}
+
+	digits, err := r.number()
+	if err != nil {
+		return time.Time{}, err
+	}
+	negative := strings.HasPrefix(digits, "-")
+	if negative {
+		digits = digits[1:]
+	}
+	var fraction string
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		digits, fraction = digits[:i], digits[i+1:]
+	}
+
+	s, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || s > 1<<63 || (s == 1<<63 && !negative) {
+		return time.Time{}, fmt.Errorf("JSON timestamp before byte %d is not a whole number of seconds with an optional fraction in range", r.i)
+	}
+	var ns int64
+	if fraction != "" {
+		ns, err = strconv.ParseInt((fraction + "00000000")[:9], 10, 64)
+		if err != nil || len(fraction) > 9 || fraction[0] == '-' || fraction[0] == '+' {
+			return time.Time{}, fmt.Errorf("JSON timestamp before byte %d has a malformed fraction or it exceeds nanosecond precision", r.i)
+		}
+	}
+
+	if !negative {
+		return time.Unix(int64(s), ns).In(time.UTC), nil
+	}
+	if ns != 0 {
+		if s == 1<<63 {
+			return time.Time{}, fmt.Errorf("JSON timestamp before byte %d out of range", r.i)
+		}
+		s++
+		ns = 1e9 - ns
+	}
+	return time.Unix(-int64(s), ns).In(time.UTC), nil
+}
+
+// Binary reads a base64 string.
+func (r *colferJSON) binary() ([]byte, error) {
+	s, err := r.text()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// Text reads a JSON string.
+func (r *colferJSON) text() (string, error) {
+	if r.peek() != '"' {
+		return "", r.typeError("string")
+	}
+	r.i++
+
+	var buf []byte // unescaped content
+	for start := r.i; r.i < len(r.data); {
+		c := r.data[r.i]
+		switch {
+		case c == '"':
+			s := string(append(buf, r.data[start:r.i]...))
+			r.i++
+			if !utf8.ValidString(s) {
+				return "", fmt.Errorf("JSON string with malformed UTF-8 before byte %d", r.i)
+			}
+			return s, nil
+		case c < ' ':
+			return "", r.syntaxError()
+		case c != '\\':
+			r.i++
+			continue
+		}
+
+		buf = append(buf, r.data[start:r.i]...)
+		r.i++
+		if r.i >= len(r.data) {
+			break
+		}
+		switch c := r.data[r.i]; c {
+		case '"', '\\', '/':
+			buf = append(buf, c)
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			x, ok := r.hex4(r.i + 1)
+			if !ok {
+				return "", r.syntaxError()
+			}
+			r.i += 4
+			if x >= 0xd800 && x < 0xdc00 && len(r.data)-r.i > 2 && r.data[r.i+1] == '\\' && r.data[r.i+2] == 'u' {
+				// surrogate pair
+				if y, ok := r.hex4(r.i + 3); ok && y >= 0xdc00 && y < 0xe000 {
+					x = 0x10000 + (x-0xd800)<<10 | (y - 0xdc00)
+					r.i += 6
+				}
+			}
+			if x >= 0xd800 && x < 0xe000 {
+				x = utf8.RuneError
+			}
+			var encoded [utf8.UTFMax]byte
+			buf = append(buf, encoded[:utf8.EncodeRune(encoded[:], x)]...)
+		default:
+			return "", r.syntaxError()
+		}
+		r.i++
+		start = r.i
+	}
+	return "", r.syntaxError()
+}
+
+// Hex4 parses the 4 hexadecimal digits at data[i:].
+func (r *colferJSON) hex4(i int) (rune, bool) {
+	if len(r.data)-i < 4 {
+		return 0, false
+	}
+	var x rune
+	for _, c := range r.data[i : i+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			x = x<<4 | rune(c-'0')
+		case c >= 'a' && c <= 'f':
+			x = x<<4 | rune(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			x = x<<4 | rune(c-'A'+10)
+		default:
+			return 0, false
+		}
+	}
+	return x, true
+}
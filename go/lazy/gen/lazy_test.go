@@ -0,0 +1,287 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestLazyUnmarshal(t *testing.T) {
+	// nested uint32 in fixed-width notation, which marshals as varint
+	const serial = "0a8100000001" + "7f" + "0b02" + "007f" + "84017f" + "7f"
+	data, _ := hex.DecodeString(serial)
+
+	var o O
+	if err := o.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	if o.O != nil || o.Os != nil {
+		t.Errorf("got O %v and Os %v, want both pending", o.O, o.Os)
+	}
+	if l, err := o.MarshalLen(); err != nil || l != len(data) {
+		t.Errorf("got marshal length %d, %v; want %d", l, err, len(data))
+	}
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if want := serial; hex.EncodeToString(got) != want {
+		t.Errorf("got pending serial 0x%x, want 0x%s", got, want)
+	}
+
+	want := &O{O: &O{U32: 1}, Os: []*O{{B: true}, {I64: -1}}}
+	if !o.Equal(want) || !want.Equal(&o) {
+		t.Errorf("pending %v not equal to %v", &o, want)
+	}
+	if s := o.String(); s != want.String() {
+		t.Errorf("got string %q, want %q", s, want.String())
+	}
+	c := o.Clone()
+
+	v, err := o.LoadO()
+	if err != nil {
+		t.Fatal("load error:", err)
+	}
+	if v == nil || v != o.O || !v.Equal(want.O) {
+		t.Errorf("got O %v, want %v", v, want.O)
+	}
+	a, err := o.LoadOs()
+	if err != nil {
+		t.Fatal("load error:", err)
+	}
+	if len(a) != 2 || !a[0].Equal(want.Os[0]) || !a[1].Equal(want.Os[1]) {
+		t.Errorf("got Os %v, want %v", a, want.Os)
+	}
+	got, err = o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if want := "0a01017f" + "0b02007f84017f" + "7f"; hex.EncodeToString(got) != want {
+		t.Errorf("got decoded serial 0x%x, want 0x%s", got, want)
+	}
+
+	// clone kept the serial
+	got, err = c.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if want := serial; hex.EncodeToString(got) != want {
+		t.Errorf("got clone serial 0x%x, want 0x%s", got, want)
+	}
+}
+
+func TestLazyAssign(t *testing.T) {
+	var o O
+	if err := o.UnmarshalBinary([]byte{0x0a, 0x00, 0x7f, 0x7f}); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	o.O = &O{U8: 2}
+	v, err := o.LoadO()
+	if err != nil || v != o.O {
+		t.Errorf("got %v, %v; want the assignment", v, err)
+	}
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if want := "0a0e027f7f"; hex.EncodeToString(got) != want {
+		t.Errorf("got serial 0x%x, want 0x%s", got, want)
+	}
+}
+
+func TestLazyReuse(t *testing.T) {
+	var o O
+	if err := o.UnmarshalBinary([]byte{0x0a, 0x0e, 0x01, 0x7f, 0x0b, 0x01, 0x0e, 0x01, 0x7f, 0x7f}); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	if _, err := o.LoadO(); err != nil {
+		t.Fatal("load error:", err)
+	}
+	if _, err := o.LoadOs(); err != nil {
+		t.Fatal("load error:", err)
+	}
+
+	// same fields with other values
+	const serial = "0a0e027f0b010e027f7f"
+	data, _ := hex.DecodeString(serial)
+	if err := o.UnmarshalBinary(data); err != nil {
+		t.Fatal("second unmarshal error:", err)
+	}
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if hex.EncodeToString(got) != serial {
+		t.Errorf("got serial 0x%x, want 0x%s", got, serial)
+	}
+	if v, err := o.LoadO(); err != nil || v == nil || v.U8 != 2 {
+		t.Errorf("got O %v, %v; want U8 2", v, err)
+	}
+	if a, err := o.LoadOs(); err != nil || len(a) != 1 || a[0].U8 != 2 {
+		t.Errorf("got Os %v, %v; want one with U8 2", a, err)
+	}
+}
+
+func TestLazyClear(t *testing.T) {
+	var o O
+	if err := o.UnmarshalBinary([]byte{0x0a, 0x0e, 0x01, 0x7f, 0x0b, 0x01, 0x0e, 0x01, 0x7f, 0x7f}); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	o.SetO(nil)
+	o.SetOs(nil)
+	if l, err := o.MarshalLen(); err != nil || l != 1 {
+		t.Errorf("got marshal length %d, %v; want 1", l, err)
+	}
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if want := "7f"; hex.EncodeToString(got) != want {
+		t.Errorf("got serial 0x%x, want 0x%s", got, want)
+	}
+	if v, err := o.LoadO(); err != nil || v != nil {
+		t.Errorf("got O %v, %v; want nil", v, err)
+	}
+	if a, err := o.LoadOs(); err != nil || a != nil {
+		t.Errorf("got Os %v, %v; want nil", a, err)
+	}
+}
+
+func TestLazyValidation(t *testing.T) {
+	golden := []struct {
+		serial string
+		err    error
+	}{
+		{"0a0a0a", io.EOF},
+		{"0a0a7f", io.EOF},
+		{"0a0a7e7f7f", ColferError(0)},
+		{"0b020a7f7f", io.EOF},
+		{"0b027f867f", ColferError(0)},
+		{"0a0801ff7f7f", ColferUTF8("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.s has malformed UTF-8 at byte 2")},
+		{"0b010c0101ff7f7f", ColferUTF8("colfer: github.com/pascaldekloe/colfer/go/lazy/gen.o.ss element 0 has malformed UTF-8 at byte 3")},
+		{"0b010d0101ff7f7f", nil},
+	}
+	for _, gold := range golden {
+		data, _ := hex.DecodeString(gold.serial)
+		_, err := new(O).Unmarshal(data)
+		if err != gold.err {
+			t.Errorf("0x%s: got error %v, want %v", gold.serial, err, gold.err)
+		}
+		_, err = new(O).unmarshalLen(data, 1)
+		if err != gold.err {
+			t.Errorf("0x%s: got length error %v, want %v", gold.serial, err, gold.err)
+		}
+	}
+}
+
+func TestUnmarshalLen(t *testing.T) {
+	data, err := (&O{
+		S:  "hello",
+		O:  &O{Os: []*O{{U16: 3}}},
+		Os: []*O{nil, {Ss: []string{"a", "b"}}},
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	data = append(data, 0xff)
+
+	n, err := new(O).unmarshalLen(data, 1)
+	if err != nil || n != len(data)-1 {
+		t.Errorf("got %d, %v; want %d", n, err, len(data)-1)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		new(O).unmarshalLen(data, 1)
+	})
+	if allocs != 0 {
+		t.Errorf("got %f allocations, want none", allocs)
+	}
+
+	var o O
+	n, err = o.Unmarshal(data)
+	if err != nil || n != len(data)-1 {
+		t.Errorf("got unmarshal %d, %v; want %d", n, err, len(data)-1)
+	}
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if !bytes.Equal(got, data[:n]) {
+		t.Errorf("got serial 0x%x, want 0x%x", got, data[:n])
+	}
+}
+
+func TestLazyColferGetSet(t *testing.T) {
+	const serial = "0a8100000001" + "7f" + "0b02" + "007f" + "84017f" + "7f"
+	data, _ := hex.DecodeString(serial)
+
+	var o O
+	if err := o.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	v, err := o.ColferGet(10)
+	if err != nil {
+		t.Fatal("get error:", err)
+	}
+	if got, ok := v.(*O); !ok || !got.Equal(&O{U32: 1}) {
+		t.Errorf("got %#v, want the pending O decoded", v)
+	}
+
+	// assignment of nil discards the pending serial
+	if err := o.ColferSet(11, nil); err != nil {
+		t.Fatal("set error:", err)
+	}
+	if os, err := o.LoadOs(); err != nil || os != nil {
+		t.Errorf("got Os %v with error %v, want nil", os, err)
+	}
+}
+
+func TestLazyDepth(t *testing.T) {
+	orig := ColferDepthMax
+	defer func() {
+		ColferDepthMax = orig
+	}()
+	ColferDepthMax = 3
+
+	// three levels of nesting
+	const serial = "0a0a7f7f7f"
+	data, _ := hex.DecodeString(serial)
+	var o O
+	if err := o.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+
+	// decoding continues at level two, which leaves no room for level three
+	ColferDepthMax = 2
+	if _, err := o.LoadO(); err == nil {
+		t.Fatal("load got no error")
+	} else if _, ok := err.(ColferDepth); !ok {
+		t.Fatalf("load got error %T: %q, want a ColferDepth", err, err)
+	}
+
+	// serial remains pending
+	if got, err := o.MarshalBinary(); err != nil {
+		t.Error("marshal error:", err)
+	} else if hex.EncodeToString(got) != serial {
+		t.Errorf("got serial 0x%x, want 0x%s", got, serial)
+	}
+	if _, err := o.MarshalJSON(); err == nil {
+		t.Error("marshal JSON got no error")
+	} else if _, ok := err.(ColferDepth); !ok {
+		t.Errorf("marshal JSON got error %T: %q, want a ColferDepth", err, err)
+	}
+	if s := o.String(); s != "gen.o{"+`colfer: depth limit: struct github.com/pascaldekloe/colfer/go/lazy/gen.o exceeds 2 levels`+"}" {
+		t.Errorf("got string %q", s)
+	}
+	if c := o.Clone(); !c.Equal(&o) {
+		t.Error("clone not equal")
+	}
+	if o.Equal(new(O)) {
+		t.Error("equal to zero value")
+	}
+
+	ColferDepthMax = 3
+	if v, err := o.LoadO(); err != nil || !v.Equal(&O{O: &O{}}) {
+		t.Errorf("got %v, %v after restore", v, err)
+	}
+}
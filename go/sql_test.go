@@ -0,0 +1,208 @@
+package gen
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// StubDriver is an in-memory database/sql driver, with one table of binary
+// values by key. Queries are either "INSERT", with a key and a value as
+// arguments, or "SELECT", with a key as argument.
+type stubDriver struct {
+	sync.Mutex
+	table map[int64]driver.Value
+}
+
+func init() {
+	sql.Register("colfer-stub", &stubDriver{table: make(map[int64]driver.Value)})
+}
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) {
+	return stubConn{d}, nil
+}
+
+type stubConn struct{ d *stubDriver }
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	switch query {
+	case "INSERT", "SELECT":
+		return stubStmt{c.d, query}, nil
+	}
+	return nil, errors.New("stub: unknown query " + query)
+}
+
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return nil, errors.New("stub: no transactions") }
+
+type stubStmt struct {
+	d     *stubDriver
+	query string
+}
+
+func (s stubStmt) Close() error { return nil }
+
+func (s stubStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 2
+	}
+	return 1
+}
+
+func (s stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query != "INSERT" {
+		return nil, errors.New("stub: exec of " + s.query)
+	}
+	s.d.Lock()
+	defer s.d.Unlock()
+	s.d.table[args[0].(int64)] = args[1]
+	return driver.RowsAffected(1), nil
+}
+
+func (s stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != "SELECT" {
+		return nil, errors.New("stub: query of " + s.query)
+	}
+	s.d.Lock()
+	defer s.d.Unlock()
+	v, ok := s.d.table[args[0].(int64)]
+	return &stubRows{v: v, done: !ok}, nil
+}
+
+type stubRows struct {
+	v    driver.Value
+	done bool
+}
+
+func (r *stubRows) Columns() []string { return []string{"v"} }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.v
+	return nil
+}
+
+func openStub(t *testing.T) *sql.DB {
+	db, err := sql.Open("colfer-stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLRoundTrip(t *testing.T) {
+	db := openStub(t)
+
+	for i, gold := range newGoldenCases() {
+		if _, err := db.Exec("INSERT", i, &gold.object); err != nil {
+			t.Errorf("0x%s: insert error: %s", gold.serial, err)
+			continue
+		}
+		var got O
+		if err := db.QueryRow("SELECT", i).Scan(&got); err != nil {
+			t.Errorf("0x%s: scan error: %s", gold.serial, err)
+			continue
+		}
+		if !got.Equal(&gold.object) {
+			t.Errorf("0x%s: got %v, want %v", gold.serial, &got, &gold.object)
+		}
+	}
+}
+
+func TestSQLNull(t *testing.T) {
+	db := openStub(t)
+
+	if _, err := db.Exec("INSERT", 1000, (*O)(nil)); err != nil {
+		t.Fatal("insert error:", err)
+	}
+
+	p := &O{B: true}
+	if err := db.QueryRow("SELECT", 1000).Scan(&p); err != nil {
+		t.Fatal("scan pointer error:", err)
+	}
+	if p != nil {
+		t.Errorf("scan pointer got %v, want nil", p)
+	}
+
+	o := O{B: true}
+	if err := db.QueryRow("SELECT", 1000).Scan(&o); err != nil {
+		t.Fatal("scan error:", err)
+	}
+	if !o.Equal(new(O)) {
+		t.Errorf("scan got %v, want zero value", &o)
+	}
+}
+
+func TestSQLMax(t *testing.T) {
+	db := openStub(t)
+
+	// serial of 11 bytes
+	o := &O{S: "01234567"}
+	if _, err := db.Exec("INSERT", 2000, o); err != nil {
+		t.Fatal("insert error:", err)
+	}
+
+	orig := ColferSizeMax
+	defer func() {
+		ColferSizeMax = orig
+	}()
+	ColferSizeMax = 10
+
+	if _, err := db.Exec("INSERT", 2001, o); !errors.As(err, new(ColferMax)) {
+		t.Errorf("insert got error %v, want a ColferMax", err)
+	}
+
+	var got O
+	err := db.QueryRow("SELECT", 2000).Scan(&got)
+	var max ColferMax
+	if !errors.As(err, &max) {
+		t.Fatalf("scan got error %v, want a ColferMax", err)
+	}
+	if want := "colfer: struct gen.o size 11 exceeds 10 bytes"; string(max) != want {
+		t.Errorf("scan got error %q, want %q", max, want)
+	}
+}
+
+func TestSQLScanError(t *testing.T) {
+	golden := []struct {
+		src  interface{}
+		want error
+	}{
+		{[]byte{}, io.EOF},
+		{"\x00", io.EOF},
+		{"\x7f\x7f", ColferTail(1)},
+		{"\x80\x7f", ColferError(0)},
+	}
+	for _, gold := range golden {
+		if err := new(O).Scan(gold.src); err != gold.want {
+			t.Errorf("scan %T %q got error %v, want %v", gold.src, gold.src, err, gold.want)
+		}
+	}
+
+	for _, src := range []interface{}{int64(127), 1.5, true} {
+		if err := new(O).Scan(src); err == nil {
+			t.Errorf("scan %T %v got no error", src, src)
+		}
+	}
+
+	data, err := hex.DecodeString("0801417f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o O
+	if err := o.Scan(string(data)); err != nil {
+		t.Fatal("scan string error:", err)
+	}
+	if o.S != "A" {
+		t.Errorf("scan string got %v, want S set to %q", &o, "A")
+	}
+}
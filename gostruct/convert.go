@@ -0,0 +1,260 @@
+package gostruct
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// Issue is a construct which could not be converted.
+type Issue struct {
+	Path string
+	Line int
+	Desc string
+}
+
+// String returns the issue with its position.
+func (issue Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s", issue.Path, issue.Line, issue.Desc)
+}
+
+// Colfer field indices are limited to 0–126, as 127 marks the end of a struct.
+const maxFields = 127
+
+// Basics maps the Go types to their Colfer equivalent. Integers without a
+// Colfer counterpart are widened.
+var basics = map[types.BasicKind]string{
+	types.Bool:    "bool",
+	types.Uint8:   "uint8",
+	types.Uint16:  "uint16",
+	types.Uint32:  "uint32",
+	types.Uint64:  "uint64",
+	types.Uint:    "uint64",
+	types.Int8:    "int32",
+	types.Int16:   "int32",
+	types.Int32:   "int32",
+	types.Int64:   "int64",
+	types.Int:     "int64",
+	types.Float32: "float32",
+	types.Float64: "float64",
+	types.String:  "text",
+}
+
+// ListTypes are the Colfer types which support lists.
+var listTypes = map[string]bool{
+	"float32": true,
+	"float64": true,
+	"int32":   true,
+	"int64":   true,
+	"text":    true,
+	"binary":  true,
+}
+
+// Converter holds the state of a conversion.
+type converter struct {
+	pkg    *Package
+	docs   map[token.Pos][]string
+	queue  []*types.TypeName // structs to convert
+	queued map[*types.TypeName]bool
+	issues []Issue
+}
+
+// Convert returns a Colfer schema with the named struct types from pkg, or all
+// exported struct types when no names are given. Struct types which are
+// referred to by the selection, from within pkg, are included too.
+//
+// Fields map to their Colfer equivalent: time.Time to timestamp, []byte to
+// binary, slices to lists, and (pointers to) structs to references. Named
+// types apply their underlying type. Signed integers smaller than 32 bits, and
+// the platform-dependent int and uint, are widened. Fields which can not be
+// converted, such as maps, interfaces, embedded fields and unexported fields,
+// are reported as issues, and any such field is omitted from the schema. The
+// error return is for names which do not resolve to a struct type.
+func Convert(pkg *Package, names ...string) (schema []byte, issues []Issue, err error) {
+	c := &converter{
+		pkg:    pkg,
+		docs:   pkg.docs(),
+		queued: make(map[*types.TypeName]bool),
+	}
+
+	scope := pkg.Types.Scope()
+	if len(names) == 0 {
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if ok && obj.Exported() && isStruct(obj.Type()) {
+				c.enqueue(obj)
+			}
+		}
+		// in order of appearance
+		sort.SliceStable(c.queue, func(i, j int) bool {
+			return c.queue[i].Pos() < c.queue[j].Pos()
+		})
+	} else {
+		for _, name := range names {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				return nil, nil, fmt.Errorf("colfer: type %s not found in Go package %s", name, pkg.Types.Path())
+			}
+			if !isStruct(obj.Type()) {
+				return nil, nil, fmt.Errorf("colfer: type %s in Go package %s is not a struct", name, pkg.Types.Path())
+			}
+			c.enqueue(obj)
+		}
+	}
+	if len(c.queue) == 0 {
+		return nil, nil, fmt.Errorf("colfer: no exported struct types in Go package %s", pkg.Types.Path())
+	}
+
+	var buf bytes.Buffer
+	for _, line := range pkg.packageDocs() {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&buf, "package %s\n", pkg.Types.Name())
+	// queue grows with references
+	for i := 0; i < len(c.queue); i++ {
+		c.writeStruct(&buf, c.queue[i])
+	}
+
+	schema, err = format.Source(buf.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("colfer: Go package %s: conversion produced malformed schema: %s", pkg.Types.Path(), err)
+	}
+
+	sort.SliceStable(c.issues, func(i, j int) bool {
+		if c.issues[i].Path != c.issues[j].Path {
+			return c.issues[i].Path < c.issues[j].Path
+		}
+		return c.issues[i].Line < c.issues[j].Line
+	})
+	return schema, c.issues, nil
+}
+
+func (c *converter) enqueue(obj *types.TypeName) {
+	if !c.queued[obj] {
+		c.queued[obj] = true
+		c.queue = append(c.queue, obj)
+	}
+}
+
+func (c *converter) issue(pos token.Pos, format string, args ...interface{}) {
+	position := c.pkg.Fset.Position(pos)
+	c.issues = append(c.issues, Issue{
+		Path: position.Filename,
+		Line: position.Line,
+		Desc: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *converter) writeStruct(buf *bytes.Buffer, obj *types.TypeName) {
+	buf.WriteByte('\n')
+	for _, line := range c.docs[obj.Pos()] {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", obj.Name())
+
+	s := obj.Type().Underlying().(*types.Struct)
+	var count int
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		switch {
+		case field.Embedded():
+			c.issue(field.Pos(), "embedded field %s in struct %s is not supported", field.Name(), obj.Name())
+			continue
+		case !field.Exported():
+			c.issue(field.Pos(), "unexported field %s in struct %s is not supported", field.Name(), obj.Name())
+			continue
+		}
+
+		typ, ok := c.fieldType(field.Type())
+		if !ok {
+			c.issue(field.Pos(), "field %s in struct %s has unsupported type %s", field.Name(), obj.Name(), types.TypeString(field.Type(), types.RelativeTo(c.pkg.Types)))
+			continue
+		}
+		if count >= maxFields {
+			c.issue(field.Pos(), "field %s in struct %s exceeds the Colfer limit of %d fields", field.Name(), obj.Name(), maxFields)
+			continue
+		}
+		count++
+
+		for _, line := range c.docs[field.Pos()] {
+			fmt.Fprintf(buf, "\t%s\n", line)
+		}
+		fmt.Fprintf(buf, "\t%s %s\n", field.Name(), typ)
+	}
+	buf.WriteString("}\n")
+}
+
+// FieldType returns the Colfer type declaration, or false when t is not
+// supported.
+func (c *converter) fieldType(t types.Type) (typ string, ok bool) {
+	if s, ok := t.Underlying().(*types.Slice); ok {
+		if isByte(s.Elem()) {
+			return "binary", true
+		}
+		elem, ref, ok := c.elemType(s.Elem())
+		if !ok || !ref && !listTypes[elem] {
+			return "", false
+		}
+		return "[]" + elem, true
+	}
+	typ, _, ok = c.elemType(t)
+	return typ, ok
+}
+
+// ElemType returns the Colfer type of a list element or a single value, with
+// whether it is a struct reference, or false when t is not supported.
+func (c *converter) elemType(t types.Type) (typ string, ref, ok bool) {
+	if isTime(t) {
+		return "timestamp", false, true
+	}
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+		if !isStruct(t) || isTime(t) {
+			return "", false, false
+		}
+	}
+
+	if named, ok := t.(*types.Named); ok && isStruct(named) {
+		obj := named.Obj()
+		if obj.Pkg() != c.pkg.Types {
+			return "", false, false
+		}
+		c.enqueue(obj)
+		return obj.Name(), true, true
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		typ, ok = basics[u.Kind()]
+		return typ, false, ok
+	case *types.Slice:
+		if isByte(u.Elem()) {
+			return "binary", false, true
+		}
+	}
+	return "", false, false
+}
+
+func isStruct(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+func isByte(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+func isTime(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time"
+}
@@ -0,0 +1,138 @@
+package gostruct
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+const goldenShop = `// Package shop has domain types for conversion tests.
+package shop
+
+// Order is a purchase.
+// It spans multiple lines of documentation.
+type Order struct {
+	// ID identifies the order.
+	ID uint64
+	// trailing comment
+	Status int32
+	Placed timestamp
+	// Lines are the products.
+	Lines []Line
+	Ship  Address
+	Bill  Address
+	Note  text
+	Tags  []text
+	Scan  binary
+	Sigs  []binary
+	Total float64
+	Count int64
+}
+
+// Line is an order entry, which is selected by reference only.
+type Line struct {
+	SKU      text
+	Quantity uint32
+	Price    float32
+}
+
+type Address struct {
+	Street text
+}
+`
+
+var goldenShopIssues = []string{
+	"../testdata/gostruct/shop.go:33: field Flags in struct Order has unsupported type []bool",
+	"../testdata/gostruct/shop.go:34: field Small in struct Order has unsupported type []uint16",
+	"../testdata/gostruct/shop.go:35: field Attrs in struct Order has unsupported type map[string]string",
+	"../testdata/gostruct/shop.go:36: field Link in struct Order has unsupported type *net/url.URL",
+	"../testdata/gostruct/shop.go:37: field When in struct Order has unsupported type *time.Time",
+	"../testdata/gostruct/shop.go:38: field Any in struct Order has unsupported type interface{}",
+	"../testdata/gostruct/shop.go:39: field Fixed in struct Order has unsupported type [4]byte",
+	"../testdata/gostruct/shop.go:41: embedded field Mutex in struct Order is not supported",
+	"../testdata/gostruct/shop.go:42: unexported field secret in struct Order is not supported",
+	"../testdata/gostruct/shop.go:54: field Country in struct Address has unsupported type [2]byte",
+}
+
+func TestConvert(t *testing.T) {
+	pkg, err := Load("../testdata/gostruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, issues, err := Convert(pkg, "Order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(schema) != goldenShop {
+		t.Errorf("got schema:\n%s\nwant:\n%s", schema, goldenShop)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	if !reflect.DeepEqual(got, goldenShopIssues) {
+		t.Errorf("got issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(goldenShopIssues, "\n"))
+	}
+
+	// must compile
+	dir, err := ioutil.TempDir("", "colfer-gostruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "shop.colf")
+	if err := ioutil.WriteFile(path, schema, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := colfer.ParseFiles(path); err != nil {
+		t.Error("converted schema rejected:", err)
+	}
+}
+
+func TestConvertAll(t *testing.T) {
+	pkg, err := Load("../testdata/gostruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, _, err := Convert(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(string(schema), "\n") {
+		if strings.HasPrefix(line, "type ") {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		"type Order struct {",
+		"type Line struct {",
+		"type Address struct {",
+		"type Unrelated struct {",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got types %q, want %q", got, want)
+	}
+}
+
+func TestConvertError(t *testing.T) {
+	pkg, err := Load("../testdata/gostruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct{ name, err string }{
+		{"Missing", "colfer: type Missing not found in Go package shop"},
+		{"Status", "colfer: type Status in Go package shop is not a struct"},
+	}
+	for _, gold := range golden {
+		_, _, err := Convert(pkg, gold.name)
+		if err == nil || err.Error() != gold.err {
+			t.Errorf("%s: got error %v, want %q", gold.name, err, gold.err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package gostruct converts Go struct types to Colfer schemas.
+package gostruct
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+)
+
+// Package is a type-checked Go package.
+type Package struct {
+	// Dir is the source location.
+	Dir string
+	// Fset has the positions of Files.
+	Fset *token.FileSet
+	// Files are the parsed sources, excluding tests.
+	Files []*ast.File
+	// Types has the definitions.
+	Types *types.Package
+}
+
+// Load parses and type-checks the Go package in a directory. Imports are
+// resolved from source, conform the build context of the go command, which
+// includes the module of the working directory, if any.
+func Load(dir string) (*Package, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("colfer: %w", err)
+	}
+
+	p := &Package{Dir: dir, Fset: token.NewFileSet()}
+	for _, name := range buildPkg.GoFiles {
+		file, err := parser.ParseFile(p.Fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("colfer: %w", err)
+		}
+		p.Files = append(p.Files, file)
+	}
+
+	path := buildPkg.ImportPath
+	if path == "." {
+		// outside of GOPATH and modules
+		path = buildPkg.Name
+	}
+	config := types.Config{Importer: importer.ForCompiler(p.Fset, "source", nil)}
+	p.Types, err = config.Check(path, p.Fset, p.Files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("colfer: %w", err)
+	}
+	return p, nil
+}
+
+// Docs returns the comments of each type declaration and each struct field,
+// keyed by the position of their name.
+func (p *Package) docs() map[token.Pos][]string {
+	docs := make(map[token.Pos][]string)
+	for _, file := range p.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				doc := spec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				docs[spec.Name.Pos()] = commentLines(doc)
+
+				s, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range s.Fields.List {
+					doc := field.Doc
+					if doc == nil {
+						doc = field.Comment
+					}
+					for _, name := range field.Names {
+						docs[name.Pos()] = commentLines(doc)
+					}
+				}
+			}
+		}
+	}
+	return docs
+}
+
+// PackageDocs returns the package comment, if any.
+func (p *Package) packageDocs() []string {
+	for _, file := range p.Files {
+		if file.Doc != nil {
+			return commentLines(file.Doc)
+		}
+	}
+	return nil
+}
+
+// CommentLines returns the text of a comment group in Colfer schema notation.
+func commentLines(group *ast.CommentGroup) []string {
+	if group == nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range splitLines(group.Text()) {
+		if line == "" {
+			lines = append(lines, "//")
+		} else {
+			lines = append(lines, "// "+line)
+		}
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for len(s) != 0 {
+		i := 0
+		for i < len(s) && s[i] != '\n' {
+			i++
+		}
+		lines = append(lines, s[:i])
+		if i < len(s) {
+			i++
+		}
+		s = s[i:]
+	}
+	return lines
+}
@@ -68,6 +68,7 @@ func GenerateJava(basedir string, packages Packages) error {
 	template.Must(packageTemplate.Parse(javaPackage))
 	codeTemplate := template.New("java-code").Funcs(funcs)
 	template.Must(codeTemplate.Parse(javaCode))
+	template.Must(codeTemplate.New("unmarshal-field").Parse(javaUnmarshalField))
 
 	for _, p := range packages {
 		p.NameNative = toJavaName(p.Name)
@@ -180,10 +181,19 @@ import java.io.ObjectOutputStream;
 import java.io.ObjectStreamException;
 import java.io.OutputStream;
 import java.io.Serializable;
+{{- if and .Pkg.StrictText .HasText}}
+import java.io.UncheckedIOException;
+import java.nio.ByteBuffer;
+import java.nio.charset.CharacterCodingException;
+import java.nio.charset.MalformedInputException;
+{{- end}}
 {{- if .HasText}}
 import java.nio.charset.StandardCharsets;
 {{- end}}
 import java.util.InputMismatchException;
+{{- if and .Pkg.Iterate .HasRefList}}
+import java.util.function.Consumer;
+{{- end}}
 import java.nio.BufferOverflowException;
 import java.nio.BufferUnderflowException;
 
@@ -205,6 +215,12 @@ import java.nio.BufferUnderflowException;
 	/** The upper limit for the number of elements in a list. */
 	public static int colferListMax = {{.Pkg.ListMax}};
 {{end}}
+	/** The upper limit for the nesting level of data structures, with 1 for the top-level. */
+	public static int colferDepthMax = {{.Pkg.DepthMax}};
+
+	/** The schema digest, which changes with any change to the fields, including the data structures referred to. */
+	public static final String colferFingerprint = "{{.Fingerprint}}";
+
 {{- range .Fields}}
 {{if .Docs}}
 	/**
@@ -301,8 +317,11 @@ import java.nio.BufferUnderflowException;
 		 * Deserializes the following object.
 		 * @return the result or {@code null} when EOF.
 		 * @throws IOException from the input stream.
-		 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+		 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
 		 * @throws InputMismatchException when the data does not match this object's schema.
+{{- if .Pkg.StrictText}}
+		 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
 		 */
 		public {{$class}} next() throws IOException {
 			if (in == null) return null;
@@ -318,28 +337,119 @@ import java.nio.BufferUnderflowException;
 				}
 				// not enough data
 
-				if (this.i <= this.offset) {
-					this.offset = 0;
-					this.i = 0;
-				} else if (i == buf.length) {
-					byte[] src = this.buf;
-					if (offset == 0) this.buf = new byte[Math.min({{$class}}.colferSizeMax, this.buf.length * 4)];
-					System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
-					this.i -= this.offset;
-					this.offset = 0;
+				if (! fill()) {
+					if (this.i > this.offset)
+						throw new InputMismatchException("colfer: pending data with EOF");
+					return null;
+				}
+			}
+		}
+{{- if .Pkg.Iterate}}{{range .Fields}}{{if and .TypeList .TypeRef}}
+
+		/**
+		 * Deserializes the following object like {@link #next()}, yet it passes
+		 * the elements of {@link {{$class}}#{{.NameNative}}} to {@code consumer}, one at a time, as
+		 * they arrive, instead of setting the field. The buffer holds one element
+		 * at most. The fields which precede {{.NameNative}} in the schema are set before
+		 * the first element, and the fields which follow are set after the last.
+		 * The size limit applies to each element, and to the other fields combined,
+		 * rather than to the object as a whole.
+		 * @param consumer the element receiver.
+		 * @return the result or {@code null} when EOF.
+		 * @throws IOException from the input stream.
+		 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+		 * @throws InputMismatchException when the data does not match this object's schema.
+{{- if .Struct.Pkg.StrictText}}
+		 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
+		 */
+		public {{$class}} next{{title .NameNative}}(Consumer<{{.TypeNative}}> consumer) throws IOException {
+			if (in == null) return null;
+
+			{{$class}} o = new {{$class}}();
+			int length = 0;
+			while (true) {
+				if (this.i > this.offset) {
+					try {
+						int at = o.unmarshalHead{{title .NameNative}}(this.buf, this.offset, this.i, 1);
+						if (this.buf[at] == (byte) {{.Index}}) {
+							at++;
+							for (int shift = 0; true; shift += 7) {
+								if (at >= this.i) throw new BufferUnderflowException();
+								byte b = this.buf[at++];
+								length |= (b & 0x7f) << shift;
+								if (shift == 28 || b >= 0) break;
+							}
+							if (length < 0 || length > {{$class}}.colferListMax)
+								throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+						}
+						this.offset = at;
+						break;
+					} catch (BufferUnderflowException e) {
+						length = 0;
+					}
 				}
-				assert this.i < this.buf.length;
+				// not enough data
 
-				int n = in.read(buf, i, buf.length - i);
-				if (n < 0) {
+				if (! fill()) {
 					if (this.i > this.offset)
 						throw new InputMismatchException("colfer: pending data with EOF");
 					return null;
 				}
-				assert n > 0;
-				i += n;
+			}
+
+			for (; length > 0; length--) {
+				{{.TypeNative}} e = new {{.TypeNative}}();
+				while (true) {
+					if (this.i > this.offset) {
+						try {
+							this.offset = e.unmarshal(this.buf, this.offset, this.i, 2);
+							break;
+						} catch (BufferUnderflowException ex) {
+						}
+					}
+					if (! fill()) throw new InputMismatchException("colfer: incomplete data with EOF");
+				}
+				consumer.accept(e);
+			}
+
+			while (true) {
+				if (this.i > this.offset) {
+					try {
+						this.offset = o.unmarshalTail{{title .NameNative}}(this.buf, this.offset, this.i, 1);
+						return o;
+					} catch (BufferUnderflowException e) {
+					}
+				}
+				if (! fill()) throw new InputMismatchException("colfer: incomplete data with EOF");
 			}
 		}
+{{- end}}{{end}}{{end}}
+
+		/**
+		 * Reads more data into the buffer, which grows when full.
+		 * @return whether any data was read, i.e., false on EOF.
+		 * @throws IOException from the input stream.
+		 */
+		private boolean fill() throws IOException {
+			if (this.i <= this.offset) {
+				this.offset = 0;
+				this.i = 0;
+			} else if (i == buf.length) {
+				byte[] src = this.buf;
+				if (offset == 0) this.buf = new byte[Math.min({{$class}}.colferSizeMax, this.buf.length * 4)];
+				System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
+				this.i -= this.offset;
+				this.offset = 0;
+			}
+			assert this.i < this.buf.length;
+
+			int n = in.read(buf, i, buf.length - i);
+			if (n < 0) return false;
+			assert n > 0;
+			i += n;
+			return true;
+		}
 
 	}
 
@@ -402,6 +512,9 @@ import java.nio.BufferUnderflowException;
 	 *  Otherwise the return is a new buffer, large enough to hold the whole serial.
 	 * @throws IOException from {@code out}.
 	 * @throws IllegalStateException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on text which can not be encoded as UTF-8.
+{{- end}}
 	 */
 	public byte[] marshal(OutputStream out, byte[] buf) throws IOException {
 		int n = 0;
@@ -426,6 +539,9 @@ import java.nio.BufferUnderflowException;
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferOverflowException when {@code buf} is too small.
 	 * @throws IllegalStateException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on text which can not be encoded as UTF-8.
+{{- end}}
 	 */
 	public int marshal(byte[] buf, int offset) {
 		int i = offset;
@@ -534,7 +650,7 @@ import java.nio.BufferUnderflowException;
 				buf[i++] = (byte) l;
 
 				for (float f : a) {
-					int x = Float.floatToRawIntBits(f);
+					int x = Float.floatTo{{if not .Struct.Pkg.Canonical}}Raw{{end}}IntBits(f);
 					buf[i++] = (byte) (x >>> 24);
 					buf[i++] = (byte) (x >>> 16);
 					buf[i++] = (byte) (x >>> 8);
@@ -544,7 +660,7 @@ import java.nio.BufferUnderflowException;
  {{- else}}
 			if (this.{{.NameNative}} != 0.0f) {
 				buf[i++] = (byte) {{.Index}};
-				int x = Float.floatToRawIntBits(this.{{.NameNative}});
+				int x = Float.floatTo{{if not .Struct.Pkg.Canonical}}Raw{{end}}IntBits(this.{{.NameNative}});
 				buf[i++] = (byte) (x >>> 24);
 				buf[i++] = (byte) (x >>> 16);
 				buf[i++] = (byte) (x >>> 8);
@@ -567,7 +683,7 @@ import java.nio.BufferUnderflowException;
 				buf[i++] = (byte) l;
 
 				for (double f : a) {
-					long x = Double.doubleToRawLongBits(f);
+					long x = Double.doubleTo{{if not .Struct.Pkg.Canonical}}Raw{{end}}LongBits(f);
 					buf[i++] = (byte) (x >>> 56);
 					buf[i++] = (byte) (x >>> 48);
 					buf[i++] = (byte) (x >>> 40);
@@ -581,7 +697,7 @@ import java.nio.BufferUnderflowException;
  {{- else}}
 			if (this.{{.NameNative}} != 0.0) {
 				buf[i++] = (byte) {{.Index}};
-				long x = Double.doubleToRawLongBits(this.{{.NameNative}});
+				long x = Double.doubleTo{{if not .Struct.Pkg.Canonical}}Raw{{end}}LongBits(this.{{.NameNative}});
 				buf[i++] = (byte) (x >>> 56);
 				buf[i++] = (byte) (x >>> 48);
 				buf[i++] = (byte) (x >>> 40);
@@ -660,6 +776,15 @@ import java.nio.BufferUnderflowException;
 							buf[i++] = (byte) (128 | c >>> 6 & 63);
 							buf[i++] = (byte) (128 | c & 63);
 						} else {
+						{{- if $.Pkg.StrictText}}
+							if (!Character.isHighSurrogate(c) || ++sIndex >= sLength || !Character.isLowSurrogate(s.charAt(sIndex)))
+								throw new UncheckedIOException(format("colfer: {{.String}}[%d] has an unpaired surrogate at char %d", ai, sIndex), new MalformedInputException(1));
+							int cp = Character.toCodePoint(c, s.charAt(sIndex));
+							buf[i++] = (byte) (240 | cp >>> 18);
+							buf[i++] = (byte) (128 | cp >>> 12 & 63);
+							buf[i++] = (byte) (128 | cp >>> 6 & 63);
+							buf[i++] = (byte) (128 | cp & 63);
+						{{- else}}
 							int cp = 0;
 							if (++sIndex < sLength) cp = Character.toCodePoint(c, s.charAt(sIndex));
 							if ((cp >= 1 << 16) && (cp < 1 << 21)) {
@@ -669,6 +794,7 @@ import java.nio.BufferUnderflowException;
 								buf[i++] = (byte) (128 | cp & 63);
 							} else
 								buf[i++] = (byte) '?';
+						{{- end}}
 						}
 					}
 					int size = i - start;
@@ -707,6 +833,15 @@ import java.nio.BufferUnderflowException;
 						buf[i++] = (byte) (128 | c >>> 6 & 63);
 						buf[i++] = (byte) (128 | c & 63);
 					} else {
+					{{- if $.Pkg.StrictText}}
+						if (!Character.isHighSurrogate(c) || ++sIndex >= sLength || !Character.isLowSurrogate(s.charAt(sIndex)))
+							throw new UncheckedIOException(format("colfer: {{.String}} has an unpaired surrogate at char %d", sIndex), new MalformedInputException(1));
+						int cp = Character.toCodePoint(c, s.charAt(sIndex));
+						buf[i++] = (byte) (240 | cp >>> 18);
+						buf[i++] = (byte) (128 | cp >>> 12 & 63);
+						buf[i++] = (byte) (128 | cp >>> 6 & 63);
+						buf[i++] = (byte) (128 | cp & 63);
+					{{- else}}
 						int cp = 0;
 						if (++sIndex < sLength) cp = Character.toCodePoint(c, s.charAt(sIndex));
 						if ((cp >= 1 << 16) && (cp < 1 << 21)) {
@@ -716,6 +851,7 @@ import java.nio.BufferUnderflowException;
 							buf[i++] = (byte) (128 | cp & 63);
 						} else
 							buf[i++] = (byte) '?';
+					{{- end}}
 					}
 				}
 				int size = i - start;
@@ -837,8 +973,11 @@ import java.nio.BufferUnderflowException;
 	 * @param offset the initial index for {@code buf}, inclusive.
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
-	 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
 	 * @throws InputMismatchException when the data does not match this object's schema.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
 	 */
 	public int unmarshal(byte[] buf, int offset) {
 		return unmarshal(buf, offset, buf.length);
@@ -851,16 +990,310 @@ import java.nio.BufferUnderflowException;
 	 * @param end the index limit for {@code buf}, exclusive.
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
-	 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
 	 * @throws InputMismatchException when the data does not match this object's schema.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
 	 */
 	public int unmarshal(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1);
+	}
+
+	/**
+	 * Deserializes the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object, with 1 for the top-level.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth) {
+		if (depth > {{$class}}.colferDepthMax)
+			throw new SecurityException(format("colfer: depth limit: struct {{.String}} exceeds %d levels", {{$class}}.colferDepthMax));
+		if (end > buf.length) end = buf.length;
+		int i = offset;
+
+		try {
+			byte header = buf[i++];
+{{range .Fields}}{{template "unmarshal-field" .}}{{end}}
+			if (header != (byte) 0x7f)
+				throw new InputMismatchException(format("colfer: unknown header at byte %d", i - 1));
+		} finally {
+			if (i > end && end - offset < {{$class}}.colferSizeMax) throw new BufferUnderflowException();
+			if (i < 0 || i - offset > {{$class}}.colferSizeMax)
+				throw new SecurityException(format("colfer: {{.String}} exceeds %d bytes", {{$class}}.colferSizeMax));
+			if (i > end) throw new BufferUnderflowException();
+		}
+
+		return i;
+	}
+{{- if .Pkg.Canonical}}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[],int,int)}, yet it
+	 * rejects any serial other than the one {@link #marshal(byte[],int)}
+	 * produces for the result. Equal values have identical serials as such.
+	 * Data after the serial, up to {@code end}, is rejected too. The check
+	 * serializes the result for comparison, which costs about as much as the
+	 * deserialization.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, when the encoding is not canonical, or when data continues after the serial.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
+	 */
+	public int unmarshalCanonical(byte[] buf, int offset, int end) {
+		int i = unmarshal(buf, offset, end);
+		if (i != end)
+			throw new InputMismatchException(format("colfer: data continuation at byte %d", i));
+
+		byte[] serial = new byte[marshalFit()];
+		int n = marshal(serial, 0);
+		for (int j = 0; j < n; j++) {
+			if (offset + j >= i || serial[j] != buf[offset + j])
+				throw new InputMismatchException(format("colfer: non-canonical encoding at byte %d", offset + j));
+		}
+		if (offset + n != i)
+			throw new InputMismatchException(format("colfer: non-canonical encoding at byte %d", offset + n));
+		return i;
+	}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[],int,int)}, and
+	 * serializes the result like {@link #marshal(byte[],int)}, which gives the
+	 * canonical serial. Data after the serial, up to {@code end}, is rejected.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the canonical serial.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or when data continues after the serial.
+{{- if .Pkg.StrictText}}
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+{{- end}}
+	 */
+	public byte[] canonicalize(byte[] buf, int offset, int end) {
+		int i = unmarshal(buf, offset, end);
+		if (i != end)
+			throw new InputMismatchException(format("colfer: data continuation at byte %d", i));
+
+		byte[] serial = new byte[marshalFit()];
+		return java.util.Arrays.copyOf(serial, marshal(serial, 0));
+	}
+{{- end}}
+{{- if .Pkg.Iterate}}{{range $f := .Fields}}{{if and .TypeList .TypeRef}}
+
+	/**
+	 * Deserializes the fields which precede {@link #{{.NameNative}}}.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object.
+	 * @return the index of the following header in {@code buf}, which is not consumed.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 */
+	private int unmarshalHead{{title .NameNative}}(byte[] buf, int offset, int end, int depth) {
+		if (end > buf.length) end = buf.length;
+		int i = offset;
+
+		try {
+			byte header = buf[i++];
+{{range .Struct.Fields}}{{if lt .Index $f.Index}}{{template "unmarshal-field" .}}{{end}}{{end}}
+		} finally {
+			if (i > end && end - offset < {{$class}}.colferSizeMax) throw new BufferUnderflowException();
+			if (i < 0 || i - offset > {{$class}}.colferSizeMax)
+				throw new SecurityException(format("colfer: {{.Struct.String}} exceeds %d bytes", {{$class}}.colferSizeMax));
+			if (i > end) throw new BufferUnderflowException();
+		}
+
+		return i - 1;
+	}
+
+	/**
+	 * Deserializes the fields which follow {@link #{{.NameNative}}}.
+	 * @param buf the data source.
+	 * @param offset the index of the header in {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 */
+	private int unmarshalTail{{title .NameNative}}(byte[] buf, int offset, int end, int depth) {
 		if (end > buf.length) end = buf.length;
 		int i = offset;
 
 		try {
 			byte header = buf[i++];
-{{range .Fields}}{{if eq .Type "bool"}}
+{{range .Struct.Fields}}{{if gt .Index $f.Index}}{{template "unmarshal-field" .}}{{end}}{{end}}
+			if (header != (byte) 0x7f)
+				throw new InputMismatchException(format("colfer: unknown header at byte %d", i - 1));
+		} finally {
+			if (i > end && end - offset < {{$class}}.colferSizeMax) throw new BufferUnderflowException();
+			if (i < 0 || i - offset > {{$class}}.colferSizeMax)
+				throw new SecurityException(format("colfer: {{.Struct.String}} exceeds %d bytes", {{$class}}.colferSizeMax));
+			if (i > end) throw new BufferUnderflowException();
+		}
+
+		return i;
+	}
+{{- end}}{{end}}{{end}}
+{{- if and .Pkg.StrictText .HasText}}
+
+	// decodeUTF8 returns the text at buf[start:start + size] conform RFC 3629.
+	private static String decodeUTF8(byte[] buf, int start, int size) {
+		try {
+			return StandardCharsets.UTF_8.newDecoder().decode(ByteBuffer.wrap(buf, start, size)).toString();
+		} catch (CharacterCodingException e) {
+			throw new UncheckedIOException(format("colfer: malformed UTF-8 at byte %d", start), e);
+		}
+	}
+{{- end}}
+
+	// {@link Serializable} version number.
+	private static final long serialVersionUID = {{len .Fields}}L;
+
+	// {@link Serializable} Colfer extension.
+	private void writeObject(ObjectOutputStream out) throws IOException {
+		byte[] buf = new byte[marshalFit()];
+		int n = marshal(buf, 0);
+		out.writeInt(n);
+		out.write(buf, 0, n);
+	}
+
+	// {@link Serializable} Colfer extension.
+	private void readObject(ObjectInputStream in) throws ClassNotFoundException, IOException {
+		init();
+
+		int n = in.readInt();
+		byte[] buf = new byte[n];
+		in.readFully(buf);
+		unmarshal(buf, 0);
+	}
+
+	// {@link Serializable} Colfer extension.
+	private void readObjectNoData() throws ObjectStreamException {
+		init();
+	}
+{{range .Fields}}
+	/**
+	 * Gets {{.String}}.
+	 * @return the value.
+	 */
+	public {{.TypeNative}}{{if .TypeList}}[]{{end}} get{{title .NameNative}}() {
+		return this.{{.NameNative}};
+	}
+
+	/**
+	 * Sets {{.String}}.
+	 * @param value the replacement.
+	 */
+	public void set{{title .NameNative}}({{.TypeNative}}{{if .TypeList}}[]{{end}} value) {
+		this.{{.NameNative}} = value;
+	}
+
+	/**
+	 * Sets {{.String}}.
+	 * @param value the replacement.
+	 * @return {@code this}.
+	 */
+	public {{$class}} with{{title .NameNative}}({{.TypeNative}}{{if .TypeList}}[]{{end}} value) {
+		this.{{.NameNative}} = value;
+		return this;
+	}
+{{end}}
+	@Override
+	public final int hashCode() {
+		int h = {{if .Pkg.SuperClass}}super.hashCode(){{else}}1{{end}};
+{{- range .Fields}}
+{{- if eq .Type "bool"}}
+		h = 31 * h + (this.{{.NameNative}} ? 1231 : 1237);
+{{- else if eq .Type "uint8"}}
+		h = 31 * h + (this.{{.NameNative}} & 0xff);
+{{- else if eq .Type "uint16"}}
+		h = 31 * h + (this.{{.NameNative}} & 0xffff);
+{{- else if eq .Type "uint32" "int32"}}
+		h = 31 * h + this.{{.NameNative}};
+{{- else if eq .Type "uint64" "int64"}}
+		h = 31 * h + (int)(this.{{.NameNative}} ^ this.{{.NameNative}} >>> 32);
+{{- else if eq .Type "float32"}}
+ {{- if .TypeList}}
+		h = 31 * h + java.util.Arrays.hashCode(this.{{.NameNative}});
+ {{- else}}
+		h = 31 * h + Float.floatToIntBits(this.{{.NameNative}});
+ {{- end}}
+{{- else if eq .Type "float64"}}
+ {{- if .TypeList}}
+		h = 31 * h + java.util.Arrays.hashCode(this.{{.NameNative}});
+ {{- else}}
+		long _{{.NameNative}}Bits = Double.doubleToLongBits(this.{{.NameNative}});
+		h = 31 * h + (int) (_{{.NameNative}}Bits ^ _{{.NameNative}}Bits >>> 32);
+ {{- end}}
+{{- else if eq .Type "binary"}}
+ {{- if .TypeList}}
+		for (byte[] b : this.{{.NameNative}}) h = 31 * h + java.util.Arrays.hashCode(b);
+ {{- else}}
+		for (byte b : this.{{.NameNative}}) h = 31 * h + b;
+ {{- end}}
+{{- else if .TypeList}}
+		for ({{.TypeNative}} o : this.{{.NameNative}}) h = 31 * h + (o == null ? 0 : o.hashCode());
+{{- else}}
+		if (this.{{.NameNative}} != null) h = 31 * h + this.{{.NameNative}}.hashCode();
+{{- end}}{{end}}
+		return h;
+	}
+
+	@Override
+	public final boolean equals(Object o) {
+		return o instanceof {{$class}} && equals(({{$class}}) o);
+	}
+
+	public final boolean equals({{$class}} o) {
+		if (o == null) return false;
+		if (o == this) return true;
+
+		return {{range .Fields}}
+{{- if eq .Index 0}}{{if .Struct.Pkg.SuperClass}}super.equals(o)
+			&& {{end}}{{else}}
+			&& {{end}}
+{{- if .TypeList}}
+ {{- if eq .Type "binary"}}_equals(this.{{.NameNative}}, o.{{.NameNative}})
+ {{- else}}java.util.Arrays.equals(this.{{.NameNative}}, o.{{.NameNative}})
+ {{- end}}
+{{- else if eq .Type "bool" "uint8" "uint16" "uint32" "uint64" "int32" "int64"}}this.{{.NameNative}} == o.{{.NameNative}}
+{{- else if eq .Type "float32" "float64"}}(this.{{.NameNative}} == o.{{.NameNative}} || (this.{{.NameNative}} != this.{{.NameNative}} && o.{{.NameNative}} != o.{{.NameNative}}))
+{{- else if eq .Type "binary"}}java.util.Arrays.equals(this.{{.NameNative}}, o.{{.NameNative}})
+{{- else}}(this.{{.NameNative}} == null ? o.{{.NameNative}} == null : this.{{.NameNative}}.equals(o.{{.NameNative}}))
+{{- end}}{{end}};
+	}
+{{if .HasBinaryList}}
+	private static boolean _equals(byte[][] a, byte[][] b) {
+		if (a == b) return true;
+		if (a == null || b == null) return false;
+
+		int i = a.length;
+		if (i != b.length) return false;
+
+		while (--i >= 0) if (! java.util.Arrays.equals(a[i], b[i])) return false;
+		return true;
+	}
+{{end}}
+}
+`
+
+const javaUnmarshalField = `{{if eq .Type "bool"}}
 			if (header == (byte) {{.Index}}) {
 				this.{{.NameNative}} = true;
 				header = buf[i++];
@@ -965,8 +1398,12 @@ import java.nio.BufferUnderflowException;
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (length < 0 || length > {{$class}}.colferListMax)
-					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+				if (length < 0 || length > {{.Struct.NameNative}}.colferListMax)
+					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{.Struct.NameNative}}.colferListMax));
+				if (i + length * 4 >= end) {
+					i += length * 4 + 1; // elements take 4 bytes each, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				float[] a = new float[length];
 				for (int ai = 0; ai < length; ai++) {
@@ -989,8 +1426,12 @@ import java.nio.BufferUnderflowException;
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (length < 0 || length > {{$class}}.colferListMax)
-					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+				if (length < 0 || length > {{.Struct.NameNative}}.colferListMax)
+					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{.Struct.NameNative}}.colferListMax));
+				if (i + length * 8 >= end) {
+					i += length * 8 + 1; // elements take 8 bytes each, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				double[] a = new double[length];
 				for (int ai = 0; ai < length; ai++) {
@@ -1028,8 +1469,12 @@ import java.nio.BufferUnderflowException;
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (length < 0 || length > {{$class}}.colferListMax)
-					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+				if (length < 0 || length > {{.Struct.NameNative}}.colferListMax)
+					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{.Struct.NameNative}}.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				{{.TypeNative}}[] a = new {{.TypeNative}}[length];
 				for (int ai = 0; ai < length; ai++) {
@@ -1039,12 +1484,16 @@ import java.nio.BufferUnderflowException;
 						size |= (b & 0x7f) << shift;
 						if (shift == 28 || b >= 0) break;
 					}
-					if (size < 0 || size > {{$class}}.colferSizeMax)
-						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d UTF-8 bytes", ai, size, {{$class}}.colferSizeMax));
+					if (size < 0 || size > {{.Struct.NameNative}}.colferSizeMax)
+						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d UTF-8 bytes", ai, size, {{.Struct.NameNative}}.colferSizeMax));
 
 					int start = i;
 					i += size;
+{{- if .Struct.Pkg.StrictText}}
+					a[ai] = decodeUTF8(buf, start, size);
+{{- else}}
 					a[ai] = new String(buf, start, size, StandardCharsets.UTF_8);
+{{- end}}
 				}
 				this.{{.NameNative}} = a;
  {{- else}}
@@ -1054,12 +1503,16 @@ import java.nio.BufferUnderflowException;
 					size |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (size < 0 || size > {{$class}}.colferSizeMax)
-					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d UTF-8 bytes", size, {{$class}}.colferSizeMax));
+				if (size < 0 || size > {{.Struct.NameNative}}.colferSizeMax)
+					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d UTF-8 bytes", size, {{.Struct.NameNative}}.colferSizeMax));
 
 				int start = i;
 				i += size;
+{{- if .Struct.Pkg.StrictText}}
+				this.{{.NameNative}} = decodeUTF8(buf, start, size);
+{{- else}}
 				this.{{.NameNative}} = new String(buf, start, size, StandardCharsets.UTF_8);
+{{- end}}
  {{- end}}
 				header = buf[i++];
 			}
@@ -1072,8 +1525,12 @@ import java.nio.BufferUnderflowException;
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (length < 0 || length > {{$class}}.colferListMax)
-					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+				if (length < 0 || length > {{.Struct.NameNative}}.colferListMax)
+					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{.Struct.NameNative}}.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				byte[][] a = new byte[length][];
 				for (int ai = 0; ai < length; ai++) {
@@ -1083,8 +1540,12 @@ import java.nio.BufferUnderflowException;
 						size |= (b & 0x7f) << shift;
 						if (shift == 28 || b >= 0) break;
 					}
-					if (size < 0 || size > {{$class}}.colferSizeMax)
-						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d bytes", ai, size, {{$class}}.colferSizeMax));
+					if (size < 0 || size > {{.Struct.NameNative}}.colferSizeMax)
+						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d bytes", ai, size, {{.Struct.NameNative}}.colferSizeMax));
+					if (i + size >= end) {
+						i += size + 1; // no allocation before the data is there
+						throw new BufferUnderflowException();
+					}
 
 					byte[] e = new byte[size];
 					int start = i;
@@ -1104,8 +1565,12 @@ import java.nio.BufferUnderflowException;
 					size |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (size < 0 || size > {{$class}}.colferSizeMax)
-					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d bytes", size, {{$class}}.colferSizeMax));
+				if (size < 0 || size > {{.Struct.NameNative}}.colferSizeMax)
+					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d bytes", size, {{.Struct.NameNative}}.colferSizeMax));
+				if (i + size >= end) {
+					i += size + 1; // no allocation before the data is there
+					throw new BufferUnderflowException();
+				}
 
 				this.{{.NameNative}} = new byte[size];
 				int start = i;
@@ -1123,13 +1588,18 @@ import java.nio.BufferUnderflowException;
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
-				if (length < 0 || length > {{$class}}.colferListMax)
-					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+				if (length < 0 || length > {{.Struct.NameNative}}.colferListMax)
+					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{.Struct.NameNative}}.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				{{.TypeNative}}[] a = new {{.TypeNative}}[length];
 				for (int ai = 0; ai < length; ai++) {
 					{{.TypeNative}} o = new {{.TypeNative}}();
-					i = o.unmarshal(buf, i, end);
+					// reserve one byte for each element pending
+					i = o.unmarshal(buf, i, end - (length - 1 - ai), depth + 1);
 					a[ai] = o;
 				}
 				this.{{.NameNative}} = a;
@@ -1138,149 +1608,7 @@ import java.nio.BufferUnderflowException;
 {{else}}
 			if (header == (byte) {{.Index}}) {
 				this.{{.NameNative}} = new {{.TypeNative}}();
-				i = this.{{.NameNative}}.unmarshal(buf, i, end);
+				i = this.{{.NameNative}}.unmarshal(buf, i, end, depth + 1);
 				header = buf[i++];
 			}
-{{end}}{{end}}
-			if (header != (byte) 0x7f)
-				throw new InputMismatchException(format("colfer: unknown header at byte %d", i - 1));
-		} finally {
-			if (i > end && end - offset < {{$class}}.colferSizeMax) throw new BufferUnderflowException();
-			if (i < 0 || i - offset > {{$class}}.colferSizeMax)
-				throw new SecurityException(format("colfer: {{.String}} exceeds %d bytes", {{$class}}.colferSizeMax));
-			if (i > end) throw new BufferUnderflowException();
-		}
-
-		return i;
-	}
-
-	// {@link Serializable} version number.
-	private static final long serialVersionUID = {{len .Fields}}L;
-
-	// {@link Serializable} Colfer extension.
-	private void writeObject(ObjectOutputStream out) throws IOException {
-		byte[] buf = new byte[marshalFit()];
-		int n = marshal(buf, 0);
-		out.writeInt(n);
-		out.write(buf, 0, n);
-	}
-
-	// {@link Serializable} Colfer extension.
-	private void readObject(ObjectInputStream in) throws ClassNotFoundException, IOException {
-		init();
-
-		int n = in.readInt();
-		byte[] buf = new byte[n];
-		in.readFully(buf);
-		unmarshal(buf, 0);
-	}
-
-	// {@link Serializable} Colfer extension.
-	private void readObjectNoData() throws ObjectStreamException {
-		init();
-	}
-{{range .Fields}}
-	/**
-	 * Gets {{.String}}.
-	 * @return the value.
-	 */
-	public {{.TypeNative}}{{if .TypeList}}[]{{end}} get{{title .NameNative}}() {
-		return this.{{.NameNative}};
-	}
-
-	/**
-	 * Sets {{.String}}.
-	 * @param value the replacement.
-	 */
-	public void set{{title .NameNative}}({{.TypeNative}}{{if .TypeList}}[]{{end}} value) {
-		this.{{.NameNative}} = value;
-	}
-
-	/**
-	 * Sets {{.String}}.
-	 * @param value the replacement.
-	 * @return {@code this}.
-	 */
-	public {{$class}} with{{title .NameNative}}({{.TypeNative}}{{if .TypeList}}[]{{end}} value) {
-		this.{{.NameNative}} = value;
-		return this;
-	}
-{{end}}
-	@Override
-	public final int hashCode() {
-		int h = {{if .Pkg.SuperClass}}super.hashCode(){{else}}1{{end}};
-{{- range .Fields}}
-{{- if eq .Type "bool"}}
-		h = 31 * h + (this.{{.NameNative}} ? 1231 : 1237);
-{{- else if eq .Type "uint8"}}
-		h = 31 * h + (this.{{.NameNative}} & 0xff);
-{{- else if eq .Type "uint16"}}
-		h = 31 * h + (this.{{.NameNative}} & 0xffff);
-{{- else if eq .Type "uint32" "int32"}}
-		h = 31 * h + this.{{.NameNative}};
-{{- else if eq .Type "uint64" "int64"}}
-		h = 31 * h + (int)(this.{{.NameNative}} ^ this.{{.NameNative}} >>> 32);
-{{- else if eq .Type "float32"}}
- {{- if .TypeList}}
-		h = 31 * h + java.util.Arrays.hashCode(this.{{.NameNative}});
- {{- else}}
-		h = 31 * h + Float.floatToIntBits(this.{{.NameNative}});
- {{- end}}
-{{- else if eq .Type "float64"}}
- {{- if .TypeList}}
-		h = 31 * h + java.util.Arrays.hashCode(this.{{.NameNative}});
- {{- else}}
-		long _{{.NameNative}}Bits = Double.doubleToLongBits(this.{{.NameNative}});
-		h = 31 * h + (int) (_{{.NameNative}}Bits ^ _{{.NameNative}}Bits >>> 32);
- {{- end}}
-{{- else if eq .Type "binary"}}
- {{- if .TypeList}}
-		for (byte[] b : this.{{.NameNative}}) h = 31 * h + java.util.Arrays.hashCode(b);
- {{- else}}
-		for (byte b : this.{{.NameNative}}) h = 31 * h + b;
- {{- end}}
-{{- else if .TypeList}}
-		for ({{.TypeNative}} o : this.{{.NameNative}}) h = 31 * h + (o == null ? 0 : o.hashCode());
-{{- else}}
-		if (this.{{.NameNative}} != null) h = 31 * h + this.{{.NameNative}}.hashCode();
-{{- end}}{{end}}
-		return h;
-	}
-
-	@Override
-	public final boolean equals(Object o) {
-		return o instanceof {{$class}} && equals(({{$class}}) o);
-	}
-
-	public final boolean equals({{$class}} o) {
-		if (o == null) return false;
-		if (o == this) return true;
-
-		return {{range .Fields}}
-{{- if eq .Index 0}}{{if .Struct.Pkg.SuperClass}}super.equals(o)
-			&& {{end}}{{else}}
-			&& {{end}}
-{{- if .TypeList}}
- {{- if eq .Type "binary"}}_equals(this.{{.NameNative}}, o.{{.NameNative}})
- {{- else}}java.util.Arrays.equals(this.{{.NameNative}}, o.{{.NameNative}})
- {{- end}}
-{{- else if eq .Type "bool" "uint8" "uint16" "uint32" "uint64" "int32" "int64"}}this.{{.NameNative}} == o.{{.NameNative}}
-{{- else if eq .Type "float32" "float64"}}(this.{{.NameNative}} == o.{{.NameNative}} || (this.{{.NameNative}} != this.{{.NameNative}} && o.{{.NameNative}} != o.{{.NameNative}}))
-{{- else if eq .Type "binary"}}java.util.Arrays.equals(this.{{.NameNative}}, o.{{.NameNative}})
-{{- else}}(this.{{.NameNative}} == null ? o.{{.NameNative}} == null : this.{{.NameNative}}.equals(o.{{.NameNative}}))
-{{- end}}{{end}};
-	}
-{{if .HasBinaryList}}
-	private static boolean _equals(byte[][] a, byte[][] b) {
-		if (a == b) return true;
-		if (a == null || b == null) return false;
-
-		int i = a.length;
-		if (i != b.length) return false;
-
-		while (--i >= 0) if (! java.util.Arrays.equals(a[i], b[i])) return false;
-		return true;
-	}
-{{end}}
-}
-`
+{{end}}`
@@ -13,6 +13,10 @@ import java.io.ObjectOutputStream;
 import java.io.ObjectStreamException;
 import java.io.OutputStream;
 import java.io.Serializable;
+import java.io.UncheckedIOException;
+import java.nio.ByteBuffer;
+import java.nio.charset.CharacterCodingException;
+import java.nio.charset.MalformedInputException;
 import java.nio.charset.StandardCharsets;
 import java.util.InputMismatchException;
 import java.nio.BufferOverflowException;
@@ -33,6 +37,11 @@ public class DromedaryCase implements Serializable {
 	/** The upper limit for serial byte sizes. */
 	public static int colferSizeMax = 16 * 1024 * 1024;
 
+	/** The upper limit for the nesting level of data structures, with 1 for the top-level. */
+	public static int colferDepthMax = 100;
+
+	/** The schema digest, which changes with any change to the fields, including the data structures referred to. */
+	public static final String colferFingerprint = "f21325a195f2e6e5";
 
 	@Deprecated(forRemoval=true)
 	// @javax.validation.constraints.NotNull
@@ -94,8 +103,9 @@ public class DromedaryCase implements Serializable {
 		 * Deserializes the following object.
 		 * @return the result or {@code null} when EOF.
 		 * @throws IOException from the input stream.
-		 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}.
+		 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax} or {@link #colferDepthMax}.
 		 * @throws InputMismatchException when the data does not match this object's schema.
+		 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
 		 */
 		public DromedaryCase next() throws IOException {
 			if (in == null) return null;
@@ -111,29 +121,39 @@ public class DromedaryCase implements Serializable {
 				}
 				// not enough data
 
-				if (this.i <= this.offset) {
-					this.offset = 0;
-					this.i = 0;
-				} else if (i == buf.length) {
-					byte[] src = this.buf;
-					if (offset == 0) this.buf = new byte[Math.min(DromedaryCase.colferSizeMax, this.buf.length * 4)];
-					System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
-					this.i -= this.offset;
-					this.offset = 0;
-				}
-				assert this.i < this.buf.length;
-
-				int n = in.read(buf, i, buf.length - i);
-				if (n < 0) {
+				if (! fill()) {
 					if (this.i > this.offset)
 						throw new InputMismatchException("colfer: pending data with EOF");
 					return null;
 				}
-				assert n > 0;
-				i += n;
 			}
 		}
 
+		/**
+		 * Reads more data into the buffer, which grows when full.
+		 * @return whether any data was read, i.e., false on EOF.
+		 * @throws IOException from the input stream.
+		 */
+		private boolean fill() throws IOException {
+			if (this.i <= this.offset) {
+				this.offset = 0;
+				this.i = 0;
+			} else if (i == buf.length) {
+				byte[] src = this.buf;
+				if (offset == 0) this.buf = new byte[Math.min(DromedaryCase.colferSizeMax, this.buf.length * 4)];
+				System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
+				this.i -= this.offset;
+				this.offset = 0;
+			}
+			assert this.i < this.buf.length;
+
+			int n = in.read(buf, i, buf.length - i);
+			if (n < 0) return false;
+			assert n > 0;
+			i += n;
+			return true;
+		}
+
 	}
 
 	/**
@@ -155,6 +175,7 @@ public class DromedaryCase implements Serializable {
 	 *  Otherwise the return is a new buffer, large enough to hold the whole serial.
 	 * @throws IOException from {@code out}.
 	 * @throws IllegalStateException on an upper limit breach defined by {@link #colferSizeMax}.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on text which can not be encoded as UTF-8.
 	 */
 	public byte[] marshal(OutputStream out, byte[] buf) throws IOException {
 		int n = 0;
@@ -176,6 +197,7 @@ public class DromedaryCase implements Serializable {
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferOverflowException when {@code buf} is too small.
 	 * @throws IllegalStateException on an upper limit breach defined by {@link #colferSizeMax}.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on text which can not be encoded as UTF-8.
 	 */
 	public int marshal(byte[] buf, int offset) {
 		int i = offset;
@@ -198,15 +220,13 @@ public class DromedaryCase implements Serializable {
 						buf[i++] = (byte) (128 | c >>> 6 & 63);
 						buf[i++] = (byte) (128 | c & 63);
 					} else {
-						int cp = 0;
-						if (++sIndex < sLength) cp = Character.toCodePoint(c, s.charAt(sIndex));
-						if ((cp >= 1 << 16) && (cp < 1 << 21)) {
-							buf[i++] = (byte) (240 | cp >>> 18);
-							buf[i++] = (byte) (128 | cp >>> 12 & 63);
-							buf[i++] = (byte) (128 | cp >>> 6 & 63);
-							buf[i++] = (byte) (128 | cp & 63);
-						} else
-							buf[i++] = (byte) '?';
+						if (!Character.isHighSurrogate(c) || ++sIndex >= sLength || !Character.isLowSurrogate(s.charAt(sIndex)))
+							throw new UncheckedIOException(format("colfer: gen.dromedaryCase.PascalCase has an unpaired surrogate at char %d", sIndex), new MalformedInputException(1));
+						int cp = Character.toCodePoint(c, s.charAt(sIndex));
+						buf[i++] = (byte) (240 | cp >>> 18);
+						buf[i++] = (byte) (128 | cp >>> 12 & 63);
+						buf[i++] = (byte) (128 | cp >>> 6 & 63);
+						buf[i++] = (byte) (128 | cp & 63);
 					}
 				}
 				int size = i - start;
@@ -243,8 +263,9 @@ public class DromedaryCase implements Serializable {
 	 * @param offset the initial index for {@code buf}, inclusive.
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
-	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax} or {@link #colferDepthMax}.
 	 * @throws InputMismatchException when the data does not match this object's schema.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
 	 */
 	public int unmarshal(byte[] buf, int offset) {
 		return unmarshal(buf, offset, buf.length);
@@ -257,10 +278,29 @@ public class DromedaryCase implements Serializable {
 	 * @param end the index limit for {@code buf}, exclusive.
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
-	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax} or {@link #colferDepthMax}.
 	 * @throws InputMismatchException when the data does not match this object's schema.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
 	 */
 	public int unmarshal(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1);
+	}
+
+	/**
+	 * Deserializes the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object, with 1 for the top-level.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth) {
+		if (depth > DromedaryCase.colferDepthMax)
+			throw new SecurityException(format("colfer: depth limit: struct gen.dromedaryCase exceeds %d levels", DromedaryCase.colferDepthMax));
 		if (end > buf.length) end = buf.length;
 		int i = offset;
 
@@ -279,7 +319,7 @@ public class DromedaryCase implements Serializable {
 
 				int start = i;
 				i += size;
-				this.pascalCase = new String(buf, start, size, StandardCharsets.UTF_8);
+				this.pascalCase = decodeUTF8(buf, start, size);
 				header = buf[i++];
 			}
 
@@ -295,6 +335,69 @@ public class DromedaryCase implements Serializable {
 		return i;
 	}
 
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[],int,int)}, yet it
+	 * rejects any serial other than the one {@link #marshal(byte[],int)}
+	 * produces for the result. Equal values have identical serials as such.
+	 * Data after the serial, up to {@code end}, is rejected too. The check
+	 * serializes the result for comparison, which costs about as much as the
+	 * deserialization.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, when the encoding is not canonical, or when data continues after the serial.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+	 */
+	public int unmarshalCanonical(byte[] buf, int offset, int end) {
+		int i = unmarshal(buf, offset, end);
+		if (i != end)
+			throw new InputMismatchException(format("colfer: data continuation at byte %d", i));
+
+		byte[] serial = new byte[marshalFit()];
+		int n = marshal(serial, 0);
+		for (int j = 0; j < n; j++) {
+			if (offset + j >= i || serial[j] != buf[offset + j])
+				throw new InputMismatchException(format("colfer: non-canonical encoding at byte %d", offset + j));
+		}
+		if (offset + n != i)
+			throw new InputMismatchException(format("colfer: non-canonical encoding at byte %d", offset + n));
+		return i;
+	}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[],int,int)}, and
+	 * serializes the result like {@link #marshal(byte[],int)}, which gives the
+	 * canonical serial. Data after the serial, up to {@code end}, is rejected.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the canonical serial.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or when data continues after the serial.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+	 */
+	public byte[] canonicalize(byte[] buf, int offset, int end) {
+		int i = unmarshal(buf, offset, end);
+		if (i != end)
+			throw new InputMismatchException(format("colfer: data continuation at byte %d", i));
+
+		byte[] serial = new byte[marshalFit()];
+		return java.util.Arrays.copyOf(serial, marshal(serial, 0));
+	}
+
+	// decodeUTF8 returns the text at buf[start:start + size] conform RFC 3629.
+	private static String decodeUTF8(byte[] buf, int start, int size) {
+		try {
+			return StandardCharsets.UTF_8.newDecoder().decode(ByteBuffer.wrap(buf, start, size)).toString();
+		} catch (CharacterCodingException e) {
+			throw new UncheckedIOException(format("colfer: malformed UTF-8 at byte %d", start), e);
+		}
+	}
+
 	// {@link Serializable} version number.
 	private static final long serialVersionUID = 1L;
 
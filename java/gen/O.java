@@ -13,8 +13,13 @@ import java.io.ObjectOutputStream;
 import java.io.ObjectStreamException;
 import java.io.OutputStream;
 import java.io.Serializable;
+import java.io.UncheckedIOException;
+import java.nio.ByteBuffer;
+import java.nio.charset.CharacterCodingException;
+import java.nio.charset.MalformedInputException;
 import java.nio.charset.StandardCharsets;
 import java.util.InputMismatchException;
+import java.util.function.Consumer;
 import java.nio.BufferOverflowException;
 import java.nio.BufferUnderflowException;
 
@@ -33,6 +38,11 @@ public class O implements Serializable {
 	/** The upper limit for the number of elements in a list. */
 	public static int colferListMax = 64 * 1024;
 
+	/** The upper limit for the nesting level of data structures, with 1 for the top-level. */
+	public static int colferDepthMax = 100;
+
+	/** The schema digest, which changes with any change to the fields, including the data structures referred to. */
+	public static final String colferFingerprint = "98768e7cc2fcda72";
 
 	/**
 	 * B tests booleans.
@@ -192,8 +202,9 @@ public class O implements Serializable {
 		 * Deserializes the following object.
 		 * @return the result or {@code null} when EOF.
 		 * @throws IOException from the input stream.
-		 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+		 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
 		 * @throws InputMismatchException when the data does not match this object's schema.
+		 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
 		 */
 		public O next() throws IOException {
 			if (in == null) return null;
@@ -209,29 +220,116 @@ public class O implements Serializable {
 				}
 				// not enough data
 
-				if (this.i <= this.offset) {
-					this.offset = 0;
-					this.i = 0;
-				} else if (i == buf.length) {
-					byte[] src = this.buf;
-					if (offset == 0) this.buf = new byte[Math.min(O.colferSizeMax, this.buf.length * 4)];
-					System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
-					this.i -= this.offset;
-					this.offset = 0;
+				if (! fill()) {
+					if (this.i > this.offset)
+						throw new InputMismatchException("colfer: pending data with EOF");
+					return null;
+				}
+			}
+		}
+
+		/**
+		 * Deserializes the following object like {@link #next()}, yet it passes
+		 * the elements of {@link O#os} to {@code consumer}, one at a time, as
+		 * they arrive, instead of setting the field. The buffer holds one element
+		 * at most. The fields which precede os in the schema are set before
+		 * the first element, and the fields which follow are set after the last.
+		 * The size limit applies to each element, and to the other fields combined,
+		 * rather than to the object as a whole.
+		 * @param consumer the element receiver.
+		 * @return the result or {@code null} when EOF.
+		 * @throws IOException from the input stream.
+		 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+		 * @throws InputMismatchException when the data does not match this object's schema.
+		 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+		 */
+		public O nextOs(Consumer<O> consumer) throws IOException {
+			if (in == null) return null;
+
+			O o = new O();
+			int length = 0;
+			while (true) {
+				if (this.i > this.offset) {
+					try {
+						int at = o.unmarshalHeadOs(this.buf, this.offset, this.i, 1);
+						if (this.buf[at] == (byte) 11) {
+							at++;
+							for (int shift = 0; true; shift += 7) {
+								if (at >= this.i) throw new BufferUnderflowException();
+								byte b = this.buf[at++];
+								length |= (b & 0x7f) << shift;
+								if (shift == 28 || b >= 0) break;
+							}
+							if (length < 0 || length > O.colferListMax)
+								throw new SecurityException(format("colfer: gen.o.os length %d exceeds %d elements", length, O.colferListMax));
+						}
+						this.offset = at;
+						break;
+					} catch (BufferUnderflowException e) {
+						length = 0;
+					}
 				}
-				assert this.i < this.buf.length;
+				// not enough data
 
-				int n = in.read(buf, i, buf.length - i);
-				if (n < 0) {
+				if (! fill()) {
 					if (this.i > this.offset)
 						throw new InputMismatchException("colfer: pending data with EOF");
 					return null;
 				}
-				assert n > 0;
-				i += n;
+			}
+
+			for (; length > 0; length--) {
+				O e = new O();
+				while (true) {
+					if (this.i > this.offset) {
+						try {
+							this.offset = e.unmarshal(this.buf, this.offset, this.i, 2);
+							break;
+						} catch (BufferUnderflowException ex) {
+						}
+					}
+					if (! fill()) throw new InputMismatchException("colfer: incomplete data with EOF");
+				}
+				consumer.accept(e);
+			}
+
+			while (true) {
+				if (this.i > this.offset) {
+					try {
+						this.offset = o.unmarshalTailOs(this.buf, this.offset, this.i, 1);
+						return o;
+					} catch (BufferUnderflowException e) {
+					}
+				}
+				if (! fill()) throw new InputMismatchException("colfer: incomplete data with EOF");
 			}
 		}
 
+		/**
+		 * Reads more data into the buffer, which grows when full.
+		 * @return whether any data was read, i.e., false on EOF.
+		 * @throws IOException from the input stream.
+		 */
+		private boolean fill() throws IOException {
+			if (this.i <= this.offset) {
+				this.offset = 0;
+				this.i = 0;
+			} else if (i == buf.length) {
+				byte[] src = this.buf;
+				if (offset == 0) this.buf = new byte[Math.min(O.colferSizeMax, this.buf.length * 4)];
+				System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
+				this.i -= this.offset;
+				this.offset = 0;
+			}
+			assert this.i < this.buf.length;
+
+			int n = in.read(buf, i, buf.length - i);
+			if (n < 0) return false;
+			assert n > 0;
+			i += n;
+			return true;
+		}
+
 	}
 
 	/**
@@ -263,6 +361,7 @@ public class O implements Serializable {
 	 *  Otherwise the return is a new buffer, large enough to hold the whole serial.
 	 * @throws IOException from {@code out}.
 	 * @throws IllegalStateException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on text which can not be encoded as UTF-8.
 	 */
 	public byte[] marshal(OutputStream out, byte[] buf) throws IOException {
 		int n = 0;
@@ -287,6 +386,7 @@ public class O implements Serializable {
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferOverflowException when {@code buf} is too small.
 	 * @throws IllegalStateException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on text which can not be encoded as UTF-8.
 	 */
 	public int marshal(byte[] buf, int offset) {
 		int i = offset;
@@ -365,7 +465,7 @@ public class O implements Serializable {
 
 			if (this.f32 != 0.0f) {
 				buf[i++] = (byte) 5;
-				int x = Float.floatToRawIntBits(this.f32);
+				int x = Float.floatToIntBits(this.f32);
 				buf[i++] = (byte) (x >>> 24);
 				buf[i++] = (byte) (x >>> 16);
 				buf[i++] = (byte) (x >>> 8);
@@ -374,7 +474,7 @@ public class O implements Serializable {
 
 			if (this.f64 != 0.0) {
 				buf[i++] = (byte) 6;
-				long x = Double.doubleToRawLongBits(this.f64);
+				long x = Double.doubleToLongBits(this.f64);
 				buf[i++] = (byte) (x >>> 56);
 				buf[i++] = (byte) (x >>> 48);
 				buf[i++] = (byte) (x >>> 40);
@@ -434,15 +534,13 @@ public class O implements Serializable {
 						buf[i++] = (byte) (128 | c >>> 6 & 63);
 						buf[i++] = (byte) (128 | c & 63);
 					} else {
-						int cp = 0;
-						if (++sIndex < sLength) cp = Character.toCodePoint(c, s.charAt(sIndex));
-						if ((cp >= 1 << 16) && (cp < 1 << 21)) {
-							buf[i++] = (byte) (240 | cp >>> 18);
-							buf[i++] = (byte) (128 | cp >>> 12 & 63);
-							buf[i++] = (byte) (128 | cp >>> 6 & 63);
-							buf[i++] = (byte) (128 | cp & 63);
-						} else
-							buf[i++] = (byte) '?';
+						if (!Character.isHighSurrogate(c) || ++sIndex >= sLength || !Character.isLowSurrogate(s.charAt(sIndex)))
+							throw new UncheckedIOException(format("colfer: gen.o.s has an unpaired surrogate at char %d", sIndex), new MalformedInputException(1));
+						int cp = Character.toCodePoint(c, s.charAt(sIndex));
+						buf[i++] = (byte) (240 | cp >>> 18);
+						buf[i++] = (byte) (128 | cp >>> 12 & 63);
+						buf[i++] = (byte) (128 | cp >>> 6 & 63);
+						buf[i++] = (byte) (128 | cp & 63);
 					}
 				}
 				int size = i - start;
@@ -544,15 +642,13 @@ public class O implements Serializable {
 							buf[i++] = (byte) (128 | c >>> 6 & 63);
 							buf[i++] = (byte) (128 | c & 63);
 						} else {
-							int cp = 0;
-							if (++sIndex < sLength) cp = Character.toCodePoint(c, s.charAt(sIndex));
-							if ((cp >= 1 << 16) && (cp < 1 << 21)) {
-								buf[i++] = (byte) (240 | cp >>> 18);
-								buf[i++] = (byte) (128 | cp >>> 12 & 63);
-								buf[i++] = (byte) (128 | cp >>> 6 & 63);
-								buf[i++] = (byte) (128 | cp & 63);
-							} else
-								buf[i++] = (byte) '?';
+							if (!Character.isHighSurrogate(c) || ++sIndex >= sLength || !Character.isLowSurrogate(s.charAt(sIndex)))
+								throw new UncheckedIOException(format("colfer: gen.o.ss[%d] has an unpaired surrogate at char %d", ai, sIndex), new MalformedInputException(1));
+							int cp = Character.toCodePoint(c, s.charAt(sIndex));
+							buf[i++] = (byte) (240 | cp >>> 18);
+							buf[i++] = (byte) (128 | cp >>> 12 & 63);
+							buf[i++] = (byte) (128 | cp >>> 6 & 63);
+							buf[i++] = (byte) (128 | cp & 63);
 						}
 					}
 					int size = i - start;
@@ -639,7 +735,7 @@ public class O implements Serializable {
 				buf[i++] = (byte) l;
 
 				for (float f : a) {
-					int x = Float.floatToRawIntBits(f);
+					int x = Float.floatToIntBits(f);
 					buf[i++] = (byte) (x >>> 24);
 					buf[i++] = (byte) (x >>> 16);
 					buf[i++] = (byte) (x >>> 8);
@@ -661,7 +757,7 @@ public class O implements Serializable {
 				buf[i++] = (byte) l;
 
 				for (double f : a) {
-					long x = Double.doubleToRawLongBits(f);
+					long x = Double.doubleToLongBits(f);
 					buf[i++] = (byte) (x >>> 56);
 					buf[i++] = (byte) (x >>> 48);
 					buf[i++] = (byte) (x >>> 40);
@@ -689,8 +785,9 @@ public class O implements Serializable {
 	 * @param offset the initial index for {@code buf}, inclusive.
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
-	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
 	 * @throws InputMismatchException when the data does not match this object's schema.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
 	 */
 	public int unmarshal(byte[] buf, int offset) {
 		return unmarshal(buf, offset, buf.length);
@@ -703,10 +800,29 @@ public class O implements Serializable {
 	 * @param end the index limit for {@code buf}, exclusive.
 	 * @return the final index for {@code buf}, exclusive.
 	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
-	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
 	 * @throws InputMismatchException when the data does not match this object's schema.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
 	 */
 	public int unmarshal(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1);
+	}
+
+	/**
+	 * Deserializes the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object, with 1 for the top-level.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth) {
+		if (depth > O.colferDepthMax)
+			throw new SecurityException(format("colfer: depth limit: struct gen.o exceeds %d levels", O.colferDepthMax));
 		if (end > buf.length) end = buf.length;
 		int i = offset;
 
@@ -834,7 +950,7 @@ public class O implements Serializable {
 
 				int start = i;
 				i += size;
-				this.s = new String(buf, start, size, StandardCharsets.UTF_8);
+				this.s = decodeUTF8(buf, start, size);
 				header = buf[i++];
 			}
 
@@ -847,6 +963,10 @@ public class O implements Serializable {
 				}
 				if (size < 0 || size > O.colferSizeMax)
 					throw new SecurityException(format("colfer: gen.o.a size %d exceeds %d bytes", size, O.colferSizeMax));
+				if (i + size >= end) {
+					i += size + 1; // no allocation before the data is there
+					throw new BufferUnderflowException();
+				}
 
 				this.a = new byte[size];
 				int start = i;
@@ -858,7 +978,7 @@ public class O implements Serializable {
 
 			if (header == (byte) 10) {
 				this.o = new O();
-				i = this.o.unmarshal(buf, i, end);
+				i = this.o.unmarshal(buf, i, end, depth + 1);
 				header = buf[i++];
 			}
 
@@ -871,11 +991,16 @@ public class O implements Serializable {
 				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.os length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				O[] a = new O[length];
 				for (int ai = 0; ai < length; ai++) {
 					O o = new O();
-					i = o.unmarshal(buf, i, end);
+					// reserve one byte for each element pending
+					i = o.unmarshal(buf, i, end - (length - 1 - ai), depth + 1);
 					a[ai] = o;
 				}
 				this.os = a;
@@ -891,6 +1016,404 @@ public class O implements Serializable {
 				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.ss length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
+
+				String[] a = new String[length];
+				for (int ai = 0; ai < length; ai++) {
+					int size = 0;
+					for (int shift = 0; true; shift += 7) {
+						byte b = buf[i++];
+						size |= (b & 0x7f) << shift;
+						if (shift == 28 || b >= 0) break;
+					}
+					if (size < 0 || size > O.colferSizeMax)
+						throw new SecurityException(format("colfer: gen.o.ss[%d] size %d exceeds %d UTF-8 bytes", ai, size, O.colferSizeMax));
+
+					int start = i;
+					i += size;
+					a[ai] = decodeUTF8(buf, start, size);
+				}
+				this.ss = a;
+				header = buf[i++];
+			}
+
+			if (header == (byte) 13) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.as length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
+
+				byte[][] a = new byte[length][];
+				for (int ai = 0; ai < length; ai++) {
+					int size = 0;
+					for (int shift = 0; true; shift += 7) {
+						byte b = buf[i++];
+						size |= (b & 0x7f) << shift;
+						if (shift == 28 || b >= 0) break;
+					}
+					if (size < 0 || size > O.colferSizeMax)
+						throw new SecurityException(format("colfer: gen.o.as[%d] size %d exceeds %d bytes", ai, size, O.colferSizeMax));
+					if (i + size >= end) {
+						i += size + 1; // no allocation before the data is there
+						throw new BufferUnderflowException();
+					}
+
+					byte[] e = new byte[size];
+					int start = i;
+					i += size;
+					System.arraycopy(buf, start, e, 0, size);
+					a[ai] = e;
+				}
+				this.as = a;
+
+				header = buf[i++];
+			}
+
+			if (header == (byte) 14) {
+				this.u8 = buf[i++];
+				header = buf[i++];
+			}
+
+			if (header == (byte) 15) {
+				this.u16 = (short) ((buf[i++] & 0xff) << 8 | (buf[i++] & 0xff));
+				header = buf[i++];
+			} else if (header == (byte) (15 | 0x80)) {
+				this.u16 = (short) (buf[i++] & 0xff);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 16) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.f32s length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length * 4 >= end) {
+					i += length * 4 + 1; // elements take 4 bytes each, plus a header
+					throw new BufferUnderflowException();
+				}
+
+				float[] a = new float[length];
+				for (int ai = 0; ai < length; ai++) {
+					int x = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+					a[ai] = Float.intBitsToFloat(x);
+				}
+				this.f32s = a;
+				header = buf[i++];
+			}
+
+			if (header == (byte) 17) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.f64s length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length * 8 >= end) {
+					i += length * 8 + 1; // elements take 8 bytes each, plus a header
+					throw new BufferUnderflowException();
+				}
+
+				double[] a = new double[length];
+				for (int ai = 0; ai < length; ai++) {
+					long x = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
+						| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+					a[ai] = Double.longBitsToDouble(x);
+				}
+				this.f64s = a;
+				header = buf[i++];
+			}
+
+			if (header != (byte) 0x7f)
+				throw new InputMismatchException(format("colfer: unknown header at byte %d", i - 1));
+		} finally {
+			if (i > end && end - offset < O.colferSizeMax) throw new BufferUnderflowException();
+			if (i < 0 || i - offset > O.colferSizeMax)
+				throw new SecurityException(format("colfer: gen.o exceeds %d bytes", O.colferSizeMax));
+			if (i > end) throw new BufferUnderflowException();
+		}
+
+		return i;
+	}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[],int,int)}, yet it
+	 * rejects any serial other than the one {@link #marshal(byte[],int)}
+	 * produces for the result. Equal values have identical serials as such.
+	 * Data after the serial, up to {@code end}, is rejected too. The check
+	 * serializes the result for comparison, which costs about as much as the
+	 * deserialization.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, when the encoding is not canonical, or when data continues after the serial.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+	 */
+	public int unmarshalCanonical(byte[] buf, int offset, int end) {
+		int i = unmarshal(buf, offset, end);
+		if (i != end)
+			throw new InputMismatchException(format("colfer: data continuation at byte %d", i));
+
+		byte[] serial = new byte[marshalFit()];
+		int n = marshal(serial, 0);
+		for (int j = 0; j < n; j++) {
+			if (offset + j >= i || serial[j] != buf[offset + j])
+				throw new InputMismatchException(format("colfer: non-canonical encoding at byte %d", offset + j));
+		}
+		if (offset + n != i)
+			throw new InputMismatchException(format("colfer: non-canonical encoding at byte %d", offset + n));
+		return i;
+	}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[],int,int)}, and
+	 * serializes the result like {@link #marshal(byte[],int)}, which gives the
+	 * canonical serial. Data after the serial, up to {@code end}, is rejected.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the canonical serial.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or when data continues after the serial.
+	 * @throws java.io.UncheckedIOException with a {@link java.nio.charset.MalformedInputException} cause on malformed UTF-8 in text.
+	 */
+	public byte[] canonicalize(byte[] buf, int offset, int end) {
+		int i = unmarshal(buf, offset, end);
+		if (i != end)
+			throw new InputMismatchException(format("colfer: data continuation at byte %d", i));
+
+		byte[] serial = new byte[marshalFit()];
+		return java.util.Arrays.copyOf(serial, marshal(serial, 0));
+	}
+
+	/**
+	 * Deserializes the fields which precede {@link #os}.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object.
+	 * @return the index of the following header in {@code buf}, which is not consumed.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 */
+	private int unmarshalHeadOs(byte[] buf, int offset, int end, int depth) {
+		if (end > buf.length) end = buf.length;
+		int i = offset;
+
+		try {
+			byte header = buf[i++];
+
+			if (header == (byte) 0) {
+				this.b = true;
+				header = buf[i++];
+			}
+
+			if (header == (byte) 1) {
+				int x = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					x |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				this.u32 = x;
+				header = buf[i++];
+			} else if (header == (byte) (1 | 0x80)) {
+				this.u32 = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 2) {
+				long x = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					if (shift == 56 || b >= 0) {
+						x |= (b & 0xffL) << shift;
+						break;
+					}
+					x |= (b & 0x7fL) << shift;
+				}
+				this.u64 = x;
+				header = buf[i++];
+			} else if (header == (byte) (2 | 0x80)) {
+				this.u64 = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
+					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 3) {
+				int x = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					x |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				this.i32 = x;
+				header = buf[i++];
+			} else if (header == (byte) (3 | 0x80)) {
+				int x = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					x |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				this.i32 = -x;
+				header = buf[i++];
+			}
+
+			if (header == (byte) 4) {
+				long x = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					if (shift == 56 || b >= 0) {
+						x |= (b & 0xffL) << shift;
+						break;
+					}
+					x |= (b & 0x7fL) << shift;
+				}
+				this.i64 = x;
+				header = buf[i++];
+			} else if (header == (byte) (4 | 0x80)) {
+				long x = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					if (shift == 56 || b >= 0) {
+						x |= (b & 0xffL) << shift;
+						break;
+					}
+					x |= (b & 0x7fL) << shift;
+				}
+				this.i64 = -x;
+				header = buf[i++];
+			}
+
+			if (header == (byte) 5) {
+				int x = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+				this.f32 = Float.intBitsToFloat(x);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 6) {
+				long x = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
+					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				this.f64 = Double.longBitsToDouble(x);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 7) {
+				long s = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				long ns = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				this.t = java.time.Instant.ofEpochSecond(s, ns);
+				header = buf[i++];
+			} else if (header == (byte) (7 | 0x80)) {
+				long s = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
+					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				long ns = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				this.t = java.time.Instant.ofEpochSecond(s, ns);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 8) {
+				int size = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					size |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (size < 0 || size > O.colferSizeMax)
+					throw new SecurityException(format("colfer: gen.o.s size %d exceeds %d UTF-8 bytes", size, O.colferSizeMax));
+
+				int start = i;
+				i += size;
+				this.s = decodeUTF8(buf, start, size);
+				header = buf[i++];
+			}
+
+			if (header == (byte) 9) {
+				int size = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					size |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (size < 0 || size > O.colferSizeMax)
+					throw new SecurityException(format("colfer: gen.o.a size %d exceeds %d bytes", size, O.colferSizeMax));
+				if (i + size >= end) {
+					i += size + 1; // no allocation before the data is there
+					throw new BufferUnderflowException();
+				}
+
+				this.a = new byte[size];
+				int start = i;
+				i += size;
+				System.arraycopy(buf, start, this.a, 0, size);
+
+				header = buf[i++];
+			}
+
+			if (header == (byte) 10) {
+				this.o = new O();
+				i = this.o.unmarshal(buf, i, end, depth + 1);
+				header = buf[i++];
+			}
+
+		} finally {
+			if (i > end && end - offset < O.colferSizeMax) throw new BufferUnderflowException();
+			if (i < 0 || i - offset > O.colferSizeMax)
+				throw new SecurityException(format("colfer: gen.o exceeds %d bytes", O.colferSizeMax));
+			if (i > end) throw new BufferUnderflowException();
+		}
+
+		return i - 1;
+	}
+
+	/**
+	 * Deserializes the fields which follow {@link #os}.
+	 * @param buf the data source.
+	 * @param offset the index of the header in {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the nesting level of this object.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 */
+	private int unmarshalTailOs(byte[] buf, int offset, int end, int depth) {
+		if (end > buf.length) end = buf.length;
+		int i = offset;
+
+		try {
+			byte header = buf[i++];
+
+			if (header == (byte) 12) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.ss length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				String[] a = new String[length];
 				for (int ai = 0; ai < length; ai++) {
@@ -905,7 +1428,7 @@ public class O implements Serializable {
 
 					int start = i;
 					i += size;
-					a[ai] = new String(buf, start, size, StandardCharsets.UTF_8);
+					a[ai] = decodeUTF8(buf, start, size);
 				}
 				this.ss = a;
 				header = buf[i++];
@@ -920,6 +1443,10 @@ public class O implements Serializable {
 				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.as length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length >= end) {
+					i += length + 1; // elements take one byte at least, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				byte[][] a = new byte[length][];
 				for (int ai = 0; ai < length; ai++) {
@@ -931,6 +1458,10 @@ public class O implements Serializable {
 					}
 					if (size < 0 || size > O.colferSizeMax)
 						throw new SecurityException(format("colfer: gen.o.as[%d] size %d exceeds %d bytes", ai, size, O.colferSizeMax));
+					if (i + size >= end) {
+						i += size + 1; // no allocation before the data is there
+						throw new BufferUnderflowException();
+					}
 
 					byte[] e = new byte[size];
 					int start = i;
@@ -965,6 +1496,10 @@ public class O implements Serializable {
 				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.f32s length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length * 4 >= end) {
+					i += length * 4 + 1; // elements take 4 bytes each, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				float[] a = new float[length];
 				for (int ai = 0; ai < length; ai++) {
@@ -984,6 +1519,10 @@ public class O implements Serializable {
 				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.f64s length %d exceeds %d elements", length, O.colferListMax));
+				if (i + length * 8 >= end) {
+					i += length * 8 + 1; // elements take 8 bytes each, plus a header
+					throw new BufferUnderflowException();
+				}
 
 				double[] a = new double[length];
 				for (int ai = 0; ai < length; ai++) {
@@ -1007,6 +1546,15 @@ public class O implements Serializable {
 		return i;
 	}
 
+	// decodeUTF8 returns the text at buf[start:start + size] conform RFC 3629.
+	private static String decodeUTF8(byte[] buf, int start, int size) {
+		try {
+			return StandardCharsets.UTF_8.newDecoder().decode(ByteBuffer.wrap(buf, start, size)).toString();
+		} catch (CharacterCodingException e) {
+			throw new UncheckedIOException(format("colfer: malformed UTF-8 at byte %d", start), e);
+		}
+	}
+
 	// {@link Serializable} version number.
 	private static final long serialVersionUID = 18L;
 
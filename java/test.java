@@ -4,11 +4,17 @@ import java.io.ByteArrayOutputStream;
 import java.io.ByteArrayInputStream;
 import java.io.ObjectInputStream;
 import java.io.ObjectOutputStream;
+import java.io.UncheckedIOException;
 import java.math.BigInteger;
+import java.nio.BufferUnderflowException;
 import java.nio.ByteBuffer;
+import java.nio.charset.MalformedInputException;
 import java.time.Instant;
+import java.util.ArrayList;
 import java.util.Arrays;
+import java.util.InputMismatchException;
 import java.util.LinkedHashMap;
+import java.util.List;
 import java.util.Map;
 import java.util.Map.Entry;
 import java.util.Set;
@@ -28,6 +34,7 @@ public class test {
 			marshal();
 			unmarshal();
 			stream();
+			streamList();
 
 			marshalMax();
 			marshalTextMax();
@@ -38,6 +45,14 @@ public class test {
 			unmarshalTextMax();
 			unmarshalBinaryMax();
 			unmarshalListMax();
+			unmarshalDepthMax();
+			unmarshalListBudget();
+
+			marshalTextMalformed();
+			unmarshalTextMalformed();
+
+			canonical();
+			fingerprint();
 
 			serializable();
 		} catch (Exception e) {
@@ -190,6 +205,36 @@ public class test {
 			fail("stream: data tail");
 	}
 
+	static void streamList() throws Exception {
+		Map<String, O> cases = newGoldenCases();
+		O composite = new O();
+		composite.s = "before";
+		composite.os = new O[] {new O(), cases.get("0a007f7f"), cases.get("0d0201000201027f")};
+		composite.u8 = 42;
+		cases.put("composite", composite);
+
+		ByteArrayOutputStream out = new ByteArrayOutputStream();
+		byte[] buf = new byte[1];
+		for (O o : cases.values()) {
+			buf = o.marshal(out, buf);
+		}
+
+		O.Unmarshaller unmarshaller = new O.Unmarshaller(new ByteArrayInputStream(out.toByteArray()), new byte[1]);
+		for (Entry<String, O> e : cases.entrySet()) {
+			List<O> elements = new ArrayList<>();
+			O got = unmarshaller.nextOs(elements::add);
+			if (got == null) {
+				fail("stream list: missing as of %s", e.getKey());
+				return;
+			}
+			got.os = elements.toArray(new O[0]);
+			if (! e.getValue().equals(got))
+				fail("stream list: mismatch for %s", e.getKey());
+		}
+		if (unmarshaller.nextOs(o -> {}) != null)
+			fail("stream list: data tail");
+	}
+
 	static void marshalMax() {
 		int origMax = O.colferSizeMax;
 		O.colferSizeMax = 2;
@@ -322,6 +367,164 @@ public class test {
 		}
 	}
 
+	static void unmarshalDepthMax() throws Exception {
+		int origMax = O.colferDepthMax;
+		O.colferDepthMax = 3;
+		try {
+			for (String hex : new String[]{"0a0a7f7f7f", "0b010b017f7f7f"}) {
+				byte[] serial = parseHex(hex);
+				int i = new O().unmarshal(serial, 0);
+				if (i != serial.length)
+					fail("0x%s: got read index %d with depth max 3", hex, i);
+			}
+
+			for (String hex : new String[]{"0a0a0a7f7f7f7f", "0b010a0a7f7f7f7f"}) {
+				try {
+					new O().unmarshal(parseHex(hex), 0);
+					fail("0x%s: no unmarshal depth max exception", hex);
+				} catch (SecurityException e) {
+					String want = "colfer: depth limit: struct gen.o exceeds 3 levels";
+					if (! want.equals(e.getMessage()))
+						fail("0x%s: unmarshal depth max error: %s\nwant: %s", hex, e.getMessage(), want);
+				}
+			}
+
+			// list elements from a stream continue at level two
+			byte[] serial = parseHex("0b010a0a7f7f7f7f");
+			O.Unmarshaller unmarshaller = new O.Unmarshaller(new ByteArrayInputStream(serial), null);
+			try {
+				unmarshaller.nextOs(o -> {});
+				fail("0x0b010a0a7f7f7f7f: no stream list depth max exception");
+			} catch (SecurityException e) {
+				if (! e.getMessage().startsWith("colfer: depth limit: "))
+					fail("0x0b010a0a7f7f7f7f: stream list depth max error: %s", e.getMessage());
+			}
+		} finally {
+			O.colferDepthMax = origMax;
+		}
+	}
+
+	static void unmarshalListBudget() {
+		// sizes and element counts with little data to back them
+		String[] sparse = {"09808080047f", "0d01808080047f", "0a09808080047f7f", "0b8080047f", "0a0b8080047f7f", "0b020b8080047f7f7f", "0c8080047f", "0d8080047f", "108080047f", "118080047f"};
+		for (String hex : sparse) {
+			try {
+				new O().unmarshal(parseHex(hex), 0);
+				fail("0x%s: no unmarshal buffer underflow", hex);
+			} catch (BufferUnderflowException e) {
+				// pass
+			}
+		}
+	}
+
+	static void fingerprint() {
+		// same in all languages, as in testdata
+		String want = "98768e7cc2fcda72";
+		if (! want.equals(O.colferFingerprint))
+			fail("got fingerprint %s, want %s", O.colferFingerprint, want);
+	}
+
+	static void marshalTextMalformed() {
+		String[] malformed = {"\ud800", "\udc00", "a\ud800b", "\udc00\ud800"};
+		for (String s : malformed) {
+			O o = new O();
+			o.s = s;
+			try {
+				o.marshal(new byte[o.marshalFit()], 0);
+				fail("no marshal exception for malformed text %s", Arrays.toString(s.toCharArray()));
+			} catch (UncheckedIOException e) {
+				if (! (e.getCause() instanceof MalformedInputException))
+					fail("marshal malformed text cause: %s", e.getCause());
+			}
+
+			o = new O();
+			o.ss = new String[]{"ok", s};
+			try {
+				o.marshal(new byte[o.marshalFit()], 0);
+				fail("no marshal exception for malformed text list %s", Arrays.toString(s.toCharArray()));
+			} catch (UncheckedIOException e) {
+				if (! (e.getCause() instanceof MalformedInputException))
+					fail("marshal malformed text list cause: %s", e.getCause());
+			}
+		}
+	}
+
+	static void unmarshalTextMalformed() {
+		String[] malformed = {"0801ff7f", "0802c0807f", "0803eda0807f", "080361e0807f", "0c020001ff7f", "0a0801ff7f7f"};
+		for (String hex : malformed) {
+			try {
+				new O().unmarshal(parseHex(hex), 0);
+				fail("0x%s: no unmarshal exception for malformed text", hex);
+			} catch (UncheckedIOException e) {
+				if (! (e.getCause() instanceof MalformedInputException))
+					fail("0x%s: unmarshal malformed text cause: %s", hex, e.getCause());
+			}
+		}
+	}
+
+	static void canonical() {
+		for (String hex : newGoldenCases().keySet()) {
+			byte[] serial = parseHex(hex);
+			try {
+				int i = new O().unmarshalCanonical(serial, 0, serial.length);
+				if (i != serial.length)
+					fail("0x%s: got canonical read index %d", hex, i);
+			} catch (InputMismatchException e) {
+				fail("0x%s: canonical unmarshal: %s", hex, e.getMessage());
+			}
+		}
+
+		String[][] noncanonical = {
+			// uint32 in fixed width
+			{"81000000017f", "01017f", "0"},
+			// uint32 in overlong varint
+			{"0181007f", "01017f", "1"},
+			// uint16 in 2 bytes
+			{"0f00ff7f", "8fff7f", "0"},
+			// int32 negative zero
+			{"83007f", "7f", "0"},
+			// float32 NaN with payload
+			{"057fc000017f", "057fc000007f", "4"},
+			// timestamp at Unix epoch
+			{"0700000000000000007f", "7f", "0"},
+			// empty list
+			{"0b007f", "7f", "0"},
+		};
+		for (String[] c : noncanonical) {
+			byte[] serial = parseHex(c[0]);
+			try {
+				new O().unmarshalCanonical(serial, 0, serial.length);
+				fail("0x%s: no canonical unmarshal exception", c[0]);
+			} catch (InputMismatchException e) {
+				String want = "colfer: non-canonical encoding at byte " + c[2];
+				if (! want.equals(e.getMessage()))
+					fail("0x%s: got canonical unmarshal exception %s, want %s", c[0], e.getMessage(), want);
+			}
+
+			String got = toHex(new O().canonicalize(serial, 0, serial.length));
+			if (! got.equals(c[1]))
+				fail("0x%s: canonicalized to 0x%s, want 0x%s", c[0], got, c[1]);
+		}
+
+		// data after the serial, same in all languages
+		byte[] tail = parseHex("01017f00");
+		String want = "colfer: data continuation at byte 3";
+		try {
+			new O().unmarshalCanonical(tail, 0, tail.length);
+			fail("0x01017f00: no canonical unmarshal exception");
+		} catch (InputMismatchException e) {
+			if (! want.equals(e.getMessage()))
+				fail("0x01017f00: got canonical unmarshal exception %s, want %s", e.getMessage(), want);
+		}
+		try {
+			new O().canonicalize(tail, 0, tail.length);
+			fail("0x01017f00: no canonicalize exception");
+		} catch (InputMismatchException e) {
+			if (! want.equals(e.getMessage()))
+				fail("0x01017f00: got canonicalize exception %s, want %s", e.getMessage(), want);
+		}
+	}
+
 	static void serializable() throws Exception {
 		Set<Entry<String, O>> cases = newGoldenCases().entrySet();
 		ByteArrayOutputStream buf = new ByteArrayOutputStream();
@@ -0,0 +1,155 @@
+package colfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+)
+
+// JSONSchemaField is the template data for a field with its limits.
+type jsonSchemaField struct {
+	*Field
+	// SizeLimit is the evaluated SizeMax.
+	SizeLimit int
+	// Base64Limit is the length of SizeLimit bytes in base64.
+	Base64Limit int
+	// ListLimit is the evaluated ListMax.
+	ListLimit int
+}
+
+// GenerateJSONSchema writes a JSON Schema (draft 2020-12) for each package into
+// file "<package>.schema.json". The schema describes the JSON from generated Go
+// code with option JSON, and it has a definition for each struct. Text limits
+// are in characters rather than bytes, and thus less restrictive.
+func GenerateJSONSchema(basedir string, packages Packages) error {
+	for _, p := range packages {
+		p.NameNative = p.Name
+		for _, t := range p.Structs {
+			t.NameNative = t.Name
+			for _, f := range t.Fields {
+				f.NameNative = f.Name
+				switch {
+				case f.TypeRef == nil:
+					f.TypeNative = f.Type
+				case f.TypeRef.Pkg == p:
+					f.TypeNative = "#/$defs/" + f.TypeRef.Name
+				default:
+					rel, err := filepath.Rel(filepath.FromSlash(path.Dir(p.Name)), filepath.FromSlash(f.TypeRef.Pkg.Name))
+					if err != nil {
+						return err
+					}
+					f.TypeNative = filepath.ToSlash(rel) + ".schema.json#/$defs/" + f.TypeRef.Name
+				}
+			}
+		}
+	}
+
+	limits := make(map[*Package]jsonSchemaField)
+	for _, p := range packages {
+		var l jsonSchemaField
+		var err error
+		l.SizeLimit, err = EvalLimit(p.SizeMax)
+		if err != nil {
+			return fmt.Errorf("colfer: package %s size limit %q: %w", p.Name, p.SizeMax, err)
+		}
+		l.Base64Limit = (l.SizeLimit + 2) / 3 * 4
+		l.ListLimit, err = EvalLimit(p.ListMax)
+		if err != nil {
+			return fmt.Errorf("colfer: package %s list limit %q: %w", p.Name, p.ListMax, err)
+		}
+		limits[p] = l
+	}
+
+	t := template.New("jsonschema-code").Funcs(template.FuncMap{
+		"json": func(s string) (string, error) {
+			bytes, err := json.Marshal(s)
+			return string(bytes), err
+		},
+		"limits": func(f *Field) jsonSchemaField {
+			l := limits[f.Struct.Pkg]
+			l.Field = f
+			return l
+		},
+	})
+	template.Must(t.Parse(jsonSchemaCode))
+	template.Must(t.New("type").Parse(jsonSchemaType))
+
+	for _, p := range packages {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, p); err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "\t"); err != nil {
+			return fmt.Errorf("colfer: package %s JSON Schema malformed: %w", p.Name, err)
+		}
+		out.WriteByte('\n')
+
+		path := filepath.Join(basedir, filepath.FromSlash(p.Name)+".schema.json")
+		if err := os.MkdirAll(filepath.Dir(path), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, out.Bytes(), 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const jsonSchemaCode = `{
+"$schema": "https://json-schema.org/draft/2020-12/schema",
+"$comment": {{json (printf "Code generated by colf(1); DO NOT EDIT. The compiler used schema file %s for package %s." .SchemaFileList .Name)}},
+"title": {{json .Name}},
+{{- if .Docs}}
+"description": {{json (.DocText "")}},
+{{- end}}
+"$defs": {
+{{- range $i, $s := .Structs}}{{if $i}},{{end}}
+{{json .Name}}: {
+	"type": "object",
+{{- if .Docs}}
+	"description": {{json (.DocText "")}},
+{{- end}}
+	"properties": {
+{{- range $j, $f := .Fields}}{{if $j}},{{end}}
+		{{json .Name}}: {
+{{- if .Docs}}
+			"description": {{json (.DocText "")}},
+{{- end}}
+{{- if .TypeList}}
+			"type": "array",
+			"maxItems": {{(limits .).ListLimit}},
+			"items": {
+				{{template "type" limits .}}
+			}
+{{- else}}
+			{{template "type" limits .}}
+{{- end}}
+		}
+{{- end}}
+	},
+	"additionalProperties": false
+}
+{{- end}}
+}
+}`
+
+const jsonSchemaType = `
+{{- if .TypeRef}}"anyOf": [{"$ref": {{json .TypeNative}}}, {"type": "null"}]
+{{- else if eq .Type "bool"}}"type": "boolean"
+{{- else if eq .Type "uint8"}}"type": "integer", "minimum": 0, "maximum": 255
+{{- else if eq .Type "uint16"}}"type": "integer", "minimum": 0, "maximum": 65535
+{{- else if eq .Type "uint32"}}"type": "integer", "minimum": 0, "maximum": 4294967295
+{{- else if eq .Type "uint64"}}"type": "integer", "minimum": 0, "maximum": 18446744073709551615
+{{- else if eq .Type "int32"}}"type": "integer", "minimum": -2147483648, "maximum": 2147483647
+{{- else if eq .Type "int64"}}"type": "integer", "minimum": -9223372036854775808, "maximum": 9223372036854775807
+{{- else if eq .Type "float32" "float64"}}"anyOf": [{"type": "number"}, {"enum": ["NaN", "Infinity", "-Infinity"]}]
+{{- else if eq .Type "timestamp"}}"anyOf": [{"type": "string", "format": "date-time"}, {"type": "number"}, {"type": "null"}]
+{{- else if eq .Type "text"}}"type": "string", "maxLength": {{.SizeLimit}}
+{{- else if eq .Type "binary"}}"type": "string", "contentEncoding": "base64", "maxLength": {{.Base64Limit}}
+{{- end}}`
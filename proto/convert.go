@@ -0,0 +1,319 @@
+package proto
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Scalars maps the Protocol Buffers types to their Colfer equivalent.
+var scalars = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint32":   "uint32",
+	"fixed32":  "uint32",
+	"uint64":   "uint64",
+	"fixed64":  "uint64",
+	"bool":     "bool",
+	"string":   "text",
+	"bytes":    "binary",
+}
+
+// ListTypes are the Colfer types which support lists.
+var listTypes = map[string]bool{
+	"float32": true,
+	"float64": true,
+	"int32":   true,
+	"int64":   true,
+	"text":    true,
+	"binary":  true,
+}
+
+// Colfer field indices are limited to 0–126, as 127 marks the end of a struct.
+const maxNumber = 127
+
+// Converter holds the definitions of all input files.
+type converter struct {
+	files    []*File
+	messages map[string]*Message // by FullName
+	enums    map[string]*Enum    // by FullName
+	pkgOf    map[string]*File    // by FullName
+	colfPkg  map[string]string   // Colfer package by Protocol Buffers package
+	issues   []Issue
+}
+
+// Convert returns a Colfer schema for each file, in order of appearance. The
+// field numbers of messages become Colfer field indices, minus one, such that
+// later additions remain compatible. Gaps in the numbering, and fields which
+// can not be converted, are filled with reserved placeholders. Enumerations
+// map to int32. Timestamps from google.protobuf.Timestamp map to timestamp.
+// Nested messages become structs with the path of names joined by underscores.
+// The Colfer package is the last element of the Protocol Buffers package, or
+// the file name without extension in case of no package.
+//
+// All constructs which could not be converted, are reported as issues, and
+// any such content is omitted from the schemas. The error return is for
+// conflicts in the input only.
+func Convert(files ...*File) (schemas [][]byte, issues []Issue, err error) {
+	c := &converter{
+		files:    files,
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+		pkgOf:    make(map[string]*File),
+		colfPkg:  make(map[string]string),
+	}
+	if err := c.index(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, f := range files {
+		var buf bytes.Buffer
+		for _, line := range f.Docs {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "package %s\n", c.colfPkg[f.Package])
+		c.writeMessages(&buf, f, f.Messages)
+
+		schema, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, nil, fmt.Errorf("colfer: %s: conversion produced malformed schema: %s", f.Path, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	// in order of appearance
+	for _, f := range files {
+		offset := len(issues)
+		issues = append(issues, f.Issues...)
+		for _, issue := range c.issues {
+			if issue.Path == f.Path {
+				issues = append(issues, issue)
+			}
+		}
+		fileIssues := issues[offset:]
+		sort.SliceStable(fileIssues, func(i, j int) bool {
+			return fileIssues[i].Line < fileIssues[j].Line
+		})
+	}
+	return schemas, issues, nil
+}
+
+// Index registers all definitions.
+func (c *converter) index() error {
+	var addMessages func(f *File, messages []*Message) error
+	addEnums := func(f *File, enums []*Enum) error {
+		for _, e := range enums {
+			if _, ok := c.pkgOf[e.FullName]; ok {
+				return fmt.Errorf("colfer: %s: duplicate definition of %s", f.Path, e.FullName)
+			}
+			c.enums[e.FullName] = e
+			c.pkgOf[e.FullName] = f
+		}
+		return nil
+	}
+	addMessages = func(f *File, messages []*Message) error {
+		for _, m := range messages {
+			if _, ok := c.pkgOf[m.FullName]; ok {
+				return fmt.Errorf("colfer: %s: duplicate definition of %s", f.Path, m.FullName)
+			}
+			c.messages[m.FullName] = m
+			c.pkgOf[m.FullName] = f
+			if err := addMessages(f, m.Messages); err != nil {
+				return err
+			}
+			if err := addEnums(f, m.Enums); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	byName := make(map[string]string) // Protocol Buffers package by Colfer package
+	for _, f := range c.files {
+		if err := addMessages(f, f.Messages); err != nil {
+			return err
+		}
+		if err := addEnums(f, f.Enums); err != nil {
+			return err
+		}
+
+		name := f.Package[strings.LastIndexByte(f.Package, '.')+1:]
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(f.Path), path.Ext(f.Path))
+		}
+		name = identifier(strings.ToLower(name))
+		if other, ok := byName[name]; ok && other != f.Package {
+			return fmt.Errorf("colfer: %s: package %q and %q both map to Colfer package %q", f.Path, other, f.Package, name)
+		}
+		byName[name] = f.Package
+		c.colfPkg[f.Package] = name
+	}
+	return nil
+}
+
+func (c *converter) writeMessages(buf *bytes.Buffer, f *File, messages []*Message) {
+	for _, m := range messages {
+		c.writeMessage(buf, f, m)
+		c.writeMessages(buf, f, m.Messages)
+	}
+}
+
+func (c *converter) issue(f *File, line int, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{
+		Path: f.Path,
+		Line: line,
+		Desc: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *converter) writeMessage(buf *bytes.Buffer, f *File, m *Message) {
+	buf.WriteByte('\n')
+	for _, line := range m.Docs {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", structName(m))
+	mName := strings.Join(m.Path, ".")
+
+	byNumber := make(map[int]*Field)
+	names := make(map[string]bool)
+	last := 0
+	for _, field := range m.Fields {
+		if field.Number < 1 || field.Number > maxNumber {
+			c.issue(f, field.Line, "field %s in message %s has number %d, which exceeds the Colfer range of 1–%d", field.Name, mName, field.Number, maxNumber)
+			continue
+		}
+		if dupe, ok := byNumber[field.Number]; ok {
+			c.issue(f, field.Line, "field %s in message %s has the same number as field %s", field.Name, mName, dupe.Name)
+			continue
+		}
+		byNumber[field.Number] = field
+		names[identifier(field.Name)] = true
+		if field.Number > last {
+			last = field.Number
+		}
+	}
+
+	for n := 1; n <= last; n++ {
+		field, ok := byNumber[n]
+		if !ok {
+			writePlaceholder(buf, n, names, "// Reserved is a placeholder for the unused field number %d.\n", n)
+			continue
+		}
+		typ, docs, ok := c.fieldType(f, m, field)
+		if !ok {
+			writePlaceholder(buf, n, names, "// Reserved is a placeholder for field %s (number %d), which is not converted.\n", field.Name, n)
+			continue
+		}
+		for _, line := range field.Docs {
+			fmt.Fprintf(buf, "\t%s\n", line)
+		}
+		for _, line := range docs {
+			fmt.Fprintf(buf, "\t%s\n", line)
+		}
+		fmt.Fprintf(buf, "\t%s %s\n", identifier(field.Name), typ)
+	}
+	buf.WriteString("}\n")
+}
+
+func writePlaceholder(buf *bytes.Buffer, n int, names map[string]bool, docFormat string, args ...interface{}) {
+	name := fmt.Sprintf("reserved%d", n)
+	for names[name] {
+		name += "_"
+	}
+	buf.WriteByte('\t')
+	fmt.Fprintf(buf, docFormat, args...)
+	fmt.Fprintf(buf, "\t%s bool\n", name)
+}
+
+// FieldType returns the Colfer type declaration with any additional
+// documentation, or false when field is not supported.
+func (c *converter) fieldType(f *File, m *Message, field *Field) (typ string, docs []string, ok bool) {
+	if field.KeyType != "" {
+		c.issue(f, field.Line, "map field %s in message %s is not supported", field.Name, strings.Join(m.Path, "."))
+		return "", nil, false
+	}
+
+	if scalar, ok := scalars[field.Type]; ok {
+		typ = scalar
+	} else if fullName := c.resolve(m.FullName, field.Type); fullName == "google.protobuf.Timestamp" {
+		typ = "timestamp"
+	} else if e, ok := c.enums[fullName]; ok {
+		typ = "int32"
+		values := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			values[i] = fmt.Sprintf("%s = %d", v.Name, v.Number)
+		}
+		docs = append(docs, fmt.Sprintf("// Values from enum %s: %s.", e.Name, strings.Join(values, ", ")))
+	} else if ref, ok := c.messages[fullName]; ok {
+		typ = structName(ref)
+		if refFile := c.pkgOf[fullName]; c.colfPkg[refFile.Package] != c.colfPkg[f.Package] {
+			typ = c.colfPkg[refFile.Package] + "." + typ
+		}
+		if field.Repeated {
+			return "[]" + typ, docs, true
+		}
+		return typ, docs, true
+	} else {
+		c.issue(f, field.Line, "field %s in message %s has unsupported type %s", field.Name, strings.Join(m.Path, "."), field.Type)
+		return "", nil, false
+	}
+
+	if field.Repeated {
+		if !listTypes[typ] {
+			c.issue(f, field.Line, "repeated field %s in message %s is not supported for type %s", field.Name, strings.Join(m.Path, "."), field.Type)
+			return "", nil, false
+		}
+		typ = "[]" + typ
+	}
+	return typ, docs, true
+}
+
+// Resolve returns the full name of a type reference from within a scope,
+// conform the Protocol Buffers scoping rules.
+func (c *converter) resolve(scope, name string) string {
+	if strings.HasPrefix(name, ".") {
+		return name[1:]
+	}
+	for {
+		fullName := name
+		if scope != "" {
+			fullName = scope + "." + name
+		}
+		if _, ok := c.pkgOf[fullName]; ok {
+			return fullName
+		}
+		if scope == "" {
+			return name
+		}
+		i := strings.LastIndexByte(scope, '.')
+		if i < 0 {
+			scope = ""
+		} else {
+			scope = scope[:i]
+		}
+	}
+}
+
+// StructName returns the Colfer name of a message.
+func structName(m *Message) string {
+	return identifier(strings.Join(m.Path, "_"))
+}
+
+// Identifier escapes Go keywords, as Colfer schemas use Go syntax.
+func identifier(name string) string {
+	if token.Lookup(name).IsKeyword() {
+		return name + "_"
+	}
+	return name
+}
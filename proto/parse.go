@@ -0,0 +1,661 @@
+// Package proto converts Protocol Buffers schemas to Colfer schemas.
+package proto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// File is a parsed .proto file.
+type File struct {
+	// Path is the source location.
+	Path string
+	// Syntax is the version, i.e., "proto2" or "proto3".
+	Syntax string
+	// Package is the optional package name, e.g., "foo.bar".
+	Package string
+	// Docs are the comments of the package statement.
+	Docs []string
+	// Messages are the top-level message definitions.
+	Messages []*Message
+	// Enums are the top-level enumerations.
+	Enums []*Enum
+	// Issues has the constructs which are not converted.
+	Issues []Issue
+}
+
+// Message is a message definition.
+type Message struct {
+	// Name is the identifier, without any parent scope.
+	Name string
+	// FullName is the qualified name, including the package.
+	FullName string
+	// Path is the sequence of message names, from top-level to Name.
+	Path []string
+	// Line is the position in the source.
+	Line int
+	// Docs are the comment lines.
+	Docs []string
+	// Fields are in order of appearance, including any oneof members.
+	Fields []*Field
+	// Messages are the nested message definitions.
+	Messages []*Message
+	// Enums are the nested enumerations.
+	Enums []*Enum
+	// Oneofs are the names of the oneof definitions.
+	Oneofs []string
+}
+
+// Field is a message member.
+type Field struct {
+	Name   string
+	Number int
+	// Type is the scalar type, or a (relative) message or enum reference.
+	Type string
+	// KeyType is set for map fields only.
+	KeyType  string
+	Repeated bool
+	// Oneof has the name of the parent oneof, if any.
+	Oneof string
+	// Line is the position in the source.
+	Line int
+	// Docs are the comment lines.
+	Docs []string
+}
+
+// Enum is an enumeration definition.
+type Enum struct {
+	// Name is the identifier, without any parent scope.
+	Name string
+	// FullName is the qualified name, including the package.
+	FullName string
+	Values   []EnumValue
+}
+
+// EnumValue is an enumeration constant.
+type EnumValue struct {
+	Name   string
+	Number int
+}
+
+// Issue is a construct which could not be converted.
+type Issue struct {
+	Path string
+	Line int
+	Desc string
+}
+
+// String returns the issue with its position.
+func (issue Issue) String() string {
+	return fmt.Sprintf("%s:%d: %s", issue.Path, issue.Line, issue.Desc)
+}
+
+// Lexeme is a lexical unit.
+type lexeme struct {
+	text string
+	line int
+	docs []string // leading comments
+}
+
+// Scanner splits .proto source into tokens.
+type scanner struct {
+	src  []byte
+	i    int  // read index
+	line int  // line number at read index
+	any  bool // whether a token was read
+}
+
+// Next returns the following token, with an empty text at the end of input.
+func (s *scanner) next() (lexeme, error) {
+	var docs []string
+	newlines := 0 // since last comment
+	sameLine := s.any
+	for s.i < len(s.src) {
+		c := s.src[s.i]
+		switch {
+		case c == '\n':
+			s.line++
+			s.i++
+			newlines++
+			sameLine = false
+			if newlines > 1 {
+				docs = nil // detached
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			s.i++
+			continue
+
+		case c == '/' && s.i+1 < len(s.src) && s.src[s.i+1] == '/':
+			end := s.i
+			for end < len(s.src) && s.src[end] != '\n' {
+				end++
+			}
+			if !sameLine {
+				// not a trailing comment of the previous token
+				docs = append(docs, strings.TrimRight(string(s.src[s.i:end]), " \t\r"))
+			}
+			s.i = end
+			newlines = 0
+			continue
+
+		case c == '/' && s.i+1 < len(s.src) && s.src[s.i+1] == '*':
+			end := strings.Index(string(s.src[s.i+2:]), "*/")
+			if end < 0 {
+				return lexeme{}, fmt.Errorf("%d: comment not terminated", s.line)
+			}
+			comment := string(s.src[s.i+2 : s.i+2+end])
+			s.i += end + 4
+			for _, l := range strings.Split(comment, "\n") {
+				l = strings.TrimSpace(l)
+				l = strings.TrimSpace(strings.TrimPrefix(l, "*"))
+				docs = append(docs, strings.TrimRight("// "+l, " "))
+			}
+			s.line += strings.Count(comment, "\n")
+			newlines = 0
+			continue
+		}
+
+		t := lexeme{line: s.line, docs: docs}
+		s.any = true
+		start := s.i
+		switch {
+		case c == '"' || c == '\'':
+			for s.i++; ; s.i++ {
+				if s.i >= len(s.src) || s.src[s.i] == '\n' {
+					return lexeme{}, fmt.Errorf("%d: string not terminated", t.line)
+				}
+				if s.src[s.i] == '\\' {
+					s.i++
+				} else if s.src[s.i] == c {
+					s.i++
+					break
+				}
+			}
+		case isWordByte(c) || c == '-' || c == '+':
+			for s.i++; s.i < len(s.src) && isWordByte(s.src[s.i]); s.i++ {
+			}
+		default:
+			s.i++
+		}
+		t.text = string(s.src[start:s.i])
+		return t, nil
+	}
+	return lexeme{line: s.line}, nil
+}
+
+// Trailing returns the comment which follows on the same line, if any.
+func (s *scanner) trailing() string {
+	i := s.i
+	for i < len(s.src) && (s.src[i] == ' ' || s.src[i] == '\t') {
+		i++
+	}
+	if i+1 >= len(s.src) || s.src[i] != '/' || s.src[i+1] != '/' {
+		return ""
+	}
+	end := i
+	for end < len(s.src) && s.src[end] != '\n' {
+		end++
+	}
+	s.i = end
+	return strings.TrimRight(string(s.src[i:end]), " \t\r")
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
+
+// Parser reads a File.
+type parser struct {
+	s    scanner
+	tok  lexeme // current
+	file *File
+}
+
+// Parse reads a .proto definition. Constructs which are not converted to
+// Colfer are recorded as issues, instead of causing an error.
+func Parse(path string, src []byte) (*File, error) {
+	p := &parser{s: scanner{src: src, line: 1}, file: &File{Path: path, Syntax: "proto2"}}
+	err := p.advance()
+	if err == nil {
+		err = p.parseFile()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("colfer: %s:%w", path, err)
+	}
+	return p.file, nil
+}
+
+func (p *parser) advance() error {
+	t, err := p.s.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%d: %s", p.tok.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) issue(line int, format string, args ...interface{}) {
+	p.file.Issues = append(p.file.Issues, Issue{
+		Path: p.file.Path,
+		Line: line,
+		Desc: fmt.Sprintf(format, args...),
+	})
+}
+
+// Expect consumes text.
+func (p *parser) expect(text string) error {
+	if p.tok.text != text {
+		if p.tok.text == "" {
+			return p.errorf("got end of input, want %q", text)
+		}
+		return p.errorf("got %q, want %q", p.tok.text, text)
+	}
+	return p.advance()
+}
+
+// Word consumes an identifier or a number.
+func (p *parser) word() (string, error) {
+	text := p.tok.text
+	if text == "" || !isWordByte(text[len(text)-1]) {
+		if text == "" {
+			return "", p.errorf("got end of input, want a name")
+		}
+		return "", p.errorf("got %q, want a name", text)
+	}
+	return text, p.advance()
+}
+
+// Number consumes an integer literal.
+func (p *parser) number() (int, error) {
+	text := p.tok.text
+	n, err := strconv.ParseInt(text, 0, 32)
+	if err != nil {
+		return 0, p.errorf("got %q, want an integer", text)
+	}
+	return int(n), p.advance()
+}
+
+// Str consumes a string literal.
+func (p *parser) str() (string, error) {
+	text := p.tok.text
+	if len(text) < 2 || (text[0] != '"' && text[0] != '\'') {
+		return "", p.errorf("got %q, want a string", text)
+	}
+	s, err := strconv.Unquote(`"` + strings.ReplaceAll(text[1:len(text)-1], `"`, `\"`) + `"`)
+	if err != nil {
+		s = text[1 : len(text)-1]
+	}
+	return s, p.advance()
+}
+
+// SkipStatement consumes up to and including the terminating semicolon, or
+// a block in braces.
+func (p *parser) skipStatement() error {
+	depth := 0
+	for {
+		switch p.tok.text {
+		case "":
+			return p.errorf("statement not terminated")
+		case "{", "[", "(", "<":
+			depth++
+		case "}", "]", ")", ">":
+			depth--
+			if depth == 0 && p.tok.text == "}" {
+				if err := p.advance(); err != nil {
+					return err
+				}
+				if p.tok.text == ";" {
+					return p.advance()
+				}
+				return nil
+			}
+		case ";":
+			if depth == 0 {
+				return p.advance()
+			}
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) parseFile() error {
+	for p.tok.text != "" {
+		line := p.tok.line
+		switch p.tok.text {
+		case ";":
+			if err := p.advance(); err != nil {
+				return err
+			}
+
+		case "syntax", "edition":
+			keyword := p.tok.text
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			s, err := p.str()
+			if err != nil {
+				return err
+			}
+			if keyword == "edition" {
+				s = "edition " + s
+			}
+			p.file.Syntax = s
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+
+		case "package":
+			p.file.Docs = p.tok.docs
+			if err := p.advance(); err != nil {
+				return err
+			}
+			name, err := p.word()
+			if err != nil {
+				return err
+			}
+			p.file.Package = name
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+
+		case "message":
+			m, err := p.parseMessage(nil)
+			if err != nil {
+				return err
+			}
+			p.file.Messages = append(p.file.Messages, m)
+
+		case "enum":
+			e, err := p.parseEnum(nil)
+			if err != nil {
+				return err
+			}
+			p.file.Enums = append(p.file.Enums, e)
+
+		case "service", "extend":
+			keyword := p.tok.text
+			if err := p.advance(); err != nil {
+				return err
+			}
+			p.issue(line, "%s %s is not converted", keyword, p.tok.text)
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+
+		case "import", "option":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+
+		default:
+			return p.errorf("unexpected %q", p.tok.text)
+		}
+	}
+	return nil
+}
+
+// FullName returns the qualified name of a definition in a parent scope.
+func (p *parser) fullName(parent *Message, name string) string {
+	switch {
+	case parent != nil:
+		return parent.FullName + "." + name
+	case p.file.Package != "":
+		return p.file.Package + "." + name
+	default:
+		return name
+	}
+}
+
+func (p *parser) parseMessage(parent *Message) (*Message, error) {
+	m := &Message{Line: p.tok.line, Docs: p.tok.docs}
+	if err := p.expect("message"); err != nil {
+		return nil, err
+	}
+	name, err := p.word()
+	if err != nil {
+		return nil, err
+	}
+	m.Name = name
+	m.FullName = p.fullName(parent, name)
+	if parent != nil {
+		m.Path = append(append([]string(nil), parent.Path...), name)
+	} else {
+		m.Path = []string{name}
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for p.tok.text != "}" {
+		line := p.tok.line
+		switch p.tok.text {
+		case "":
+			return nil, p.errorf("message %s not terminated", m.Name)
+		case ";":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+		case "message":
+			nested, err := p.parseMessage(m)
+			if err != nil {
+				return nil, err
+			}
+			m.Messages = append(m.Messages, nested)
+
+		case "enum":
+			e, err := p.parseEnum(m)
+			if err != nil {
+				return nil, err
+			}
+			m.Enums = append(m.Enums, e)
+
+		case "oneof":
+			if err := p.parseOneof(m); err != nil {
+				return nil, err
+			}
+
+		case "extend":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			p.issue(line, "extend %s in message %s is not converted", p.tok.text, strings.Join(m.Path, "."))
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+
+		case "option", "reserved", "extensions":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+
+		default:
+			f, err := p.parseField(m)
+			if err != nil {
+				return nil, err
+			}
+			if f != nil {
+				m.Fields = append(m.Fields, f)
+			}
+		}
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (p *parser) parseOneof(m *Message) error {
+	line := p.tok.line
+	if err := p.expect("oneof"); err != nil {
+		return err
+	}
+	name, err := p.word()
+	if err != nil {
+		return err
+	}
+	m.Oneofs = append(m.Oneofs, name)
+	p.issue(line, "oneof %s in message %s is not supported; its fields are converted as regular fields", name, strings.Join(m.Path, "."))
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	for p.tok.text != "}" {
+		switch p.tok.text {
+		case "":
+			return p.errorf("oneof %s not terminated", name)
+		case ";":
+			if err := p.advance(); err != nil {
+				return err
+			}
+		case "option":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			f, err := p.parseField(m)
+			if err != nil {
+				return err
+			}
+			if f != nil {
+				f.Oneof = name
+				m.Fields = append(m.Fields, f)
+			}
+		}
+	}
+	return p.advance()
+}
+
+// ParseField reads a field definition. The return is nil for groups, which
+// are recorded as an issue instead.
+func (p *parser) parseField(m *Message) (*Field, error) {
+	f := &Field{Line: p.tok.line, Docs: p.tok.docs}
+	switch p.tok.text {
+	case "repeated":
+		f.Repeated = true
+		fallthrough
+	case "optional", "required":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.tok.text == "group" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		p.issue(f.Line, "group %s in message %s is not supported", p.tok.text, strings.Join(m.Path, "."))
+		return nil, p.skipStatement()
+	}
+
+	typ, err := p.word()
+	if err != nil {
+		return nil, err
+	}
+	f.Type = typ
+	if typ == "map" && p.tok.text == "<" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if f.KeyType, err = p.word(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if f.Type, err = p.word(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Name, err = p.word(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if f.Number, err = p.number(); err != nil {
+		return nil, err
+	}
+	if p.tok.text == "[" {
+		// field options
+		for p.tok.text != "]" {
+			if p.tok.text == "" {
+				return nil, p.errorf("field options of %s not terminated", f.Name)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.tok.text != ";" {
+		return nil, p.errorf("got %q, want %q", p.tok.text, ";")
+	}
+	// trailing comment before the next token
+	if comment := p.s.trailing(); comment != "" {
+		f.Docs = append(f.Docs, comment)
+	}
+	return f, p.advance()
+}
+
+func (p *parser) parseEnum(parent *Message) (*Enum, error) {
+	if err := p.expect("enum"); err != nil {
+		return nil, err
+	}
+	name, err := p.word()
+	if err != nil {
+		return nil, err
+	}
+	e := &Enum{Name: name, FullName: p.fullName(parent, name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for p.tok.text != "}" {
+		switch p.tok.text {
+		case "":
+			return nil, p.errorf("enum %s not terminated", name)
+		case ";":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case "option", "reserved":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			var v EnumValue
+			if v.Name, err = p.word(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			if v.Number, err = p.number(); err != nil {
+				return nil, err
+			}
+			e.Values = append(e.Values, v)
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e, p.advance()
+}
@@ -0,0 +1,135 @@
+package proto
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/colfer"
+)
+
+func TestConvert(t *testing.T) {
+	paths := []string{"../testdata/proto/shop.proto", "../testdata/proto/money.proto"}
+	var files []*File
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := Parse(path, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	schemas, issues, err := Convert(files...)
+	if err != nil {
+		t.Fatal("conversion error:", err)
+	}
+	var goldenPaths []string
+	for i, path := range paths {
+		goldenPath := strings.TrimSuffix(path, ".proto") + ".colf"
+		goldenPaths = append(goldenPaths, goldenPath)
+		want, err := ioutil.ReadFile(goldenPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(schemas[i], want) {
+			t.Errorf("%s: got schema:\n%s\nwant:\n%s", path, schemas[i], want)
+		}
+	}
+	if _, err := colfer.ParseFiles(goldenPaths...); err != nil {
+		t.Error("golden schemas:", err)
+	}
+
+	wantIssues := []string{
+		"../testdata/proto/shop.proto:34: map field labels in message Order is not supported",
+		"../testdata/proto/shop.proto:35: repeated field flags in message Order is not supported for type bool",
+		"../testdata/proto/shop.proto:38: oneof payment in message Order is not supported; its fields are converted as regular fields",
+		"../testdata/proto/shop.proto:45: field legacy in message Order has number 200, which exceeds the Colfer range of 1–127",
+		"../testdata/proto/shop.proto:48: service Checkout is not converted",
+	}
+	if len(issues) != len(wantIssues) {
+		t.Errorf("got %d issues, want %d", len(issues), len(wantIssues))
+	}
+	for i, issue := range issues {
+		if i < len(wantIssues) && issue.String() != wantIssues[i] {
+			t.Errorf("got issue %q, want %q", issue, wantIssues[i])
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const src = `syntax = "proto2";
+message A {
+	message B {
+		message A {}
+		optional A inner = 1;
+		optional .A outer = 2;
+	}
+	required B b = 3;
+	optional C c = 4;
+	optional D d = 5;
+}
+message C {}
+`
+	f, err := Parse("scope.proto", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas, issues, err := Convert(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = `package scope
+
+type A struct {
+	// Reserved is a placeholder for the unused field number 1.
+	reserved1 bool
+	// Reserved is a placeholder for the unused field number 2.
+	reserved2 bool
+	b         A_B
+	c         C
+	// Reserved is a placeholder for field d (number 5), which is not converted.
+	reserved5 bool
+}
+
+type A_B struct {
+	inner A_B_A
+	outer A
+}
+
+type A_B_A struct {
+}
+
+type C struct {
+}
+`
+	if got := string(schemas[0]); got != want {
+		t.Errorf("got schema:\n%s\nwant:\n%s", got, want)
+	}
+	if len(issues) != 1 || issues[0].String() != "scope.proto:10: field d in message A has unsupported type D" {
+		t.Errorf("got issues %q", issues)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	golden := []struct{ src, want string }{
+		{`message A {`, "colfer: x.proto:1: message A not terminated"},
+		{`message A { int32 a = 1 }`, `colfer: x.proto:1: got "}", want ";"`},
+		{"message A {\n\tint32 a = b;\n}", `colfer: x.proto:2: got "b", want an integer`},
+		{`/* open`, "colfer: x.proto:1: comment not terminated"},
+		{`package "a";`, `colfer: x.proto:1: got "\"a\"", want a name`},
+	}
+	for _, gold := range golden {
+		_, err := Parse("x.proto", []byte(gold.src))
+		if err == nil {
+			t.Errorf("%q: no error", gold.src)
+		} else if err.Error() != gold.want {
+			t.Errorf("%q: got error %q, want %q", gold.src, err, gold.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package colfer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ProtobufTypes maps the Colfer datatypes to their proto3 equivalent.
+// Protocol Buffers has no integers smaller than 32 bits.
+var protobufTypes = map[string]string{
+	"bool":      "bool",
+	"uint8":     "uint32",
+	"uint16":    "uint32",
+	"uint32":    "uint32",
+	"uint64":    "uint64",
+	"int32":     "int32",
+	"int64":     "int64",
+	"float32":   "float",
+	"float64":   "double",
+	"timestamp": "google.protobuf.Timestamp",
+	"text":      "string",
+	"binary":    "bytes",
+}
+
+// ProtobufScalars are the type names which can not refer to a message.
+var protobufScalars = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true,
+	"uint32": true, "uint64": true, "sint32": true, "sint64": true,
+	"fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bool": true, "string": true, "bytes": true,
+}
+
+// GenerateProtobuf writes a proto3 schema for each package into file
+// "<package>.proto". The field numbers are the Colfer field indices plus one.
+// Both languages omit zero values on the wire, so the encodings do agree on
+// which fields are present, yet the serials are not interchangeable.
+func GenerateProtobuf(basedir string, packages Packages) error {
+	for _, p := range packages {
+		p.NameNative = strings.Replace(p.Name, "/", ".", -1)
+		for _, t := range p.Structs {
+			t.NameNative = t.Name
+			for _, f := range t.Fields {
+				f.NameNative = f.Name
+				if f.TypeRef == nil {
+					f.TypeNative = protobufTypes[f.Type]
+				} else if f.TypeRef.Pkg == p && !protobufScalars[f.TypeRef.Name] {
+					f.TypeNative = f.TypeRef.Name
+				} else {
+					f.TypeNative = "." + strings.Replace(f.TypeRef.Pkg.Name, "/", ".", -1) + "." + f.TypeRef.Name
+				}
+			}
+		}
+	}
+
+	t := template.New("protobuf-code").Funcs(template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	})
+	template.Must(t.Parse(protobufCode))
+
+	for _, p := range packages {
+		path := filepath.Join(basedir, filepath.FromSlash(p.Name)+".proto")
+		if err := os.MkdirAll(filepath.Dir(path), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := t.Execute(f, p); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const protobufCode = `// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}} for package {{.Name}}.
+// Colfer limits serial byte sizes to {{.SizeMax}}
+{{- if .HasList}}, and the number of elements in a list to {{.ListMax}}{{end}}.
+
+syntax = "proto3";
+{{if .Docs}}
+{{.DocText "// "}}
+{{- end}}
+package {{.NameNative}};
+{{if or .HasTimestamp .Refs}}
+{{- if .HasTimestamp}}
+import "google/protobuf/timestamp.proto";
+{{- end}}
+{{- range .Refs}}
+import "{{.Name}}.proto";
+{{- end}}
+{{end}}
+{{- range .Structs}}
+{{- if .Docs}}
+{{.DocText "// "}}
+{{- end}}
+message {{.NameNative}} {
+{{- range .Fields}}
+{{- if .Docs}}
+{{.DocText "\t// "}}
+{{- end}}
+	{{if .TypeList}}repeated {{end}}{{.TypeNative}} {{.NameNative}} = {{inc .Index}};
+{{- if eq .Type "uint8" "uint16"}} // {{.Type}}{{end}}
+{{- end}}
+}
+{{end}}`
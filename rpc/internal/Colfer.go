@@ -7,6 +7,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 var intconv = binary.BigEndian
@@ -15,6 +17,17 @@ var intconv = binary.BigEndian
 var (
 	// ColferSizeMax is the upper limit for serial byte sizes.
 	ColferSizeMax = 16 * 1024 * 1024
+	// ColferDepthMax is the upper limit for the nesting level of data
+	// structures, with 1 for the top-level. Data structures from another
+	// package count from 1 again, against the limit of their own package.
+	ColferDepthMax = 100
+)
+
+// Schema fingerprints change with any change to the fields of the respective
+// data structure, including the data structures referred to.
+const (
+	// ColferFingerprintHeader is the schema digest of Header.
+	ColferFingerprintHeader = "f610dd5aa29027e2"
 )
 
 // ColferMax signals an upper limit breach.
@@ -23,6 +36,12 @@ type ColferMax string
 // Error honors the error interface.
 func (m ColferMax) Error() string { return string(m) }
 
+// ColferDepth signals an upper limit breach of the nesting level.
+type ColferDepth string
+
+// Error honors the error interface.
+func (m ColferDepth) Error() string { return string(m) }
+
 // ColferError signals a data mismatch as as a byte index.
 type ColferError int
 
@@ -177,9 +196,228 @@ func (o *Header) MarshalBinary() (data []byte, err error) {
 	return data, nil
 }
 
+// MarshalAppend encodes o as Colfer to the end of dst and returns the extended
+// buffer. The capacity of dst grows as needed.
+// The error return option is ColferMax, in which case dst is returned as is.
+func (o *Header) MarshalAppend(dst []byte) ([]byte, error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return dst, err
+	}
+	offset := len(dst)
+	dst = append(dst, make([]byte, l)...)
+	o.MarshalTo(dst[offset:])
+	return dst, nil
+}
+
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
-// The error return options are io.EOF, ColferError and ColferMax.
+// The error return options are io.EOF, ColferError, ColferDepth and ColferMax.
 func (o *Header) Unmarshal(data []byte) (int, error) {
+	return o.unmarshal(data, 1)
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The depth is the nesting level of o.
+func (o *Header) unmarshal(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct internal.header exceeds %d levels", ColferDepthMax))
+	}
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.SeqID = x
+
+		header = data[i]
+		i++
+	} else if header == 0|0x80 {
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.SeqID = intconv.Uint64(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.method size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Method = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(ColferSizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.error size %d exceeds %d bytes", x, ColferSizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		o.Error = string(data[start:i])
+
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.BodySize = x
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.BodySize = intconv.Uint32(data[start:])
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < ColferSizeMax {
+		return i, nil
+	}
+eof:
+	if i >= ColferSizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct internal.header size exceeds %d bytes", ColferSizeMax))
+	}
+	return 0, io.EOF
+}
+
+// Reset sets all fields to their zero value.
+func (o *Header) Reset() {
+	*o = Header{}
+}
+
+// UnmarshalReuse decodes data as Colfer like Unmarshal, yet it resets o first.
+// Slices and nested structs from the previous content are recycled wherever
+// possible, which means that any references to them get overwritten.
+// Empty binaries and lists in data decode as non-nil, like with Unmarshal.
+// The error return options are io.EOF, ColferError, ColferDepth and ColferMax.
+func (o *Header) UnmarshalReuse(data []byte) (int, error) {
+	return o.unmarshalReuse(data, 1)
+}
+
+// UnmarshalReuse decodes data as Colfer like unmarshal, yet it resets o first.
+func (o *Header) unmarshalReuse(data []byte, depth int) (int, error) {
+	if depth > ColferDepthMax {
+		return 0, ColferDepth(fmt.Sprintf("colfer: depth limit: struct internal.header exceeds %d levels", ColferDepthMax))
+	}
+	*o = Header{}
+
 	if len(data) == 0 {
 		return 0, io.EOF
 	}
@@ -356,7 +594,7 @@ eof:
 }
 
 // UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
-// The error return options are io.EOF, ColferError, ColferTail and ColferMax.
+// The error return options are io.EOF, ColferError, ColferTail, ColferDepth and ColferMax.
 func (o *Header) UnmarshalBinary(data []byte) error {
 	i, err := o.Unmarshal(data)
 	if i < len(data) && err == nil {
@@ -364,3 +602,66 @@ func (o *Header) UnmarshalBinary(data []byte) error {
 	}
 	return err
 }
+
+// Equal returns whether o and other have the same Colfer serial. Thus nil and
+// empty lists are equal, nil entries in lists of data structures are equal to
+// new values, and all NaN floating points are considered equal.
+func (o *Header) Equal(other *Header) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.SeqID != other.SeqID {
+		return false
+	}
+
+	if o.Method != other.Method {
+		return false
+	}
+
+	if o.Error != other.Error {
+		return false
+	}
+
+	if o.BodySize != other.BodySize {
+		return false
+	}
+
+	return true
+}
+
+// Clone returns a deep copy of o.
+func (o *Header) Clone() *Header {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	return &c
+}
+
+// String returns the non-zero fields with their schema names.
+func (o *Header) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	var fields []string
+
+	if v := o.SeqID; v != 0 {
+		fields = append(fields, fmt.Sprint("seqID: ", v))
+	}
+
+	if v := o.Method; v != "" {
+		fields = append(fields, "method: "+strconv.Quote(v))
+	}
+
+	if v := o.Error; v != "" {
+		fields = append(fields, "error: "+strconv.Quote(v))
+	}
+
+	if v := o.BodySize; v != 0 {
+		fields = append(fields, fmt.Sprint("bodySize: ", v))
+	}
+
+	return "internal.header{" + strings.Join(fields, ", ") + "}"
+}
@@ -0,0 +1,62 @@
+// Package shop has domain types for conversion tests.
+package shop
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Status is a named integer.
+type Status int16
+
+// Tags is a named slice.
+type Tags []string
+
+// Order is a purchase.
+// It spans multiple lines of documentation.
+type Order struct {
+	// ID identifies the order.
+	ID     uint64
+	Status Status // trailing comment
+	Placed time.Time
+	// Lines are the products.
+	Lines []*Line
+	Ship  *Address
+	Bill  Address
+	Note  string
+	Tags  Tags
+	Scan  []byte
+	Sigs  [][]byte
+	Total float64
+	Count int
+	Flags []bool
+	Small []uint16
+	Attrs map[string]string
+	Link  *url.URL
+	When  *time.Time
+	Any   interface{}
+	Fixed [4]byte
+
+	sync.Mutex
+	secret string
+}
+
+// Line is an order entry, which is selected by reference only.
+type Line struct {
+	SKU      string
+	Quantity uint32
+	Price    float32
+}
+
+type Address struct {
+	Street  string
+	Country [2]byte
+}
+
+// Unrelated is not referred to by Order.
+type Unrelated struct {
+	X int8
+}
+
+type notStruct int